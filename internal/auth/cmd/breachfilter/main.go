@@ -0,0 +1,74 @@
+// Command breachfilter compiles a Have I Been Pwned SHA-1 file into the
+// Bloom filter format loaded by the auth service for breached-password screening.
+//
+//	go run ./cmd/breachfilter -in pwned-passwords-sha1-ordered-by-hash.txt -out breached.bloom
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/breach"
+)
+
+func main() {
+	in := flag.String("in", "", "Path to the HIBP SHA-1 file (HASH:COUNT per line)")
+	out := flag.String("out", "breached.bloom", "Path of the compiled Bloom filter")
+	fpRate := flag.Float64("fp-rate", 0.001, "Target false positive rate")
+	minCount := flag.Int("min-count", 0, "Skip hashes seen fewer times than this")
+	expected := flag.Uint64("expected", 0, "Number of entries to size the filter for (counted from the input when 0)")
+	flag.Parse()
+
+	if *in == "" {
+		log.Fatal("input file must be provided with -in flag")
+	}
+
+	if *expected == 0 {
+		n, err := countLines(*in)
+		if err != nil {
+			log.Fatalf("failed to count entries: %v", err)
+		}
+		*expected = n
+	}
+
+	src, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("failed to open input: %v", err)
+	}
+	defer src.Close()
+
+	filter, err := breach.CompileBloomFilter(src, *expected, *fpRate, *minCount)
+	if err != nil {
+		log.Fatalf("failed to compile bloom filter: %v", err)
+	}
+
+	dst, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("failed to create output: %v", err)
+	}
+	size, err := filter.WriteTo(dst)
+	if err != nil {
+		log.Fatalf("failed to write bloom filter: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		log.Fatalf("failed to close output: %v", err)
+	}
+	log.Printf("compiled %d entries into %s (%d bytes)", *expected, *out, size)
+}
+
+func countLines(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	return n, scanner.Err()
+}
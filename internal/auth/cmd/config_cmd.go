@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	authconfig "github.com/istiak-004/myFolio-microservices/auth/config"
+	"github.com/istiak-004/myFolio-microservices/pkg/config"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  config    print the effective config, where each value comes from, and whether it is valid")
+	fmt.Fprintln(out, "\nWithout a command the service is started.\n\nFlags:")
+	flag.PrintDefaults()
+}
+
+// runConfigCommand prints the merged config with secrets redacted and the
+// source of each key. It returns the exit code: 1 when the config is invalid,
+// so deploy scripts can check a config before rolling it out.
+func runConfigCommand(configDir string, out io.Writer) int {
+	report, err := config.Inspect(serviceName, configDir, &authconfig.AuthConfig{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load configuration:", err)
+		return 1
+	}
+	if err := report.Print(out); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to print configuration:", err)
+		return 1
+	}
+	if report.Err != nil {
+		return 1
+	}
+	return 0
+}
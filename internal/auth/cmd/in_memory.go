@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	authconfig "github.com/istiak-004/myFolio-microservices/auth/config"
+	handler "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/handlers"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	device_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/device"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/mail"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/memory"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	pkghttp "github.com/istiak-004/myFolio-microservices/pkg/http"
+	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// runInMemory serves the auth API with every adapter kept in process memory,
+// so no Postgres, Redis or SMTP server is needed. Tokens are signed with a
+// key generated at startup and emails are captured for /dev/mail instead of
+// being sent. It blocks until a shutdown signal arrives or the server fails.
+func runInMemory(log *logger.Logger, cfg *authconfig.AuthConfig, shutdownCh <-chan os.Signal) error {
+	jwt, err := token.NewEphemeralTokenManager(cfg.JWT.Issuer, cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
+	if err != nil {
+		return err
+	}
+
+	templates, err := mail.NewTemplates(cfg.Mail.TemplatesDir, cfg.Mail.DefaultLocale)
+	if err != nil {
+		return err
+	}
+	capture := cfg.Mail.Capture
+	capture.Storage = mail.CaptureMemory
+	mailer, err := mail.NewCaptureMailer(capture, templates)
+	if err != nil {
+		return err
+	}
+
+	users := memory.NewUserRepository()
+	verifications := memory.NewVerificationRepository()
+	tokens := memory.NewTokenRepository()
+	deviceService := device_service.NewDeviceService(
+		memory.NewDeviceRepository(), users, tokens, verifications, mailer, nil, cfg.Links.DeviceReportURL,
+	)
+	authService := auth_service.NewAuthService(users, verifications, tokens, jwt, cfg.JWT.TokenLifetimes, mailer,
+		auth_service.WithDeviceTracking(deviceService),
+		auth_service.WithVerificationURL(cfg.Links.VerifyEmailURL),
+		auth_service.WithPasswordResetURL(cfg.Links.PasswordResetURL),
+	)
+
+	server := pkghttp.NewServer(pkghttp.ServerConfig{
+		Mode:            gin.DebugMode,
+		Port:            cfg.HTTP.Port,
+		ReadTimeout:     cfg.HTTP.Timeout,
+		WriteTimeout:    cfg.HTTP.Timeout,
+		ShutdownTimeout: cfg.HTTP.ShutdownTimeout,
+		TrustedProxies:  cfg.HTTP.TrustedProxies,
+	}, log)
+	server.RegisterMiddleware(middleware.RequestContext(log), middleware.LoggingMiddleware(), middleware.SecurityHeaders())
+	handler.NewAuthHandler(server.Router(), authService, deviceService, cfg.ProofOfWork, middleware.NewRateLimiter(cfg.HTTP.AuthRateLimit))
+	if err := handler.NewDevMailboxHandler(server.Router(), mailer, cfg.App.Environment); err != nil {
+		return err
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+	log.Warn("Running in memory: all state is lost on exit and tokens are signed with a throwaway key",
+		zap.Int("port", cfg.HTTP.Port),
+		zap.String("mailbox", "/dev/mail"),
+	)
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case sig := <-shutdownCh:
+		log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	}
+	return server.Shutdown()
+}
@@ -2,34 +2,71 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	authconfig "github.com/istiak-004/myFolio-microservices/auth/config"
+	grpcapi "github.com/istiak-004/myFolio-microservices/auth/internal/api/grpc"
+	authhttp "github.com/istiak-004/myFolio-microservices/auth/internal/api/http"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/api/http/utils"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	account_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/account"
+	admin_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/admin"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	device_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/device"
+	mailqueue_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/mailqueue"
+	oauth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/oauth"
+	organization_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/organization"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
+	profile_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/profile"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/breach"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/events"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/geo"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/mail"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/worker"
 	"github.com/istiak-004/myFolio-microservices/pkg/config"
 	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
+	pkghttp "github.com/istiak-004/myFolio-microservices/pkg/http"
+	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
 	"github.com/istiak-004/myFolio-microservices/pkg/logger"
-
-	// "github.com/istiak-004/myFolio-microservices/pkg/server"
 	"go.uber.org/zap"
 )
 
 const (
-	serviceName     = "auth"
-	shutdownTimeout = 15 * time.Second
+	serviceName      = "auth"
+	defaultConfigDir = "../config"
+	shutdownTimeout  = 15 * time.Second
 )
 
 func main() {
-	// Initialize context with cancellation
-	_, cancel := context.WithCancel(context.Background())
-	
-	defer cancel()
+	inMemory := flag.Bool("in-memory", false, "run without Postgres, Redis or SMTP; all state is kept in memory and lost on exit")
+	configDir := flag.String("config-dir", defaultConfigDir, "directory holding config.yml, its config.<env>.yml overlays and config.local.yml")
+	flag.Usage = usage
+	flag.Parse()
 
-	// Initialize logger
+	switch flag.Arg(0) {
+	case "":
+	case "config":
+		os.Exit(runConfigCommand(*configDir, os.Stdout))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Bootstrap logger, replaced by the configured one once the config is loaded
 	log := logger.NewLogger(serviceName)
-	defer log.Sync() // Ensure logs are flushed
 
 	// Setup signal handling for graceful shutdown
 	shutdownCh := make(chan os.Signal, 1)
@@ -37,88 +74,310 @@ func main() {
 
 	// Load configuration
 	log.Info("Loading configuration...")
-	var authConfig authconfig.AuthConfig
-	baseConfig, appConfig, err := config.LoadConfig(serviceName, "../config", &authConfig)
+	watcher, err := config.NewWatcher(serviceName, *configDir, func() *authconfig.AuthConfig {
+		return &authconfig.AuthConfig{}
+	})
 	if err != nil {
 		log.Fatal("Failed to load configuration", zap.Error(err))
 	}
+	baseConfig, appConfig := watcher.Base(), watcher.Current()
+
+	log.Sync()
+	if log, err = logger.New(serviceName, appConfig.Log); err != nil {
+		logger.NewLogger(serviceName).Fatal("Invalid log configuration", zap.Error(err))
+	}
+	defer log.Close() // Ensure logs are flushed
 
 	log.Info("Configuration loaded successfully",
 		zap.Any("base_config", baseConfig),
-		zap.Any("app_config", appConfig),
+		zap.Any("app_config", config.Redact(appConfig)),
 	)
 
-	// Initialize database
-	log.Info("Initializing database connection...")
-	dbClient, err := database.NewDB(&appConfig.Database, log)
+	// Log level, CORS origins, rate limit and features follow edits to the
+	// config file; invalid edits are rejected and logged
+	live := newLiveSettings(appConfig)
+	if err := watchConfig(watcher, live, log); err != nil {
+		log.Fatal("Failed to watch configuration", zap.Error(err))
+	}
+
+	// Apply the password hashing policy before any password is hashed or verified
+	hasher, err := hashing.New(appConfig.PasswordHashing)
 	if err != nil {
-		log.Fatal("Failed to initialize database", zap.Error(err))
+		log.Fatal("Invalid password hashing policy", zap.Error(err))
+	}
+	hashing.SetDefault(hasher)
+
+	if err := valueobjects.SetPasswordPolicy(appConfig.PasswordPolicy); err != nil {
+		log.Fatal("Invalid password policy", zap.Error(err))
+	}
+
+	// Screen new passwords against the local breached-password corpus
+	if appConfig.BreachedPasswords.Enabled {
+		source, err := breach.Open(appConfig.BreachedPasswords.Source, appConfig.BreachedPasswords.Path, appConfig.BreachedPasswords.MinCount)
+		if err != nil {
+			log.Fatal("Failed to load breached password corpus", zap.Error(err))
+		}
+		valueobjects.SetBreachedPasswordChecker(breach.NewChecker(source))
+		log.Info("Breached password screening enabled", zap.String("source", appConfig.BreachedPasswords.Source))
+	}
+
+	cookie := appConfig.Cookie
+	cookie.MaxAge = appConfig.JWT.RefreshExpiry
+	utils.SetCookieConfig(cookie)
+	configureOAuth(appConfig.OAuth)
+
+	// Standalone mode for local demos and end-to-end tests
+	if *inMemory {
+		if err := runInMemory(log, appConfig, shutdownCh); err != nil {
+			log.Fatal("In-memory server failed", zap.Error(err))
+		}
+		return
+	}
+
+	if err := run(log, appConfig, live, shutdownCh); err != nil {
+		log.Fatal("Auth service failed", zap.Error(err))
 	}
+	log.Info("Service shutdown completed")
+}
+
+// run wires the service from its configuration and serves until a shutdown
+// signal arrives or a server fails. Shutdown drains HTTP and gRPC first, then
+// stops the background workers, and only then closes the database, Redis and
+// the SMTP pool, so nothing in flight loses its connections.
+func run(log *logger.Logger, cfg *authconfig.AuthConfig, live *liveSettings, shutdownCh <-chan os.Signal) error {
+	var closers []func()
 	defer func() {
-		log.Info("Closing database connection...")
-		if err := dbClient.Close(); err != nil {
-			log.Error("Failed to close database connection", zap.Error(err))
+		for _, closeFn := range closers {
+			closeFn()
+		}
+	}()
+
+	// Infrastructure
+	log.Info("Initializing database connection...")
+	dbClient, err := openDatabase(cfg, log)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	closers = append(closers, closeWith(log, "database", dbClient.Close))
+
+	var redisClient *database.RedisClient
+	if !usesSQLite(cfg) || cfg.Events.Broker == "redis" {
+		log.Info("Connecting to Redis...", zap.String("addr", cfg.Redis.Addr))
+		if redisClient, err = database.NewRedisClient(cfg, log); err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		closers = append(closers, closeWith(log, "redis", redisClient.Close))
+	}
+
+	var repos *repositories
+	if usesSQLite(cfg) {
+		repos = newSQLiteRepositories(dbClient, log)
+	} else {
+		repos = newPostgresRepositories(dbClient, redisClient, log)
+	}
+
+	jwt, err := token.NewTokenManager(cfg.JWT.PrivateKeyPath, cfg.JWT.PublicKeyPath, cfg.JWT.Issuer, cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+
+	// Mail: SMTP or the development mailbox, optionally behind the durable queue
+	templates, err := mail.NewTemplates(cfg.Mail.TemplatesDir, cfg.Mail.DefaultLocale)
+	if err != nil {
+		return err
+	}
+	var (
+		delivery ports.Mailer
+		mailbox  ports.Mailbox
+	)
+	if cfg.Mail.Capture.Enabled {
+		capture, err := mail.NewCaptureMailer(cfg.Mail.Capture, templates)
+		if err != nil {
+			return err
+		}
+		delivery, mailbox = capture, capture
+		log.Warn("Capturing outgoing emails instead of sending them", zap.String("mailbox", "/dev/mail"))
+	} else {
+		smtpMailer, err := mail.NewSMTPMailer(cfg.SMTP, templates)
+		if err != nil {
+			return fmt.Errorf("failed to configure SMTP: %w", err)
+		}
+		delivery = smtpMailer
+		closers = append(closers, closeWith(log, "SMTP pool", smtpMailer.Close))
+	}
+	mailer := delivery
+	var mailQueue ports.MailQueue
+	if cfg.Mail.Queue.Enabled {
+		mailQueue = mailqueue_service.NewMailQueue(repos.mailQueue, repos.transactor, delivery, cfg.Mail.Queue.Retry)
+		mailer = mailQueue
+	}
+
+	var publisher ports.EventPublisher = events.NewLogPublisher(log)
+	if cfg.Events.Broker == "redis" {
+		publisher = events.NewRedisStreamPublisher(redisClient, cfg.Events.Stream, cfg.Events.MaxLen)
+	}
+
+	var locator ports.GeoLocator = geo.NoopLocator{}
+	if cfg.GeoIP.Path != "" {
+		csvLocator, err := geo.LoadCSVLocator(cfg.GeoIP.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load GeoIP database: %w", err)
+		}
+		locator = csvLocator
+	}
+
+	// Domain services
+	deviceService := device_service.NewDeviceService(
+		repos.devices, repos.users, repos.tokens, repos.verifications, mailer, locator, cfg.Links.DeviceReportURL,
+	)
+	authService := auth_service.NewAuthService(repos.users, repos.verifications, repos.tokens, jwt, cfg.JWT.TokenLifetimes, mailer,
+		auth_service.WithPasswordHistory(repos.passwordHistory),
+		auth_service.WithDeviceTracking(deviceService),
+		auth_service.WithVerificationURL(cfg.Links.VerifyEmailURL),
+		auth_service.WithPasswordResetURL(cfg.Links.PasswordResetURL),
+		auth_service.WithTransactor(repos.transactor),
+		auth_service.WithOutbox(repos.outbox),
+		auth_service.WithOrganizations(repos.organizations),
+	)
+	accountService := account_service.NewAccountService(
+		repos.users, repos.tokens, repos.pats, repos.audit, publisher, cfg.AccountDeletion.GracePeriod,
+	)
+	patService := pat_service.NewPersonalAccessTokenService(repos.pats, repos.users)
+	var oauthService ports.OAuthService
+	if cfg.OAuth.Google.ClientID != "" {
+		oauthService = oauth_service.NewOAuthService(repos.users, repos.tokens, jwt, cfg.JWT.TokenLifetimes)
+	}
+
+	// HTTP
+	mode := gin.ReleaseMode
+	if cfg.App.IsDevelopment() {
+		mode = gin.DebugMode
+	}
+	server := pkghttp.NewServer(pkghttp.ServerConfig{
+		Mode:            mode,
+		Port:            cfg.HTTP.Port,
+		ReadTimeout:     cfg.HTTP.Timeout,
+		WriteTimeout:    cfg.HTTP.Timeout,
+		ShutdownTimeout: cfg.HTTP.ShutdownTimeout,
+		TrustedProxies:  cfg.HTTP.TrustedProxies,
+	}, log)
+	server.RegisterMiddleware(
+		middleware.RequestContext(log),
+		middleware.LoggingMiddleware(),
+		middleware.SecurityHeaders(),
+		live.cors.Middleware(),
+		live.rateLimiter.Middleware(),
+	)
+	err = authhttp.RegisterRoutes(server.Router(), authhttp.Services{
+		Auth:                authService,
+		OAuth:               oauthService,
+		Device:              deviceService,
+		Account:             accountService,
+		Profile:             profile_service.NewProfileService(repos.users),
+		Organization:        organization_service.NewOrganizationService(repos.organizations, repos.users, repos.tokens, jwt, cfg.JWT.TokenLifetimes),
+		PersonalAccessToken: patService,
+		Impersonation:       admin_service.NewImpersonationService(repos.users, jwt, repos.audit),
+		Audit:               repos.audit,
+		MailQueue:           mailQueue,
+		Mailbox:             mailbox,
+		Environment:         cfg.App.Environment,
+	}, authmw.NewJWTMiddleware(*jwt, patService), cfg.ProofOfWork, live.authRateLimiter, live.features)
+	if err != nil {
+		return err
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		if err := server.Start(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("HTTP server: %w", err)
 		}
 	}()
 
-	log.Info("Database connection established successfully")
+	var grpcServer *grpcapi.Server
+	if cfg.GRPC.Enabled {
+		grpcServer = grpcapi.NewServer(authService, jwt, patService, cfg.GRPC.ServiceTokens, log)
+		go func() {
+			if err := grpcServer.ListenAndServe(cfg.GRPC.Port); err != nil {
+				serverErr <- fmt.Errorf("gRPC server: %w", err)
+			}
+		}()
+	}
 
-	// // Initialize services
-	// // Example: authService := auth.NewService(dbClient, log)
+	// Background workers stop when ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+	startWorker(worker.NewAccountPurger(accountService, cfg.AccountDeletion.PurgeInterval, log).Run)
+	startWorker(worker.NewOutboxRelay(repos.transactor, repos.outbox, publisher, cfg.Events.RelayInterval, cfg.Events.BatchSize, log).Run)
+	if mailQueue != nil {
+		startWorker(worker.NewMailSender(mailQueue, cfg.Mail.Queue.PollInterval, log).Run)
+		startWorker(worker.NewMailPurger(mailQueue, cfg.Mail.Queue.PurgeInterval, log).Run)
+	}
 
-	// // Initialize HTTP server
-	// log.Info("Starting HTTP server...")
-	// srv := server.NewHTTPServer(appConfig.Server, log /*, authService */)
+	log.Info("Service started successfully",
+		zap.String("version", cfg.App.Version),
+		zap.Int("port", cfg.HTTP.Port),
+	)
 
-	// go func() {
-	// 	if err := srv.Start(); err != nil {
-	// 		log.Error("HTTP server error", zap.Error(err))
-	// 		shutdownCh <- syscall.SIGTERM
-	// 	}
-	// }()
+	var runErr error
+	select {
+	case runErr = <-serverErr:
+		log.Error("Server failed, shutting down", zap.Error(runErr))
+	case sig := <-shutdownCh:
+		log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	}
 
-	// log.Info("Service started successfully",
-	// 	zap.String("service", serviceName),
-	// 	zap.String("version", baseConfig.Version),
-	// )
+	log.Info("Initiating graceful shutdown...")
+	if err := server.Shutdown(); err != nil {
+		log.Error("HTTP server shutdown error", zap.Error(err))
+	} else {
+		log.Info("HTTP server stopped gracefully")
+	}
+	if grpcServer != nil {
+		grpcCtx, grpcCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := grpcServer.Shutdown(grpcCtx); err != nil {
+			log.Error("gRPC server shutdown error", zap.Error(err))
+		}
+		grpcCancel()
+	}
 
-	// // Wait for shutdown signal
-	// sig := <-shutdownCh
-	// log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Warn("Background workers did not stop in time")
+	}
+	return runErr
+}
 
-	// // Graceful shutdown
-	// gracefulShutdown(ctx, log, srv, dbClient)
+// configureOAuth applies the Google client registration to the OAuth flow
+func configureOAuth(cfg authconfig.OAuth) {
+	google := oauth_service.GoogleOAuthConfig
+	google.ClientID = cfg.Google.ClientID
+	google.ClientSecret = cfg.Google.ClientSecret
+	if cfg.Google.RedirectURL != "" {
+		google.RedirectURL = cfg.Google.RedirectURL
+	}
 }
 
-// func gracefulShutdown(
-// 	ctx context.Context,
-// 	log *zap.Logger,
-// 	srv *server.HTTPServer,
-// 	dbClient database.DatabaseManager,
-// ) {
-// 	// Create a deadline for shutdown operations
-// 	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
-// 	defer cancel()
-
-// 	log.Info("Initiating graceful shutdown...")
-
-// 	// Shutdown HTTP server
-// 	if srv != nil {
-// 		if err := srv.Shutdown(shutdownCtx); err != nil {
-// 			log.Error("HTTP server shutdown error", zap.Error(err))
-// 		} else {
-// 			log.Info("HTTP server stopped gracefully")
-// 		}
-// 	}
-
-// 	// Close database connection
-// 	if dbClient != nil {
-// 		if err := dbClient.Close(); err != nil {
-// 			log.Error("Database connection closure error", zap.Error(err))
-// 		} else {
-// 			log.Info("Database connection closed gracefully")
-// 		}
-// 	}
-
-// 	log.Info("Service shutdown completed")
-// }
+// closeWith returns a function closing a resource and logging the outcome
+func closeWith(log *logger.Logger, name string, close func() error) func() {
+	return func() {
+		log.Info("Closing " + name + "...")
+		if err := close(); err != nil {
+			log.Error("Failed to close "+name, zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	authconfig "github.com/istiak-004/myFolio-microservices/auth/config"
+	"github.com/istiak-004/myFolio-microservices/pkg/config"
+	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// liveSettings are the parts of the HTTP stack that follow config reloads.
+// Everything else is read once at startup and needs a restart to change.
+type liveSettings struct {
+	cors            *middleware.CORS
+	rateLimiter     *middleware.RateLimiter
+	authRateLimiter *middleware.RateLimiter
+	features        *middleware.FeatureFlags
+}
+
+func newLiveSettings(cfg *authconfig.AuthConfig) *liveSettings {
+	return &liveSettings{
+		cors:            middleware.NewCORS(cfg.HTTP.AllowedOrigins),
+		rateLimiter:     middleware.NewRateLimiter(cfg.HTTP.RateLimit),
+		authRateLimiter: middleware.NewRateLimiter(cfg.HTTP.AuthRateLimit),
+		features:        middleware.NewFeatureFlags(cfg.Features),
+	}
+}
+
+// watchConfig applies reloaded log level, CORS origins, rate limits and
+// feature flags, and logs reloads that were rejected
+func watchConfig(watcher *config.Watcher[*authconfig.AuthConfig], live *liveSettings, log *logger.Logger) error {
+	watcher.OnError(func(err error) {
+		log.Error("Keeping the previous configuration", zap.Error(err))
+	})
+	watcher.Subscribe(func(_, _ *authconfig.AuthConfig) {
+		log.Info("Configuration reloaded")
+	})
+
+	config.OnChange(watcher, func(c *authconfig.AuthConfig) string { return c.Log.Level },
+		func(old, new string) {
+			if err := log.SetLevel(new); err != nil {
+				log.Error("Failed to change log level", zap.Error(err))
+				return
+			}
+			log.Info("Log level changed", zap.String("from", old), zap.String("to", new))
+		})
+	config.OnChange(watcher, func(c *authconfig.AuthConfig) []string { return c.HTTP.AllowedOrigins },
+		func(_, new []string) {
+			live.cors.SetAllowedOrigins(new)
+			log.Info("CORS origins changed", zap.Strings("allowed_origins", new))
+		})
+	config.OnChange(watcher, func(c *authconfig.AuthConfig) authconfig.RateLimit { return c.HTTP.RateLimit },
+		func(_, new authconfig.RateLimit) {
+			live.rateLimiter.Update(new)
+			log.Info("Rate limit changed",
+				zap.Bool("enabled", new.Enabled),
+				zap.Int("requests", new.Requests),
+				zap.Duration("interval", new.Interval),
+			)
+		})
+	config.OnChange(watcher, func(c *authconfig.AuthConfig) authconfig.RateLimit { return c.HTTP.AuthRateLimit },
+		func(_, new authconfig.RateLimit) {
+			live.authRateLimiter.Update(new)
+			log.Info("Auth rate limit changed",
+				zap.Bool("enabled", new.Enabled),
+				zap.Int("requests", new.Requests),
+				zap.Duration("interval", new.Interval),
+			)
+		})
+	config.OnChange(watcher, func(c *authconfig.AuthConfig) authconfig.Features { return c.Features },
+		func(_, new authconfig.Features) {
+			live.features.Set(new)
+			log.Info("Feature flags changed", zap.Any("features", new))
+		})
+
+	return watcher.Watch()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	authconfig "github.com/istiak-004/myFolio-microservices/auth/config"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/postgres"
+	redisrepo "github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/redis"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+// repositories are the persistence adapters of the configured storage driver
+type repositories struct {
+	users           ports.UserRepository
+	verifications   ports.VerificationRepository
+	tokens          ports.TokenRepository
+	pats            ports.PersonalAccessTokenRepository
+	passwordHistory ports.PasswordHistoryRepository
+	audit           ports.AuditRepository
+	organizations   ports.OrganizationRepository
+	devices         ports.DeviceRepository
+	mailQueue       ports.MailQueueRepository
+	outbox          ports.OutboxRepository
+	transactor      ports.Transactor
+}
+
+// usesSQLite reports whether the sqlite storage driver is configured
+func usesSQLite(cfg *authconfig.AuthConfig) bool {
+	return cfg.Storage.Driver == "sqlite"
+}
+
+// openDatabase connects to the configured storage driver. The SQLite file is
+// migrated on open since there is no separate migrate step for it.
+func openDatabase(cfg *authconfig.AuthConfig, log *logger.Logger) (*database.Client, error) {
+	if !usesSQLite(cfg) {
+		return database.NewDB(&cfg.Database, log)
+	}
+	db, err := database.NewSQLite(cfg.Storage.SQLite, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlite.Migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// newPostgresRepositories keeps refresh tokens in Redis and everything else in Postgres
+func newPostgresRepositories(db *database.Client, rdb *database.RedisClient, log *logger.Logger) *repositories {
+	return &repositories{
+		users:           postgres.NewUserRepository(db, log),
+		verifications:   postgres.NewVerificationRepository(db),
+		tokens:          redisrepo.NewTokenRepository(rdb),
+		pats:            postgres.NewPersonalAccessTokenRepository(db),
+		passwordHistory: postgres.NewPasswordHistoryRepository(db),
+		audit:           postgres.NewAuditRepository(db),
+		organizations:   postgres.NewOrganizationRepository(db),
+		devices:         postgres.NewDeviceRepository(db),
+		mailQueue:       postgres.NewMailQueueRepository(db),
+		outbox:          postgres.NewOutboxRepository(db),
+		transactor:      postgres.NewTransactor(db, log),
+	}
+}
+
+// newSQLiteRepositories keeps all state, refresh tokens included, in the SQLite file
+func newSQLiteRepositories(db *database.Client, log *logger.Logger) *repositories {
+	return &repositories{
+		users:           sqlite.NewUserRepository(db),
+		verifications:   sqlite.NewVerificationRepository(db),
+		tokens:          sqlite.NewTokenRepository(db),
+		pats:            sqlite.NewPersonalAccessTokenRepository(db),
+		passwordHistory: sqlite.NewPasswordHistoryRepository(db),
+		audit:           sqlite.NewAuditRepository(db),
+		organizations:   sqlite.NewOrganizationRepository(db),
+		devices:         sqlite.NewDeviceRepository(db),
+		mailQueue:       sqlite.NewMailQueueRepository(db),
+		outbox:          sqlite.NewOutboxRepository(db),
+		transactor:      sqlite.NewTransactor(db, log),
+	}
+}
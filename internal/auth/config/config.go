@@ -1,28 +1,166 @@
 package config
 
+import (
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/api/http/utils"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	mailqueue_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/mailqueue"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/mail"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
+	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+// Redis configures the connection holding refresh tokens and the event stream
 type Redis struct {
-	Addr     string `mapstructure:"addr" validate:"required"`
-	Password string `mapstructure:"password" validate:"required"`
-	DB       int    `mapstructure:"db" validate:"required"`
-	PoolSize int    `mapstructure:"pool_size" validate:"required"`
+	Addr        string        `mapstructure:"addr" validate:"required,hostname_port"`
+	Password    string        `mapstructure:"password" secret:"true"`
+	DB          int           `mapstructure:"db"`
+	PoolSize    int           `mapstructure:"pool_size"`
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+}
+
+// JWT configures signing and lifetimes of access and refresh tokens
+type JWT struct {
+	Issuer         string `mapstructure:"issuer" validate:"required"`
+	PrivateKeyPath string `mapstructure:"private_key_path" validate:"required"` // PKCS#1 PEM
+	PublicKeyPath  string `mapstructure:"public_key_path" validate:"required"`  // PKIX PEM
+	TokenLifetimes `mapstructure:",squash"`
+}
+
+// TokenLifetimes are the lifetimes of access and refresh tokens
+type TokenLifetimes = models.TokenLifetimes
+
+// Cookie configures the refresh token cookie
+type Cookie = utils.CookieConfig
+
+// OAuth holds the client credentials of the supported identity providers
+type OAuth struct {
+	Google OAuthProvider `mapstructure:"google"`
+	GitHub OAuthProvider `mapstructure:"github"`
 }
 
-type SMTP struct {
-	Host     string `mapstructure:"host" validate:"required"`
-	Port     int    `mapstructure:"port" validate:"required"`
-	Username string `mapstructure:"username" validate:"required"`
-	Password string `mapstructure:"password" validate:"required"`
-	From     string `mapstructure:"from" validate:"required"`
+// OAuthProvider is an OAuth client registration; a provider without a
+// client ID is disabled
+type OAuthProvider struct {
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret" validate:"required_with=ClientID" secret:"true"`
+	RedirectURL  string `mapstructure:"redirect_url" validate:"required_with=ClientID,omitempty,url"`
 }
 
+// SMTP configures the SMTP transport, including TLS, pooling and DKIM
+type SMTP = mail.SMTPConfig
+
+// AuthConfig is the complete configuration of the auth service. It is read
+// from config.yml; every key can be overridden by an AUTH_ prefixed
+// environment variable, e.g. AUTH_JWT_ACCESS_EXPIRY for jwt.access_expiry.
 type AuthConfig struct {
-	App          AppConfig      `mapstructure:"app" validate:"required"`
-	Database     DatabaseConfig `mapstructure:"database" validate:"required,dive"`
-	HTTP         HTTPConfig     `mapstructure:"http" validate:"required,dive"`
-	Log          LogConfig      `mapstructure:"log" validate:"required,dive"`
-	JWTIssuer    string         `mapstructure:"jwt_issuer" validate:"required"`
-	JWTExpiry    string         `mapstructure:"jwt_expiry" validate:"required"`
-	CookieDomain string         `mapstructure:"cookie_domain" validate:"required"`
+	App      AppConfig      `mapstructure:"app"`
+	Database DatabaseConfig `mapstructure:"database"`
+	HTTP     HTTPConfig     `mapstructure:"http"`
+	Log      LogConfig      `mapstructure:"log"`
+	JWT      JWT            `mapstructure:"jwt"`
+	Cookie   Cookie         `mapstructure:"cookie"`
+	OAuth    OAuth          `mapstructure:"oauth"`
+
+	PasswordHashing   hashing.Policy    `mapstructure:"password_hashing"`
+	BreachedPasswords BreachedPasswords `mapstructure:"breached_passwords"`
+	PasswordPolicy    PasswordPolicy    `mapstructure:"password_policy"`
+	AccountDeletion   AccountDeletion   `mapstructure:"account_deletion"`
+	Links             Links             `mapstructure:"links"`
+	GeoIP             GeoIP             `mapstructure:"geoip"`
+	ProofOfWork       ProofOfWork       `mapstructure:"proof_of_work"`
+	GRPC              GRPC              `mapstructure:"grpc"`
+	Events            Events            `mapstructure:"events"`
+	Mail              Mail              `mapstructure:"mail"`
+	SMTP              SMTP              `mapstructure:"smtp"`
+	Storage           Storage           `mapstructure:"storage"`
+	Redis             Redis             `mapstructure:"redis"`
+	Features          Features          `mapstructure:"features" validate:"dive,keys,oneof=registration organizations personal_access_tokens impersonation data_export,endkeys"`
+}
+
+// Features switches optional parts of the API on and off; a feature that is
+// not listed is on. Reloaded without a restart.
+type Features map[string]bool
+
+// Storage selects where users, tokens and the queues are persisted
+type Storage struct {
+	Driver string `mapstructure:"driver" validate:"omitempty,oneof=postgres sqlite"` // defaults to postgres
+	SQLite SQLite `mapstructure:"sqlite"`
+}
+
+// SQLite configures the embedded database used when Storage.Driver is sqlite
+type SQLite = database.SQLiteConfig
+
+// Mail configures email rendering and delivery
+type Mail struct {
+	TemplatesDir  string      `mapstructure:"templates_dir"`  // overrides the embedded templates file by file
+	DefaultLocale string      `mapstructure:"default_locale"` // used when a user's locale has no templates
+	Queue         MailQueue   `mapstructure:"queue"`
+	Capture       MailCapture `mapstructure:"capture"`
+}
+
+// MailCapture keeps outgoing emails in a local mailbox instead of sending
+// them, browsable at /dev/mail. Development only.
+type MailCapture = mail.CaptureConfig
+
+// MailQueue configures the durable queue emails are sent through
+type MailQueue struct {
+	Enabled       bool                     `mapstructure:"enabled"`
+	PollInterval  time.Duration            `mapstructure:"poll_interval"`
+	PurgeInterval time.Duration            `mapstructure:"purge_interval"` // how often sent and dead emails past their retention are deleted
+	Retry         mailqueue_service.Config `mapstructure:",squash"`
+}
+
+// Events configures how domain events are relayed from the outbox to other services
+type Events struct {
+	Broker        string        `mapstructure:"broker" validate:"omitempty,oneof=log redis"`
+	Stream        string        `mapstructure:"stream"`  // Redis stream name
+	MaxLen        int64         `mapstructure:"max_len"` // approximate stream length cap, 0 keeps every entry
+	RelayInterval time.Duration `mapstructure:"relay_interval"`
+	BatchSize     int           `mapstructure:"batch_size"`
+}
+
+// GRPC configures the internal gRPC API used by other services
+type GRPC struct {
+	Enabled       bool              `mapstructure:"enabled"`
+	Port          int               `mapstructure:"port" validate:"required_if=Enabled true"`
+	ServiceTokens map[string]string `mapstructure:"service_tokens" secret:"true"` // caller name -> bearer token
+}
+
+// ProofOfWork configures the anti-bot challenge on registration and login
+type ProofOfWork = middleware.ProofOfWorkConfig
+
+// Links configures the pages that emails link to
+type Links struct {
+	VerifyEmailURL   string `mapstructure:"verify_email_url" validate:"omitempty,url"`
+	PasswordResetURL string `mapstructure:"password_reset_url" validate:"omitempty,url"`
+	DeviceReportURL  string `mapstructure:"device_report_url" validate:"omitempty,url"`
+}
+
+// GeoIP configures the offline IP database used to show approximate login locations
+type GeoIP struct {
+	Path string `mapstructure:"path"` // DB-IP lite CSV; empty disables lookups
+}
+
+// AccountDeletion configures how long self-deleted accounts are kept before they are purged
+type AccountDeletion struct {
+	GracePeriod   time.Duration `mapstructure:"grace_period"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// PasswordPolicy configures the rules for new passwords
+type PasswordPolicy = valueobjects.PasswordPolicy
+
+// BreachedPasswords configures screening against a local breached-password corpus
+type BreachedPasswords struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Source   string `mapstructure:"source" validate:"required_if=Enabled true,omitempty,oneof=hibp_file hibp_range_dir bloom"`
+	Path     string `mapstructure:"path" validate:"required_if=Enabled true"`
+	MinCount int    `mapstructure:"min_count"`
 }
 
 type AppConfig struct {
@@ -31,29 +169,40 @@ type AppConfig struct {
 	Version     string `mapstructure:"version" validate:"required"`
 }
 
+// EnvironmentDevelopment is the app.environment of local development, the
+// only one in which debugging aids such as the dev mailbox are served
+const EnvironmentDevelopment = "development"
+
+func (a AppConfig) IsDevelopment() bool {
+	return a.Environment == EnvironmentDevelopment
+}
+
 // DatabaseConfig represents database configuration
 type DatabaseConfig struct {
-	Host            string `mapstructure:"host" validate:"required"`
-	Port            int    `mapstructure:"port" validate:"required"`
-	User            string `mapstructure:"user" validate:"required"`
-	Password        string `mapstructure:"password" validate:"required"`
-	Name            string `mapstructure:"name" validate:"required"`
-	SSLMode         string `mapstructure:"sslmode" validate:"required"`
-	MaxOpenConns    int    `mapstructure:"max_open_conns" validate:"required"`
-	MaxIdleConns    int    `mapstructure:"max_idle_conns" validate:"required"`
-	ConnMaxLifetime string `mapstructure:"conn_max_lifetime" validate:"required"`
+	Host            string        `mapstructure:"host" validate:"required"`
+	Port            int           `mapstructure:"port" validate:"required"`
+	User            string        `mapstructure:"user" validate:"required"`
+	Password        string        `mapstructure:"password" validate:"required" secret:"true"`
+	Name            string        `mapstructure:"name" validate:"required"`
+	SSLMode         string        `mapstructure:"sslmode" validate:"required,oneof=disable allow prefer require verify-ca verify-full"`
+	MaxOpenConns    int           `mapstructure:"max_open_conns" validate:"required"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"` // 0 selects 30 minutes
 }
 
 // HTTPConfig represents HTTP server configuration
 type HTTPConfig struct {
-	Port            int `mapstructure:"port" validate:"required"`
-	Timeout         int `mapstructure:"timeout" validate:"required"`
-	ShutdownTimeout int `mapstructure:"shutdown_timeout" validate:"required"`
+	Port            int           `mapstructure:"port" validate:"required"`
+	Timeout         time.Duration `mapstructure:"timeout" validate:"required"`             // read and write timeout
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"required"`    // how long in-flight requests get to finish
+	AllowedOrigins  []string      `mapstructure:"allowed_origins"`                         // CORS; "*" allows any origin
+	TrustedProxies  []string      `mapstructure:"trusted_proxies" validate:"dive,ip|cidr"` // whose X-Forwarded-For sets the client IP
+	RateLimit       RateLimit     `mapstructure:"rate_limit"`
+	AuthRateLimit   RateLimit     `mapstructure:"auth_rate_limit"` // stricter limit of the /auth endpoints
 }
 
-// LogConfig represents logging configuration
-type LogConfig struct {
-	Level  string `mapstructure:"level" validate:"required"`
-	Format string `mapstructure:"format" validate:"required"`
-	Output string `mapstructure:"output" validate:"required"`
-}
+// RateLimit limits requests per client IP across the whole HTTP API
+type RateLimit = middleware.RateLimitConfig
+
+// LogConfig configures the level, format and outputs of the service logs
+type LogConfig = logger.Config
@@ -1,16 +1,56 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
 )
 
+// Validate checks the configuration. Sections the configured drivers do not
+// use are skipped: Postgres settings with SQLite storage, Redis when nothing
+// needs it, and SMTP while emails are captured.
 func (a *AuthConfig) Validate() error {
-	validator := validator.New()
-	if err := validator.Struct(a); err != nil {
+	var unused []string
+	if a.Storage.Driver == "sqlite" {
+		unused = append(unused, "Database")
+		if a.Events.Broker != "redis" {
+			unused = append(unused, "Redis")
+		}
+	} else {
+		unused = append(unused, "Storage.SQLite")
+	}
+	if a.Mail.Capture.Enabled {
+		unused = append(unused, "SMTP")
+	}
+	if err := validator.New().StructExcept(a, unused...); err != nil {
 		return err
 	}
+	// the mailbox is served without authentication
+	if a.Mail.Capture.Enabled && !a.App.IsDevelopment() {
+		return errors.New("mail.capture.enabled is only allowed when app.environment is " + EnvironmentDevelopment)
+	}
+	if a.GRPC.Enabled {
+		return a.GRPC.validateServiceTokens()
+	}
+	return nil
+}
+
+// validateServiceTokens refuses a gRPC API no caller could authenticate to,
+// and callers left without a token
+func (g GRPC) validateServiceTokens() error {
+	if len(g.ServiceTokens) == 0 {
+		return errors.New("grpc.service_tokens must name at least one caller when grpc.enabled is true")
+	}
+	for name, token := range g.ServiceTokens {
+		if token == "" {
+			return fmt.Errorf("grpc.service_tokens.%s is empty", name)
+		}
+	}
 	return nil
 }
 
@@ -48,10 +88,27 @@ func (c *DatabaseConfig) GetMaxIdleConns() int {
 }
 
 func (c *DatabaseConfig) GetConnMaxLifetime() time.Duration {
-	duration, err := time.ParseDuration(c.ConnMaxLifetime)
-	if err != nil {
-		// Return a default value or handle the error as appropriate
+	if c.ConnMaxLifetime <= 0 {
 		return 30 * time.Minute
 	}
-	return duration
+	return c.ConnMaxLifetime
+}
+
+// GetRedisConfig implements database.HasRedisCionfig
+func (a *AuthConfig) GetRedisConfig() *database.RedisConfig {
+	host, port := a.Redis.Addr, 6379
+	if h, p, err := net.SplitHostPort(a.Redis.Addr); err == nil {
+		host = h
+		if n, err := strconv.Atoi(p); err == nil {
+			port = n
+		}
+	}
+	return &database.RedisConfig{
+		Host:        host,
+		Port:        port,
+		Password:    a.Redis.Password,
+		DB:          a.Redis.DB,
+		PoolSize:    a.Redis.PoolSize,
+		DialTimeout: a.Redis.DialTimeout,
+	}
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	pkgconfig "github.com/istiak-004/myFolio-microservices/pkg/config"
+)
+
+// shipped loads the config.yml next to this file
+func shipped(t *testing.T) *AuthConfig {
+	t.Helper()
+	_, cfg, err := pkgconfig.LoadConfig("auth", ".", &AuthConfig{})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+func TestShippedConfigLoads(t *testing.T) {
+	cfg := shipped(t)
+	if cfg.JWT.AccessExpiry != 15*time.Minute || cfg.JWT.RefreshExpiry != 720*time.Hour {
+		t.Errorf("jwt lifetimes = %v and %v, want 15m and 720h", cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
+	}
+	if cfg.HTTP.AuthRateLimit.Requests != 10 || cfg.HTTP.AuthRateLimit.Interval != time.Minute {
+		t.Errorf("auth rate limit = %+v, want 10 per minute", cfg.HTTP.AuthRateLimit)
+	}
+	if cfg.Mail.Queue.Retry.MaxAttempts != 8 {
+		t.Errorf("mail.queue.max_attempts = %d, want 8", cfg.Mail.Queue.Retry.MaxAttempts)
+	}
+	if cfg.Log.Rotation.MaxSizeMB != 100 || !cfg.Log.Rotation.Compress {
+		t.Errorf("log rotation = %+v", cfg.Log.Rotation)
+	}
+}
+
+func TestNestedKeysAreOverriddenFromTheEnvironment(t *testing.T) {
+	t.Setenv("AUTH_JWT_ACCESS_EXPIRY", "5m")
+	t.Setenv("AUTH_HTTP_RATE_LIMIT_REQUESTS", "7")
+	t.Setenv("AUTH_OAUTH_GITHUB_CLIENT_ID", "gh-client")
+	t.Setenv("AUTH_OAUTH_GITHUB_CLIENT_SECRET", "gh-secret")
+
+	cfg := shipped(t)
+	if cfg.JWT.AccessExpiry != 5*time.Minute {
+		t.Errorf("jwt.access_expiry = %v, want 5m", cfg.JWT.AccessExpiry)
+	}
+	if cfg.HTTP.RateLimit.Requests != 7 {
+		t.Errorf("http.rate_limit.requests = %d, want 7", cfg.HTTP.RateLimit.Requests)
+	}
+	if cfg.OAuth.GitHub.ClientID != "gh-client" || cfg.OAuth.GitHub.ClientSecret != "gh-secret" {
+		t.Errorf("oauth.github = %+v", cfg.OAuth.GitHub)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*AuthConfig)
+		wantErr bool
+	}{
+		{"shipped", func(*AuthConfig) {}, false},
+		{"missing jwt issuer", func(c *AuthConfig) { c.JWT.Issuer = "" }, true},
+		{"unknown storage driver", func(c *AuthConfig) { c.Storage.Driver = "mysql" }, true},
+		{"postgres without a database host", func(c *AuthConfig) { c.Database.Host = "" }, true},
+		{"sqlite ignores the database section", func(c *AuthConfig) {
+			c.Storage.Driver = "sqlite"
+			c.Database = DatabaseConfig{}
+			c.Redis = Redis{}
+		}, false},
+		{"sqlite with the redis broker needs redis", func(c *AuthConfig) {
+			c.Storage.Driver = "sqlite"
+			c.Events.Broker = "redis"
+			c.Redis = Redis{}
+		}, true},
+		{"oauth client without a secret", func(c *AuthConfig) { c.OAuth.Google.ClientID = "google-client" }, true},
+		{"unknown feature", func(c *AuthConfig) { c.Features["teleport"] = true }, true},
+		{"untrusted proxy that is not an address", func(c *AuthConfig) { c.HTTP.TrustedProxies = []string{"lb.internal"} }, true},
+		{"mail capture in development", func(c *AuthConfig) { c.Mail.Capture.Enabled = true; c.SMTP = SMTP{} }, false},
+		{"mail capture in production", func(c *AuthConfig) {
+			c.Mail.Capture.Enabled = true
+			c.App.Environment = "production"
+		}, true},
+		{"grpc without service tokens", func(c *AuthConfig) { c.GRPC.Enabled = true }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := shipped(t)
+			tt.edit(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGRPCRequiresServiceTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  map[string]string
+		wantErr bool
+	}{
+		{"none", nil, true},
+		{"empty", map[string]string{}, true},
+		{"blank token", map[string]string{"gateway": "s3cret", "portfolio": ""}, true},
+		{"configured", map[string]string{"gateway": "s3cret"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GRPC{Enabled: true, Port: 9090, ServiceTokens: tt.tokens}.validateServiceTokens()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methods callable without a service token, such as health checks
+var publicMethods = map[string]bool{
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+}
+
+type callerKey struct{}
+
+// CallerFromContext returns the name of the service that made the call
+func CallerFromContext(ctx context.Context) (string, bool) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	return caller, ok
+}
+
+// AuthInterceptor only admits calls carrying one of the configured service
+// tokens, keyed by the calling service's name, as "authorization: Bearer <token>"
+func AuthInterceptor(serviceTokens map[string]string) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "missing service token")
+		}
+		presented := []byte(strings.TrimPrefix(values[0], "Bearer "))
+
+		// compare against every token so timing does not reveal which one matched
+		caller := ""
+		for name, token := range serviceTokens {
+			if token != "" && subtle.ConstantTimeCompare(presented, []byte(token)) == 1 {
+				caller = name
+			}
+		}
+		if caller == "" {
+			return nil, status.Error(codes.Unauthenticated, "invalid service token")
+		}
+		return handler(context.WithValue(ctx, callerKey{}, caller), req)
+	}
+}
+
+// LoggingInterceptor logs every call with its outcome and duration
+func LoggingInterceptor(log *logger.Logger) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		caller, _ := CallerFromContext(ctx)
+		code := status.Code(err)
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", code.String()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("caller", caller),
+		}
+		switch code {
+		case codes.OK, codes.NotFound, codes.InvalidArgument:
+			log.Info("gRPC call", fields...)
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			log.Error("gRPC call failed", append(fields, zap.Error(err))...)
+		default:
+			log.Warn("gRPC call rejected", append(fields, zap.Error(err))...)
+		}
+		return resp, err
+	}
+}
+
+// RecoveryInterceptor turns a panic in a handler into an Internal error
+// instead of crashing the server
+func RecoveryInterceptor(log *logger.Logger) grpclib.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("gRPC handler panicked",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
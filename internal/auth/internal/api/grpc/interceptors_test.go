@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	intercept := AuthInterceptor(map[string]string{
+		"portfolio": "portfolio-token",
+		"blog":      "blog-token",
+		"disabled":  "",
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		header     []string
+		wantCode   codes.Code
+		wantCaller string
+	}{
+		{"no metadata", "/auth.v1.AuthService/GetUser", nil, codes.Unauthenticated, ""},
+		{"not a bearer token", "/auth.v1.AuthService/GetUser", []string{"Basic portfolio-token"}, codes.Unauthenticated, ""},
+		{"unknown token", "/auth.v1.AuthService/GetUser", []string{"Bearer guessed"}, codes.Unauthenticated, ""},
+		{"empty token", "/auth.v1.AuthService/GetUser", []string{"Bearer "}, codes.Unauthenticated, ""},
+		{"portfolio token", "/auth.v1.AuthService/GetUser", []string{"Bearer portfolio-token"}, codes.OK, "portfolio"},
+		{"blog token", "/auth.v1.AuthService/ValidateToken", []string{"Bearer blog-token"}, codes.OK, "blog"},
+		{"health check", "/grpc.health.v1.Health/Check", nil, codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header[0]))
+			}
+
+			var caller string
+			called := false
+			_, err := intercept(ctx, nil, &grpclib.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, _ any) (any, error) {
+				called = true
+				caller, _ = CallerFromContext(ctx)
+				return nil, nil
+			})
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %s, want %s", code, tt.wantCode)
+			}
+			if called != (tt.wantCode == codes.OK) {
+				t.Errorf("handler called = %v", called)
+			}
+			if caller != tt.wantCaller {
+				t.Errorf("caller = %q, want %q", caller, tt.wantCaller)
+			}
+		})
+	}
+}
+
+func TestRecoveryInterceptorTurnsPanicsIntoInternalErrors(t *testing.T) {
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery := RecoveryInterceptor(log)
+
+	_, err = recovery(context.Background(), nil, &grpclib.UnaryServerInfo{FullMethod: "/auth.v1.AuthService/GetUser"}, func(context.Context, any) (any, error) {
+		panic("nil map")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("got %v, want Internal", err)
+	}
+}
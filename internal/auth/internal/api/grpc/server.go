@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/pkg/authpb"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Server serves the auth gRPC API
+type Server struct {
+	server *grpclib.Server
+	health *health.Server
+	logger *logger.Logger
+}
+
+// NewServer builds the gRPC server. Interceptors run outermost first:
+// recovery, then logging, then service token authentication.
+func NewServer(authService ports.AuthService, jwtService ports.JWTService, patService ports.PersonalAccessTokenService, serviceTokens map[string]string, log *logger.Logger) *Server {
+	server := grpclib.NewServer(grpclib.ChainUnaryInterceptor(
+		RecoveryInterceptor(log),
+		LoggingInterceptor(log),
+		AuthInterceptor(serviceTokens),
+	))
+	authpb.RegisterAuthServiceServer(server, NewAuthServer(authService, jwtService, patService))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	return &Server{server: server, health: healthServer, logger: log}
+}
+
+// ListenAndServe blocks serving on the given port until the server is stopped
+func (s *Server) ListenAndServe(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	s.logger.Info("Starting gRPC server", zap.Int("port", port))
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops accepting calls and waits for in-flight ones to finish.
+// Calls still running when the context expires are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
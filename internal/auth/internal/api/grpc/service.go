@@ -0,0 +1,163 @@
+package grpc
+
+//go:generate protoc --go_out=../../../pkg/authpb --go_opt=paths=source_relative --go-grpc_out=../../../pkg/authpb --go-grpc_opt=paths=source_relative auth.proto
+
+import (
+	"context"
+	"errors"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
+	"github.com/istiak-004/myFolio-microservices/auth/pkg/authpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxUsersPerLookup bounds GetUsersByIDs so one call cannot scan the users table
+const MaxUsersPerLookup = 100
+
+// AuthServer implements the gRPC API over the auth, JWT and personal access
+// token services
+type AuthServer struct {
+	authpb.UnimplementedAuthServiceServer
+
+	AuthService ports.AuthService
+	JWTService  ports.JWTService
+	PATService  ports.PersonalAccessTokenService
+}
+
+func NewAuthServer(authService ports.AuthService, jwtService ports.JWTService, patService ports.PersonalAccessTokenService) *AuthServer {
+	return &AuthServer{AuthService: authService, JWTService: jwtService, PATService: patService}
+}
+
+// ValidateToken accepts the same bearer credentials as the HTTP API: JWT
+// access tokens and personal access tokens, which come back with their scopes
+func (s *AuthServer) ValidateToken(ctx context.Context, req *authpb.ValidateTokenRequest) (*authpb.ValidateTokenResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "access_token is required")
+	}
+	if pat_service.IsPersonalAccessToken(req.GetAccessToken()) {
+		return s.validatePersonalAccessToken(ctx, req.GetAccessToken())
+	}
+
+	claims, err := s.JWTService.VerifyAccessToken(ctx, req.GetAccessToken())
+	if err != nil {
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	resp := &authpb.ValidateTokenResponse{
+		Valid:      true,
+		UserId:     claims.Subject,
+		Role:       claims.Role,
+		TokenId:    claims.ID,
+		TenantId:   claims.TenantID,
+		TenantRole: claims.TenantRole,
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = timestamppb.New(claims.ExpiresAt.Time)
+	}
+	if claims.IsImpersonated() {
+		resp.ActorId = claims.Actor.Subject
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) validatePersonalAccessToken(ctx context.Context, rawToken string) (*authpb.ValidateTokenResponse, error) {
+	pat, owner, err := s.PATService.Authenticate(ctx, rawToken)
+	switch {
+	case errors.Is(err, pat_service.ErrTokenInvalid), errors.Is(err, pat_service.ErrTokenExpired), errors.Is(err, pat_service.ErrTokenRevoked):
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	case err != nil:
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+	return &authpb.ValidateTokenResponse{
+		Valid:     true,
+		UserId:    pat.UserID,
+		Role:      owner.Role,
+		TokenId:   pat.ID,
+		ExpiresAt: timestamppb.New(pat.ExpiresAt),
+		Scopes:    pat.Scopes,
+	}, nil
+}
+
+func (s *AuthServer) GetUser(ctx context.Context, req *authpb.GetUserRequest) (*authpb.GetUserResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	user, err := s.AuthService.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, auth_service.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to load user")
+	}
+	return &authpb.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *AuthServer) GetUsersByIDs(ctx context.Context, req *authpb.GetUsersByIDsRequest) (*authpb.GetUsersByIDsResponse, error) {
+	ids := dedupe(req.GetUserIds())
+	if len(ids) > MaxUsersPerLookup {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d user_ids may be requested at once", MaxUsersPerLookup)
+	}
+
+	users, err := s.AuthService.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to load users")
+	}
+
+	resp := &authpb.GetUsersByIDsResponse{Users: make([]*authpb.User, 0, len(users))}
+	found := make(map[string]bool, len(users))
+	for _, user := range users {
+		found[user.ID] = true
+		resp.Users = append(resp.Users, toProtoUser(user))
+	}
+	for _, id := range ids {
+		if !found[id] {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) RevokeUserSessions(ctx context.Context, req *authpb.RevokeUserSessionsRequest) (*authpb.RevokeUserSessionsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if err := s.AuthService.RevokeUserSessions(ctx, req.GetUserId()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to revoke sessions")
+	}
+	return &authpb.RevokeUserSessionsResponse{}, nil
+}
+
+func toProtoUser(user *models.User) *authpb.User {
+	return &authpb.User{
+		Id:          user.ID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		DisplayName: user.DisplayName,
+		AvatarUrl:   user.AvatarURL,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		Role:        user.Role,
+		IsVerified:  user.IsVerified,
+		IsActive:    user.IsActive,
+		CreatedAt:   timestamppb.New(user.CreatedAt),
+	}
+}
+
+func dedupe(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	"github.com/istiak-004/myFolio-microservices/auth/pkg/authpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubPATService authenticates a single raw token, or fails with err
+type stubPATService struct {
+	ports.PersonalAccessTokenService
+	raw string
+	pat *models.PersonalAccessToken
+	err error
+}
+
+func (s stubPATService) Authenticate(_ context.Context, raw string) (*models.PersonalAccessToken, *models.User, error) {
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	if raw != s.raw {
+		return nil, nil, pat_service.ErrTokenInvalid
+	}
+	return s.pat, &models.User{ID: s.pat.UserID, Role: "creator"}, nil
+}
+
+// stubUsers knows a fixed set of users
+type stubUsers struct {
+	ports.AuthService
+	users map[string]*models.User
+}
+
+func (s stubUsers) GetUsersByIDs(_ context.Context, ids []string) ([]*models.User, error) {
+	var users []*models.User
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func TestValidateTokenVerifiesAccessTokens(t *testing.T) {
+	jwt, err := token.NewEphemeralTokenManager("test", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewAuthServer(nil, jwt, nil)
+	ctx := context.Background()
+
+	access, _, err := jwt.GenerateTenantAccessToken("ada", "creator", "acme", models.OrgRoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: access})
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !resp.GetValid() || resp.GetUserId() != "ada" || resp.GetRole() != "creator" || resp.GetTenantId() != "acme" || resp.GetTenantRole() != models.OrgRoleAdmin {
+		t.Errorf("got %v, want a valid token of ada in acme", resp)
+	}
+	if resp.GetExpiresAt() == nil || resp.GetActorId() != "" {
+		t.Errorf("got expiry %v and actor %q", resp.GetExpiresAt(), resp.GetActorId())
+	}
+
+	impersonation, _, err := jwt.GenerateImpersonationToken("ada", "creator", "root", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: impersonation}); err != nil || resp.GetActorId() != "root" {
+		t.Errorf("impersonation token: got %v, %v; want actor root", resp, err)
+	}
+
+	if resp, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: access + "x"}); err != nil || resp.GetValid() {
+		t.Errorf("tampered token: got %v, %v; want invalid", resp, err)
+	}
+	if _, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty token: got %v, want codes.InvalidArgument", err)
+	}
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	server := NewAuthServer(stubUsers{users: map[string]*models.User{
+		"ada":   {ID: "ada", Email: "ada@example.com"},
+		"grace": {ID: "grace", Email: "grace@example.com"},
+	}}, nil, nil)
+	ctx := context.Background()
+
+	resp, err := server.GetUsersByIDs(ctx, &authpb.GetUsersByIDsRequest{UserIds: []string{"ada", "linus", "ada", "", "grace"}})
+	if err != nil {
+		t.Fatalf("GetUsersByIDs: %v", err)
+	}
+	if len(resp.GetUsers()) != 2 || !slices.Equal(resp.GetMissingIds(), []string{"linus"}) {
+		t.Errorf("got %d users and missing %v, want 2 and [linus]", len(resp.GetUsers()), resp.GetMissingIds())
+	}
+
+	ids := make([]string, MaxUsersPerLookup+1)
+	for i := range ids {
+		ids[i] = "user-" + strconv.Itoa(i)
+	}
+	if _, err := server.GetUsersByIDs(ctx, &authpb.GetUsersByIDsRequest{UserIds: ids}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("oversized lookup: got %v, want codes.InvalidArgument", err)
+	}
+}
+
+func TestValidateTokenAcceptsPersonalAccessTokens(t *testing.T) {
+	raw := pat_service.TokenPrefix + "secret"
+	pat := &models.PersonalAccessToken{
+		ID:        "pat-1",
+		UserID:    "ada",
+		Scopes:    []string{models.ScopeContentRead},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	server := NewAuthServer(nil, nil, stubPATService{raw: raw, pat: pat})
+	ctx := context.Background()
+
+	resp, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: raw})
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !resp.GetValid() || resp.GetUserId() != "ada" || resp.GetRole() != "creator" || resp.GetTokenId() != "pat-1" {
+		t.Errorf("got %v, want a valid token of ada", resp)
+	}
+	if !slices.Equal(resp.GetScopes(), pat.Scopes) {
+		t.Errorf("scopes = %v, want %v", resp.GetScopes(), pat.Scopes)
+	}
+
+	resp, err = server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: pat_service.TokenPrefix + "guess"})
+	if err != nil || resp.GetValid() {
+		t.Errorf("unknown token: got %v, %v; want invalid", resp, err)
+	}
+
+	server = NewAuthServer(nil, nil, stubPATService{err: errors.New("database is down")})
+	if _, err := server.ValidateToken(ctx, &authpb.ValidateTokenRequest{AccessToken: raw}); status.Code(err) != codes.Internal {
+		t.Errorf("failing lookup: got %v, want codes.Internal", err)
+	}
+}
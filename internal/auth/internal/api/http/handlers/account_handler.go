@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	account_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/account"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+type AccountHandler struct {
+	AccountService ports.AccountService
+}
+
+func NewAccountHandler(r *gin.Engine, accountService ports.AccountService, auth *authmw.JWTMiddleware) {
+	h := &AccountHandler{AccountService: accountService}
+
+	// personal data requests must come from the user themselves
+	group := r.Group("/auth/me")
+	group.Use(auth.GinHandler(), authmw.RequireSession(), authmw.BlockImpersonation(), authmw.ClientInfo())
+
+	group.GET("/export", h.HandleExport)
+	group.DELETE("", h.HandleDelete)
+}
+
+// HandleExport returns the user's personal data as a downloadable JSON archive
+func (h *AccountHandler) HandleExport(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	export, err := h.AccountService.Export(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, account_service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	filename := fmt.Sprintf("myfolio-export-%s.json", export.ExportedAt.UTC().Format("20060102T150405Z"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+type DeleteAccountRequest struct {
+	// Password is required unless the account was created through OAuth
+	Password string `json:"password"`
+}
+
+func (h *AccountHandler) HandleDelete(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req DeleteAccountRequest
+	// OAuth-only accounts have no password and may send an empty body
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deletion, err := h.AccountService.RequestDeletion(c.Request.Context(), userID, valueobjects.PasswordFromInput(req.Password))
+	if err != nil {
+		switch {
+		case errors.Is(err, account_service.ErrInvalidCredentials):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, account_service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+		}
+		return
+	}
+	clearRefreshCookie(c)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":     "your account has been deleted and will be permanently erased after the grace period",
+		"purge_after": deletion.PurgeAfter,
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	admin_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/admin"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+)
+
+const RoleSuperAdmin = models.RoleSuperAdmin
+
+type AdminHandler struct {
+	ImpersonationService ports.ImpersonationService
+	AuthService          ports.AuthService
+}
+
+func NewAdminHandler(r *gin.Engine, impersonationService ports.ImpersonationService, authService ports.AuthService, auth *authmw.JWTMiddleware) {
+	h := &AdminHandler{ImpersonationService: impersonationService, AuthService: authService}
+
+	group := r.Group("/admin")
+	group.Use(
+		auth.GinHandler(),
+		authmw.RequireSession(),
+		authmw.RequireRole(RoleSuperAdmin),
+		authmw.BlockImpersonation(),
+		authmw.ClientInfo(),
+	)
+
+	group.POST("/impersonations", h.HandleImpersonate)
+	group.POST("/users/:id/deactivate", h.HandleDeactivateUser)
+}
+
+type ImpersonateRequest struct {
+	UserID          string `json:"user_id" binding:"required,uuid"`
+	Reason          string `json:"reason" binding:"required,max=500"`
+	DurationMinutes int    `json:"duration_minutes" binding:"omitempty,min=1,max=60"`
+}
+
+func (h *AdminHandler) HandleImpersonate(c *gin.Context) {
+	actorID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	grant, err := h.ImpersonationService.Impersonate(c.Request.Context(), actorID, req.UserID, req.Reason, duration)
+	if err != nil {
+		switch {
+		case errors.Is(err, admin_service.ErrNotSuperAdmin):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, admin_service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, admin_service.ErrCannotImpersonate),
+			errors.Is(err, admin_service.ErrReasonRequired),
+			errors.Is(err, admin_service.ErrInvalidImpersonation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start impersonation"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, grant)
+}
+
+// HandleDeactivateUser disables an account and revokes its sessions
+func (h *AdminHandler) HandleDeactivateUser(c *gin.Context) {
+	if err := h.AuthService.DeactivateUser(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, auth_service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user deactivated"})
+}
@@ -1,12 +1,20 @@
 package handler
 
 import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/api/http/utils"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	device_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/device"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
 	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
 )
@@ -23,29 +31,39 @@ const (
 	RefreshTokenCookieName = "refresh_token"
 )
 
+//go:embed templates/device_report.html
+var deviceReportFS embed.FS
+
+var deviceReportTemplates = template.Must(template.ParseFS(deviceReportFS, "templates/device_report.html"))
+
 type AuthHandler struct {
-	AuthService ports.AuthService
+	AuthService   ports.AuthService
+	DeviceService ports.DeviceService
 }
 
-func NewAuthHandler(r *gin.Engine, authService ports.AuthService) {
-	h := &AuthHandler{authService}
+func NewAuthHandler(r *gin.Engine, authService ports.AuthService, deviceService ports.DeviceService, pow middleware.ProofOfWorkConfig, rateLimiter *middleware.RateLimiter) {
+	h := &AuthHandler{AuthService: authService, DeviceService: deviceService}
 
 	group := r.Group("/auth")
-	group.Use(middleware.RateLimiterMiddleware(middleware.RateLimitConfig{
-		Enabled:  true,
-		Requests: 10,
-		Interval: time.Minute,
-	}), middleware.SecurityHeaders())
-
-	group.POST("/register", h.HandleRegister)
-	group.POST("/login", h.HandleLogin)
+	group.Use(rateLimiter.Middleware(), authmw.DeviceCookie())
+
+	// bots target account creation and credential stuffing, so only these
+	// endpoints ask suspicious clients for a proof of work
+	challenge := middleware.ProofOfWork(pow)
+	group.POST("/register", challenge, h.HandleRegister)
+	group.POST("/login", challenge, h.HandleLogin)
 	group.POST("/refresh", h.HandleRefresh)
 	group.POST("/logout", h.HandleLogout)
+	group.POST("/password/forgot", h.HandleForgotPassword)
+	group.POST("/password/reset", h.HandleResetPassword)
+	group.GET("/devices/report", h.HandleReportDevicePage)
+	group.POST("/devices/report", h.HandleReportDevice)
+	group.GET("/verify-email", h.HandleVerifyEmail)
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
 	Name     string `json:"name" binding:"required"`
 }
 
@@ -60,16 +78,22 @@ func (h *AuthHandler) HandleRegister(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	password, err := valueobjects.NewPassword(req.Password)
+	password, err := valueobjects.NewPasswordForEmail(req.Password, email)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writePasswordError(c, err, "failed to register")
 		return
 	}
 
 	// register the user
 	_, err = h.AuthService.Register(c.Request.Context(), email, password, req.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, auth_service.ErrEmailExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		// logged with the request
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register"})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "registration successful, please check your email"})
@@ -91,11 +115,8 @@ func (h *AuthHandler) HandleLogin(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	password, err := valueobjects.NewPassword(req.Password)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	// login input is never checked against the password policy, only against the stored hash
+	password := valueobjects.PasswordFromInput(req.Password)
 	tokens, err := h.AuthService.Login(c.Request.Context(), email, password)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
@@ -136,15 +157,182 @@ func (h *AuthHandler) HandleLogout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// HandleForgotPassword always answers the same way, whether or not the email is registered
+func (h *AuthHandler) HandleForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email, err := valueobjects.NewEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.AuthService.RequestPasswordReset(c.Request.Context(), email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start password reset"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link is on its way"})
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+func (h *AuthHandler) HandleResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	password, err := valueobjects.NewPassword(req.NewPassword)
+	if err != nil {
+		writePasswordError(c, err, "failed to reset password")
+		return
+	}
+
+	token := valueobjects.Token{TokenString: req.Token}
+	if err := h.AuthService.ResetPassword(c.Request.Context(), token, password); err != nil {
+		switch {
+		case errors.Is(err, auth_service.ErrTokenInvalid), errors.Is(err, auth_service.ErrTokenExpired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset link"})
+		default:
+			writePasswordError(c, err, "failed to reset password")
+		}
+		return
+	}
+	clearRefreshCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "password reset, please log in again"})
+}
+
+// HandleVerifyEmail confirms an email address from the link in the verification email
+func (h *AuthHandler) HandleVerifyEmail(c *gin.Context) {
+	token := valueobjects.Token{TokenString: c.Query("token")}
+	if token.String() == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing token"})
+		return
+	}
+
+	if err := h.AuthService.VerifyEmail(c.Request.Context(), token); err != nil {
+		switch {
+		case errors.Is(err, auth_service.ErrTokenInvalid), errors.Is(err, auth_service.ErrTokenExpired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification link"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified, you can now sign in"})
+}
+
+// HandleReportDevicePage is the "this wasn't me" link from new-device emails.
+// It only asks for confirmation, since mail scanners and link previews follow
+// links too; the account is secured by the form it posts.
+func (h *AuthHandler) HandleReportDevicePage(c *gin.Context) {
+	// the token is in the URL; keep it out of referrers and caches
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+
+	token := c.Query("token")
+	if token == "" {
+		renderDeviceReport(c, http.StatusBadRequest, "result", gin.H{
+			"Title":   "Invalid link",
+			"Message": "This link is incomplete. Open it again from the email.",
+		})
+		return
+	}
+	renderDeviceReport(c, http.StatusOK, "confirm", gin.H{"Title": "Was this you?", "Token": token})
+}
+
+type ReportDeviceRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// HandleReportDevice signs the user out everywhere and emails them a password
+// reset link. The confirmation page gets a page back, API clients JSON.
+func (h *AuthHandler) HandleReportDevice(c *gin.Context) {
+	var req ReportDeviceRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondDeviceReport(c, http.StatusBadRequest, "missing token")
+		return
+	}
+
+	user, err := h.DeviceService.ReportUnrecognized(c.Request.Context(), valueobjects.Token{TokenString: req.Token})
+	if err != nil {
+		if errors.Is(err, device_service.ErrTokenInvalid) {
+			respondDeviceReport(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondDeviceReport(c, http.StatusInternalServerError, "failed to secure account")
+		return
+	}
+
+	email, err := valueobjects.NewEmail(user.Email)
+	if err == nil {
+		err = h.AuthService.RequestPasswordReset(c.Request.Context(), email)
+	}
+	if err != nil {
+		respondDeviceReport(c, http.StatusInternalServerError, "signed out of all sessions, but failed to send the password reset email")
+		return
+	}
+	respondDeviceReport(c, http.StatusOK, "you have been signed out everywhere, check your email to reset your password")
+}
+
+// respondDeviceReport answers browsers with a result page and anything else
+// with JSON
+func respondDeviceReport(c *gin.Context, status int, message string) {
+	if c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) != binding.MIMEHTML {
+		if status >= http.StatusBadRequest {
+			c.JSON(status, gin.H{"error": message})
+		} else {
+			c.JSON(status, gin.H{"message": message})
+		}
+		return
+	}
+	title := "Account secured"
+	if status >= http.StatusBadRequest {
+		title = "Could not secure your account"
+	}
+	renderDeviceReport(c, status, "result", gin.H{"Title": title, "Message": message})
+}
+
+func renderDeviceReport(c *gin.Context, status int, name string, data gin.H) {
+	var page bytes.Buffer
+	if err := deviceReportTemplates.ExecuteTemplate(&page, name, data); err != nil {
+		c.String(http.StatusInternalServerError, "failed to render page: %v", err)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// writePasswordError reports password policy violations individually so
+// clients can point users at each broken rule
+func writePasswordError(c *gin.Context, err error, fallback string) {
+	var policyErr *valueobjects.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password does not meet the password policy", "violations": policyErr.Violations})
+		return
+	}
+	// logged with the request; the details are not the client's business
+	_ = c.Error(err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
 // setSecureRefreshCookie sets the refresh token securely as an HTTP-only cookie
 // with SameSite=Strict to prevent CSRF attacks
 func setSecureRefreshCookie(c *gin.Context, refreshToken string) {
-	utils.SetRefreshTokenCookie(c.Writer, refreshToken, c.Request.Host)
+	utils.SetRefreshTokenCookie(c.Writer, refreshToken)
 	c.Writer.Header().Add("Set-Cookie", "SameSite=Strict")
 }
 
 // clearRefreshCookie deletes the refresh token cookie
 func clearRefreshCookie(c *gin.Context) {
-	utils.ClearRefreshTokenCookie(c.Writer, c.Request.Host)
+	utils.ClearRefreshTokenCookie(c.Writer)
 	c.Writer.Header().Add("Set-Cookie", "SameSite=Strict")
 }
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+const internalDetail = "pq: connection refused to 10.0.0.7:5432"
+
+// failingAuthService fails registration with an internal error
+type failingAuthService struct {
+	ports.AuthService
+}
+
+func (failingAuthService) Register(context.Context, valueobjects.Email, valueobjects.Password, string) (*models.User, error) {
+	return nil, errors.New(internalDetail)
+}
+
+func TestUnexpectedErrorsAreLoggedNotReturned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &AuthHandler{AuthService: failingAuthService{}}
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		body    string
+	}{
+		{"register", h.HandleRegister, `{"email":"ada@example.com","password":"Tr0ub4dor&Zebra-Quilt","name":"Ada"}`},
+		{"password error", func(c *gin.Context) { writePasswordError(c, errors.New(internalDetail), "failed to reset password") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			r := gin.New()
+			r.POST("/", func(c *gin.Context) {
+				c.Next()
+				logged = c.Errors.Errors()
+			}, tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want 500", w.Code)
+			}
+			if strings.Contains(w.Body.String(), internalDetail) {
+				t.Errorf("response leaks the internal error: %s", w.Body)
+			}
+			if len(logged) != 1 || logged[0] != internalDetail {
+				t.Errorf("errors attached for logging = %v, want [%s]", logged, internalDetail)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyViolationsAreReturned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		_, err := valueobjects.NewPassword("short")
+		writePasswordError(c, err, "failed to change password")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "violations") {
+		t.Errorf("got %d %s, want 400 with the violations", w.Code, w.Body)
+	}
+}
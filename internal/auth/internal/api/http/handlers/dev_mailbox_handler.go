@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+)
+
+//go:embed templates/dev_mailbox.html
+var devMailboxFS embed.FS
+
+var devMailboxTemplates = template.Must(template.ParseFS(devMailboxFS, "templates/dev_mailbox.html"))
+
+// developmentEnvironment is the only app environment the mailbox is served in
+const developmentEnvironment = "development"
+
+// ErrDevMailboxNotAllowed is returned when the mailbox would be mounted
+// outside development
+var ErrDevMailboxNotAllowed = errors.New("the dev mailbox is only served in the development environment")
+
+// DevMailboxHandler shows emails captured instead of sent. It has no
+// authentication, so it refuses to be registered outside development.
+type DevMailboxHandler struct {
+	Mailbox ports.Mailbox
+}
+
+// NewDevMailboxHandler mounts the mailbox when environment is development and
+// returns ErrDevMailboxNotAllowed otherwise
+func NewDevMailboxHandler(r *gin.Engine, mailbox ports.Mailbox, environment string) error {
+	if environment != developmentEnvironment {
+		return ErrDevMailboxNotAllowed
+	}
+	h := &DevMailboxHandler{Mailbox: mailbox}
+
+	ui := r.Group("/dev/mail")
+	ui.GET("", h.HandleListPage)
+	ui.GET("/:id", h.HandleMessagePage)
+	ui.GET("/:id/html", h.HandleMessageHTML)
+
+	api := r.Group("/dev/api/mail")
+	api.GET("", h.HandleList)
+	api.GET("/:id", h.HandleGet)
+	api.DELETE("", h.HandleClear)
+	return nil
+}
+
+func (h *DevMailboxHandler) HandleListPage(c *gin.Context) {
+	mails, err := h.Mailbox.List(c.Request.Context())
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to list mail: %v", err)
+		return
+	}
+	renderDevMailbox(c, "list", gin.H{"Title": "Inbox", "Mails": mails, "Refresh": true})
+}
+
+func (h *DevMailboxHandler) HandleMessagePage(c *gin.Context) {
+	mail, ok := h.find(c)
+	if !ok {
+		return
+	}
+	renderDevMailbox(c, "detail", gin.H{"Title": mail.Subject, "Mail": mail})
+}
+
+// HandleMessageHTML serves the email body as sent, for the preview frame
+func (h *DevMailboxHandler) HandleMessageHTML(c *gin.Context) {
+	mail, ok := h.find(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Security-Policy", "sandbox allow-popups allow-popups-to-escape-sandbox")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(mail.HTML))
+}
+
+func (h *DevMailboxHandler) HandleList(c *gin.Context) {
+	mails, err := h.Mailbox.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list mail"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mails": mails})
+}
+
+func (h *DevMailboxHandler) HandleGet(c *gin.Context) {
+	mail, err := h.Mailbox.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "mail not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get mail"})
+		return
+	}
+	c.JSON(http.StatusOK, mail)
+}
+
+func (h *DevMailboxHandler) HandleClear(c *gin.Context) {
+	if err := h.Mailbox.Clear(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clear mailbox"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mailbox cleared"})
+}
+
+// find loads the mail named in the path, writing a plain error page when it cannot
+func (h *DevMailboxHandler) find(c *gin.Context) (*models.CapturedMail, bool) {
+	mail, err := h.Mailbox.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.String(http.StatusNotFound, "mail not found")
+		} else {
+			c.String(http.StatusInternalServerError, "failed to get mail: %v", err)
+		}
+		return nil, false
+	}
+	return mail, true
+}
+
+func renderDevMailbox(c *gin.Context, name string, data gin.H) {
+	var page bytes.Buffer
+	if err := devMailboxTemplates.ExecuteTemplate(&page, name, data); err != nil {
+		c.String(http.StatusInternalServerError, "failed to render page: %v", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	mailqueue_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/mailqueue"
+)
+
+// MailAdminHandler lets super admins inspect the mail queue and re-drive failed mail
+type MailAdminHandler struct {
+	MailQueue ports.MailQueue
+}
+
+func NewMailAdminHandler(r *gin.Engine, mailQueue ports.MailQueue, auth *authmw.JWTMiddleware) {
+	h := &MailAdminHandler{MailQueue: mailQueue}
+
+	group := r.Group("/admin/mail")
+	group.Use(
+		auth.GinHandler(),
+		authmw.RequireSession(),
+		authmw.RequireRole(RoleSuperAdmin),
+		authmw.BlockImpersonation(),
+	)
+
+	group.GET("", h.HandleList)
+	group.GET("/:id", h.HandleGet)
+	group.POST("/:id/redrive", h.HandleRedrive)
+}
+
+// HandleList lists queued mail by status, dead letters by default
+func (h *MailAdminHandler) HandleList(c *gin.Context) {
+	status := c.DefaultQuery("status", models.MailDead)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	mails, err := h.MailQueue.List(c.Request.Context(), status, limit)
+	if err != nil {
+		writeMailQueueError(c, err, "failed to list mail")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mails": mails})
+}
+
+func (h *MailAdminHandler) HandleGet(c *gin.Context) {
+	mail, err := h.MailQueue.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeMailQueueError(c, err, "failed to get mail")
+		return
+	}
+	c.JSON(http.StatusOK, mail)
+}
+
+// HandleRedrive schedules a dead mail for immediate delivery
+func (h *MailAdminHandler) HandleRedrive(c *gin.Context) {
+	mail, err := h.MailQueue.Redrive(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeMailQueueError(c, err, "failed to re-drive mail")
+		return
+	}
+	c.JSON(http.StatusOK, mail)
+}
+
+func writeMailQueueError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, mailqueue_service.ErrMailNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, mailqueue_service.ErrMailNotDead):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, mailqueue_service.ErrUnknownStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
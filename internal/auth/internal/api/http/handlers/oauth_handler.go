@@ -1,28 +1,34 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
 	oauth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/oauth"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type OAuthHandler struct {
-	OauthService ports.OAuthService // supports RegisterOrLoginGoogle(ctx, email, name)
+	OauthService  ports.OAuthService // supports RegisterOrLoginGoogle(ctx, email, name)
+	DeviceService ports.DeviceService
 }
 
-func NewOAuthHandler(r *gin.Engine, oauthService ports.OAuthService) {
-	h := &OAuthHandler{OauthService: oauthService}
+func NewOAuthHandler(r *gin.Engine, oauthService ports.OAuthService, deviceService ports.DeviceService) {
+	h := &OAuthHandler{OauthService: oauthService, DeviceService: deviceService}
 
 	group := r.Group("/auth/google")
+	group.Use(authmw.DeviceCookie())
 	group.GET("/login", h.Login)
 	group.GET("/callback", h.Callback)
 }
 
 func (h *OAuthHandler) Login(c *gin.Context) {
-	url := oauth_service.GoogleOAuthConfig.AuthCodeURL("random-state") // Add CSRF token later
-	c.Redirect(http.StatusFound, url)
+	c.Redirect(http.StatusFound, h.OauthService.AuthURL(oauth_service.ProviderGoogle))
 }
 
 func (h *OAuthHandler) Callback(c *gin.Context) {
@@ -46,11 +52,25 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 	}
 
 	authToken, err := h.OauthService.RegisterOrLoginGoogle(ctx, user.Email, user.Name)
+	if errors.Is(err, oauth_service.ErrAccountDeactivated) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth service error"})
 		return
 	}
 
+	// the device check may send an email; it must never slow down or block an
+	// otherwise valid login, so it runs on its own
+	if h.DeviceService != nil {
+		go func(ctx context.Context) {
+			if err := h.DeviceService.CheckLogin(ctx, user.Email); err != nil {
+				logger.FromContext(ctx).Warn("Failed to check the login device", zap.Error(err))
+			}
+		}(context.WithoutCancel(ctx))
+	}
+
 	setSecureRefreshCookie(c, authToken.RefreshToken)
 	c.JSON(http.StatusOK, gin.H{"access_token": authToken.AccessToken, "expires_in": authToken.ExpiresIn})
 }
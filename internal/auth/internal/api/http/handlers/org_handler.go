@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	organization_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/organization"
+)
+
+type OrganizationHandler struct {
+	OrganizationService ports.OrganizationService
+}
+
+func NewOrganizationHandler(r *gin.Engine, organizationService ports.OrganizationService, auth *authmw.JWTMiddleware) {
+	h := &OrganizationHandler{OrganizationService: organizationService}
+
+	group := r.Group("/auth/orgs")
+	group.Use(auth.GinHandler(), authmw.RequireSession(), authmw.BlockImpersonation())
+
+	group.GET("", h.HandleList)
+	group.POST("", h.HandleCreate)
+	group.POST("/:id/switch", h.HandleSwitch)
+
+	// member management acts on the organization the access token is scoped to
+	members := group.Group("/current/members")
+	members.GET("", h.HandleListMembers)
+	members.POST("", authmw.RequireTenantRole(models.OrgRoleOwner, models.OrgRoleAdmin), h.HandleAddMember)
+	members.DELETE("/:userID", authmw.RequireTenantRole(models.OrgRoleOwner, models.OrgRoleAdmin), h.HandleRemoveMember)
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin editor viewer"`
+}
+
+func (h *OrganizationHandler) HandleCreate(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.OrganizationService.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		if errors.Is(err, organization_service.ErrInvalidName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+		return
+	}
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) HandleList(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	orgs, err := h.OrganizationService.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list organizations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
+}
+
+// HandleSwitch re-issues the caller's tokens scoped to another organization
+func (h *OrganizationHandler) HandleSwitch(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	tokens, err := h.OrganizationService.Switch(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, organization_service.ErrNotMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to switch organization"})
+		return
+	}
+	// the rotated refresh token travels like the one issued at login
+	setSecureRefreshCookie(c, tokens.RefreshToken)
+	c.JSON(http.StatusOK, gin.H{"access_token": tokens.AccessToken, "expires_in": tokens.ExpiresIn})
+}
+
+func (h *OrganizationHandler) HandleListMembers(c *gin.Context) {
+	members, err := h.OrganizationService.ListMembers(c.Request.Context())
+	if err != nil {
+		writeOrganizationError(c, err, "failed to list members")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *OrganizationHandler) HandleAddMember(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.OrganizationService.AddMember(c.Request.Context(), userID, req.Email, req.Role)
+	if err != nil {
+		writeOrganizationError(c, err, "failed to add member")
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+func (h *OrganizationHandler) HandleRemoveMember(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	if err := h.OrganizationService.RemoveMember(c.Request.Context(), userID, c.Param("userID")); err != nil {
+		writeOrganizationError(c, err, "failed to remove member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+func writeOrganizationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, organization_service.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, organization_service.ErrNoOrganization),
+		errors.Is(err, organization_service.ErrForbidden),
+		errors.Is(err, organization_service.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, organization_service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, organization_service.ErrAlreadyMember),
+		errors.Is(err, organization_service.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
+)
+
+type PersonalAccessTokenHandler struct {
+	TokenService ports.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(r *gin.Engine, tokenService ports.PersonalAccessTokenService, auth *authmw.JWTMiddleware) {
+	h := &PersonalAccessTokenHandler{TokenService: tokenService}
+
+	// tokens can only be managed from an interactive session, never with another token
+	group := r.Group("/auth/tokens")
+	group.Use(auth.GinHandler(), authmw.RequireSession(), authmw.BlockImpersonation())
+
+	group.GET("", h.HandleList)
+	group.POST("", h.HandleCreate)
+	group.DELETE("/:id", h.HandleRevoke)
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+// CreateTokenResponse contains the plain token, which is only ever returned here
+type CreateTokenResponse struct {
+	Token string                      `json:"token"`
+	Info  *models.PersonalAccessToken `json:"info"`
+}
+
+func (h *PersonalAccessTokenHandler) HandleCreate(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	pat, raw, err := h.TokenService.Create(c.Request.Context(), userID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, pat_service.ErrInvalidName),
+			errors.Is(err, pat_service.ErrInvalidScope),
+			errors.Is(err, pat_service.ErrDuplicateScopes),
+			errors.Is(err, pat_service.ErrNoScopes),
+			errors.Is(err, pat_service.ErrInvalidTTL):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, CreateTokenResponse{Token: raw, Info: pat})
+}
+
+func (h *PersonalAccessTokenHandler) HandleList(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	tokens, err := h.TokenService.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tokens"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (h *PersonalAccessTokenHandler) HandleRevoke(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	if err := h.TokenService.Revoke(c.Request.Context(), userID, c.Param("id")); err != nil {
+		if errors.Is(err, pat_service.ErrTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	profile_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/profile"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+type ProfileHandler struct {
+	ProfileService ports.ProfileService
+	AuthService    ports.AuthService
+}
+
+func NewProfileHandler(r *gin.Engine, profileService ports.ProfileService, authService ports.AuthService, auth *authmw.JWTMiddleware) {
+	h := &ProfileHandler{ProfileService: profileService, AuthService: authService}
+
+	group := r.Group("/auth/me")
+
+	group.GET("", auth.GinHandler(models.ScopeProfileRead), h.HandleGet)
+	group.PATCH("", auth.GinHandler(models.ScopeProfileWrite), h.HandleUpdate)
+	group.POST("/password", auth.GinHandler(), authmw.RequireSession(), authmw.BlockImpersonation(), h.HandleChangePassword)
+}
+
+func (h *ProfileHandler) HandleGet(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	user, err := h.ProfileService.Get(c.Request.Context(), userID)
+	if err != nil {
+		writeProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *ProfileHandler) HandleUpdate(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req models.ProfileUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.ProfileService.Update(c.Request.Context(), userID, req)
+	if err != nil {
+		writeProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+func (h *ProfileHandler) HandleChangePassword(c *gin.Context) {
+	userID, _ := authmw.GetUserIDFromContext(c.Request.Context())
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	next, err := valueobjects.NewPassword(req.NewPassword)
+	if err != nil {
+		writePasswordError(c, err, "failed to change password")
+		return
+	}
+
+	current := valueobjects.PasswordFromInput(req.CurrentPassword)
+	if err := h.AuthService.ChangePassword(c.Request.Context(), userID, current, next); err != nil {
+		switch {
+		case errors.Is(err, auth_service.ErrInvalidCredentials):
+			c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+		case errors.Is(err, auth_service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			writePasswordError(c, err, "failed to change password")
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+func writeProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, profile_service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, profile_service.ErrInvalidName),
+		errors.Is(err, profile_service.ErrInvalidAvatarURL),
+		errors.Is(err, profile_service.ErrInvalidLocale),
+		errors.Is(err, profile_service.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load profile"})
+	}
+}
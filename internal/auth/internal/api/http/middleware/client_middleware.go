@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/api/http/utils"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+const (
+	DeviceCookieName   = "device_id"
+	deviceCookieMaxAge = 2 * 365 * 24 * 60 * 60 // two years
+)
+
+// ClientInfo stores the caller's IP address and user agent in the request
+// context, so the domain services can record where an action came from
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID, _ := c.Cookie(DeviceCookieName)
+		setClientInfo(c, deviceID)
+		c.Next()
+	}
+}
+
+// DeviceCookie is ClientInfo for login endpoints. It also issues the
+// long-lived device cookie used to recognise the browser on later logins.
+func DeviceCookie() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID, err := c.Cookie(DeviceCookieName)
+		if err != nil || deviceID == "" {
+			deviceID = newDeviceID()
+			utils.SetCookie(c.Writer, &http.Cookie{
+				Name:     DeviceCookieName,
+				Value:    deviceID,
+				Path:     "/auth",
+				MaxAge:   deviceCookieMaxAge,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode, // must survive the redirect back from OAuth providers
+			})
+		}
+		setClientInfo(c, deviceID)
+		c.Next()
+	}
+}
+
+func setClientInfo(c *gin.Context, deviceID string) {
+	ctx := models.ContextWithClientInfo(c.Request.Context(), models.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  deviceID,
+	})
+	c.Request = c.Request.WithContext(ctx)
+}
+
+func newDeviceID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
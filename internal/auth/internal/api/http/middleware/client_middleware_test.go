@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/api/http/utils"
+)
+
+func TestDeviceCookieFollowsCookieConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/auth/login", DeviceCookie(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		config utils.CookieConfig
+		secure bool
+	}{
+		{"secure behind a TLS terminating proxy", utils.CookieConfig{Domain: "myfolio.dev", Secure: true}, true},
+		{"insecure for local development", utils.CookieConfig{}, false},
+	}
+	t.Cleanup(func() { utils.SetCookieConfig(utils.CookieConfig{}) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetCookieConfig(tt.config)
+			// plain HTTP, as seen by the server behind a proxy
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != DeviceCookieName {
+				t.Fatalf("got cookies %v, want %s", cookies, DeviceCookieName)
+			}
+			if cookies[0].Secure != tt.secure {
+				t.Errorf("Secure = %v, want %v", cookies[0].Secure, tt.secure)
+			}
+			if cookies[0].Domain != tt.config.Domain {
+				t.Errorf("Domain = %q, want %q", cookies[0].Domain, tt.config.Domain)
+			}
+		})
+	}
+}
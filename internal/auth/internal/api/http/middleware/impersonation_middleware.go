@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+)
+
+// BlockImpersonation rejects sensitive operations, such as password or email
+// changes, while an admin is impersonating the user
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := GetActorIDFromContext(c.Request.Context()); impersonated {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this operation is not allowed while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuditImpersonation records every state changing request made during an
+// impersonation session in the impersonated user's audit trail, including
+// those refused. It inspects the request once the handlers have run, so it
+// can be registered ahead of the authentication of the groups it covers.
+func AuditImpersonation(audit ports.AuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		actorID, impersonated := GetActorIDFromContext(c.Request.Context())
+		if !impersonated || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		userID, _ := GetUserIDFromContext(c.Request.Context())
+
+		_ = audit.Record(c.Request.Context(), &models.AuditEvent{
+			ID:      uuid.New().String(),
+			UserID:  userID,
+			ActorID: actorID,
+			Action:  models.AuditImpersonatedRequest,
+			Metadata: map[string]string{
+				"method": c.Request.Method,
+				"route":  c.FullPath(),
+				"status": strconv.Itoa(c.Writer.Status()),
+			},
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			CreatedAt: time.Now(),
+		})
+	}
+}
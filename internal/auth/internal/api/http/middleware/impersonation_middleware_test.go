@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+)
+
+// auditTrail keeps recorded events in memory
+type auditTrail struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+}
+
+func (a *auditTrail) Record(_ context.Context, event *models.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	return nil
+}
+
+func (a *auditTrail) ListByUser(context.Context, string) ([]*models.AuditEvent, error) {
+	return nil, nil
+}
+
+func TestImpersonationSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwt, err := token.NewEphemeralTokenManager("test", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _, err := jwt.GenerateAccessToken("ada", "creator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonated, _, err := jwt.GenerateImpersonationToken("ada", "creator", "root", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := &auditTrail{}
+	auth := NewJWTMiddleware(*jwt, nil)
+	r := gin.New()
+	r.Use(AuditImpersonation(audit))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/me", auth.GinHandler(), ok)
+	r.PATCH("/me", auth.GinHandler(), ok)
+	r.POST("/me/password", auth.GinHandler(), BlockImpersonation(), ok)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		token     string
+		want      int
+		wantAudit bool
+	}{
+		{"session changes the password", http.MethodPost, "/me/password", session, http.StatusOK, false},
+		{"session edits the profile", http.MethodPatch, "/me", session, http.StatusOK, false},
+		{"impersonator reads the profile", http.MethodGet, "/me", impersonated, http.StatusOK, false},
+		{"impersonator edits the profile", http.MethodPatch, "/me", impersonated, http.StatusOK, true},
+		{"impersonator changes the password", http.MethodPost, "/me/password", impersonated, http.StatusForbidden, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit.events = nil
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if audited := len(audit.events) == 1; audited != tt.wantAudit {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantAudit)
+			}
+			if !tt.wantAudit {
+				return
+			}
+			event := audit.events[0]
+			if event.UserID != "ada" || event.ActorID != "root" || event.Action != models.AuditImpersonatedRequest {
+				t.Errorf("audited %s of %s by %s", event.Action, event.UserID, event.ActorID)
+			}
+			if event.Metadata["route"] != tt.path || event.Metadata["status"] != strconv.Itoa(tt.want) {
+				t.Errorf("audit metadata %v, want route %s and status %d", event.Metadata, tt.path, tt.want)
+			}
+		})
+	}
+}
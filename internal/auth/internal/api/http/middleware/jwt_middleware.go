@@ -2,51 +2,198 @@ package middleware
 
 import (
 	"context"
+	"errors"
 
 	"net/http"
 	"strings"
 
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type contextKey string
 
 const (
-	ContextUserIDKey contextKey = "userID"
-	ContextRoleKey   contextKey = "role"
+	ContextUserIDKey     contextKey = "userID"
+	ContextRoleKey       contextKey = "role"
+	ContextAuthMethodKey contextKey = "authMethod"
+	ContextScopesKey     contextKey = "scopes"
+	ContextActorIDKey    contextKey = "actorID"
+	ContextTenantRoleKey contextKey = "tenantRole"
+)
+
+// Authentication methods accepted by the middleware
+const (
+	AuthMethodJWT = "jwt"
+	AuthMethodPAT = "pat"
+)
+
+var (
+	errMissingToken = errors.New("missing or malformed token")
+	errInvalidToken = errors.New("invalid or expired token")
+
+	errTokenNotAccepted  = errors.New("personal access tokens are not accepted here")
+	errInsufficientScope = errors.New("insufficient token scope")
 )
 
 type JWTMiddleware struct {
 	TokenVerifier token.JWTTokenManager
+	PATVerifier   ports.PersonalAccessTokenService
 }
 
-func NewJWTMiddleware(verifier token.JWTTokenManager) *JWTMiddleware {
+// NewJWTMiddleware creates the authentication middleware. Personal access
+// tokens are only accepted when a PAT verifier is provided.
+func NewJWTMiddleware(verifier token.JWTTokenManager, pats ports.PersonalAccessTokenService) *JWTMiddleware {
 	return &JWTMiddleware{
 		TokenVerifier: verifier,
+		PATVerifier:   pats,
 	}
 }
 
-func (m *JWTMiddleware) Handler(next http.Handler) http.Handler {
+// Handler authenticates requests to next. Personal access tokens are only
+// accepted when they were granted one of scopes; see GinHandler.
+func (m *JWTMiddleware) Handler(next http.Handler, scopes ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "missing or malformed token", http.StatusUnauthorized)
+		ctx, err := m.authenticate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err := authorizeScopes(ctx, scopes); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GinHandler is the gin flavour of Handler. Sessions act with the full
+// authority of the user and are always accepted, but a personal access token
+// only passes when it was granted one of scopes: routes that declare no scope
+// are closed to tokens, so a new route is never exposed to them by omission.
+func (m *JWTMiddleware) GinHandler(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, err := m.authenticate(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		if err := authorizeScopes(ctx, scopes); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// authorizeScopes checks a personal access token against the scopes the
+// route accepts
+func authorizeScopes(ctx context.Context, scopes []string) error {
+	if method, _ := GetAuthMethodFromContext(ctx); method != AuthMethodPAT {
+		return nil
+	}
+	if len(scopes) == 0 {
+		return errTokenNotAccepted
+	}
+	for _, scope := range scopes {
+		if HasScope(ctx, scope) {
+			return nil
+		}
+	}
+	return errInsufficientScope
+}
+
+// authenticate validates the bearer credential, which is either a JWT access
+// token or a personal access token, and returns a context carrying the caller.
+func (m *JWTMiddleware) authenticate(r *http.Request) (context.Context, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, errMissingToken
+	}
 
-		rawToken := strings.TrimPrefix(authHeader, "Bearer ")
+	rawToken := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := m.TokenVerifier.VerifyAccessToken(r.Context(), rawToken)
+	if pat_service.IsPersonalAccessToken(rawToken) {
+		if m.PATVerifier == nil {
+			return nil, errInvalidToken
+		}
+		pat, owner, err := m.PATVerifier.Authenticate(r.Context(), rawToken)
 		if err != nil {
-			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+			return nil, errInvalidToken
+		}
+		ctx := context.WithValue(r.Context(), ContextUserIDKey, pat.UserID)
+		ctx = context.WithValue(ctx, ContextRoleKey, owner.Role)
+		ctx = context.WithValue(ctx, ContextAuthMethodKey, AuthMethodPAT)
+		ctx = context.WithValue(ctx, ContextScopesKey, pat.Scopes)
+		return logger.ContextWithFields(ctx, zap.String(logger.UserIDKey, pat.UserID)), nil
+	}
+
+	claims, err := m.TokenVerifier.VerifyAccessToken(r.Context(), rawToken)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	ctx := context.WithValue(r.Context(), ContextUserIDKey, claims.Subject)
+	ctx = context.WithValue(ctx, ContextRoleKey, claims.Role)
+	ctx = context.WithValue(ctx, ContextAuthMethodKey, AuthMethodJWT)
+	if claims.IsImpersonated() {
+		ctx = context.WithValue(ctx, ContextActorIDKey, claims.Actor.Subject)
+	}
+	if claims.TenantID != "" {
+		ctx = models.ContextWithTenant(ctx, claims.TenantID)
+		ctx = context.WithValue(ctx, ContextTenantRoleKey, claims.TenantRole)
+	}
+	return logger.ContextWithFields(ctx, zap.String(logger.UserIDKey, claims.Subject)), nil
+}
+
+// RequireSession rejects requests authenticated with a personal access token
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if method, _ := GetAuthMethodFromContext(c.Request.Context()); method != AuthMethodJWT {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this operation requires an interactive session"})
 			return
 		}
+		c.Next()
+	}
+}
 
-		ctx := context.WithValue(r.Context(), ContextUserIDKey, claims.Subject)
-		ctx = context.WithValue(ctx, ContextRoleKey, claims.Role)
+// RequireRole rejects callers whose token does not carry one of the roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := GetUserRoleFromContext(c.Request.Context())
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// RequireTenantRole rejects callers whose token is not scoped to an
+// organization in which they hold one of the roles
+func RequireTenantRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := models.TenantFromContext(c.Request.Context()); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no organization selected"})
+			return
+		}
+		role, _ := GetTenantRoleFromContext(c.Request.Context())
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient organization role"})
+	}
 }
 
 // GetUserIDFromContext extracts user ID from context
@@ -60,3 +207,36 @@ func GetUserRoleFromContext(ctx context.Context) (string, bool) {
 	val, ok := ctx.Value(ContextRoleKey).(string)
 	return val, ok
 }
+
+// GetAuthMethodFromContext extracts the authentication method from context
+func GetAuthMethodFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ContextAuthMethodKey).(string)
+	return val, ok
+}
+
+// GetActorIDFromContext extracts the impersonating admin's ID from context.
+// It is only present while an admin impersonates the user.
+func GetActorIDFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ContextActorIDKey).(string)
+	return val, ok
+}
+
+// HasScope reports whether the caller may act within the given scope
+func HasScope(ctx context.Context, scope string) bool {
+	if method, _ := GetAuthMethodFromContext(ctx); method != AuthMethodPAT {
+		return true
+	}
+	scopes, _ := ctx.Value(ContextScopesKey).([]string)
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTenantRoleFromContext extracts the caller's role in the current organization
+func GetTenantRoleFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ContextTenantRoleKey).(string)
+	return val, ok
+}
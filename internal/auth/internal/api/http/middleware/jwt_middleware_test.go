@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	pat_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/pat"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+)
+
+const testPAT = pat_service.TokenPrefix + "read-only"
+
+// readOnlyPATs knows a single token granted content:read
+type readOnlyPATs struct {
+	ports.PersonalAccessTokenService
+}
+
+func (readOnlyPATs) Authenticate(_ context.Context, raw string) (*models.PersonalAccessToken, *models.User, error) {
+	if raw != testPAT {
+		return nil, nil, pat_service.ErrTokenInvalid
+	}
+	pat := &models.PersonalAccessToken{ID: "pat-1", UserID: "ada", Scopes: []string{models.ScopeContentRead}}
+	return pat, &models.User{ID: "ada", Role: "creator"}, nil
+}
+
+func TestGinHandlerLimitsPersonalAccessTokensToTheirScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwt, err := token.NewEphemeralTokenManager("test", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _, err := jwt.GenerateAccessToken("ada", "creator")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := NewJWTMiddleware(*jwt, readOnlyPATs{})
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/content", auth.GinHandler(models.ScopeContentRead, models.ScopeContentWrite), ok)
+	r.POST("/content", auth.GinHandler(models.ScopeContentWrite), ok)
+	r.GET("/account", auth.GinHandler(), ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"token with a granted scope", http.MethodGet, "/content", testPAT, http.StatusOK},
+		{"token without the scope", http.MethodPost, "/content", testPAT, http.StatusForbidden},
+		{"token on a route declaring no scope", http.MethodGet, "/account", testPAT, http.StatusForbidden},
+		{"unknown token", http.MethodGet, "/content", pat_service.TokenPrefix + "guessed", http.StatusUnauthorized},
+		{"session on a route declaring no scope", http.MethodGet, "/account", session, http.StatusOK},
+		{"session on a scoped route", http.MethodPost, "/content", session, http.StatusOK},
+		{"no credentials", http.MethodGet, "/content", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
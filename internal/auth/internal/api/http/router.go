@@ -1 +1,73 @@
 package http
+
+import (
+	"github.com/gin-gonic/gin"
+	handler "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/handlers"
+	authmw "github.com/istiak-004/myFolio-microservices/auth/internal/api/http/middleware"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/pkg/http/middleware"
+)
+
+// Services are the domain services exposed over HTTP
+type Services struct {
+	Auth                ports.AuthService
+	OAuth               ports.OAuthService // nil when no identity provider is configured
+	Device              ports.DeviceService
+	Account             ports.AccountService
+	Profile             ports.ProfileService
+	Organization        ports.OrganizationService
+	PersonalAccessToken ports.PersonalAccessTokenService
+	Impersonation       ports.ImpersonationService
+	Audit               ports.AuditRepository // records what impersonating admins do
+	MailQueue           ports.MailQueue       // nil when emails are sent directly
+	Mailbox             ports.Mailbox         // nil unless emails are captured for development
+	Environment         string                // app environment, which gates development-only routes
+}
+
+// Features that can be switched off at runtime in the features section of
+// the config, e.g. to close sign-ups
+const (
+	FeatureRegistration        = "registration"
+	FeatureOrganizations       = "organizations"
+	FeaturePersonalAccessToken = "personal_access_tokens"
+	FeatureImpersonation       = "impersonation"
+	FeatureDataExport          = "data_export"
+)
+
+// featureRoutes maps route prefixes to the feature serving them
+var featureRoutes = map[string]string{
+	"/auth/register":        FeatureRegistration,
+	"/auth/orgs":            FeatureOrganizations,
+	"/auth/tokens":          FeaturePersonalAccessToken,
+	"/admin/impersonations": FeatureImpersonation,
+	"/auth/me/export":       FeatureDataExport,
+}
+
+// RegisterRoutes mounts every auth API handler on the router. Routes behind
+// authentication use auth; pow guards registration and login;
+// authRateLimiter limits the /auth endpoints on top of the global limit;
+// routes of features switched off in features answer 404. It fails when a
+// development-only route is requested outside development.
+func RegisterRoutes(r *gin.Engine, services Services, auth *authmw.JWTMiddleware, pow middleware.ProofOfWorkConfig, authRateLimiter *middleware.RateLimiter, features *middleware.FeatureFlags) error {
+	r.Use(middleware.FeatureGate(features, featureRoutes))
+	// covers every group authenticated by auth, whatever its own middleware
+	r.Use(authmw.AuditImpersonation(services.Audit))
+
+	handler.NewAuthHandler(r, services.Auth, services.Device, pow, authRateLimiter)
+	if services.OAuth != nil {
+		handler.NewOAuthHandler(r, services.OAuth, services.Device)
+	}
+	handler.NewProfileHandler(r, services.Profile, services.Auth, auth)
+	handler.NewAccountHandler(r, services.Account, auth)
+	handler.NewOrganizationHandler(r, services.Organization, auth)
+	handler.NewPersonalAccessTokenHandler(r, services.PersonalAccessToken, auth)
+	handler.NewAdminHandler(r, services.Impersonation, services.Auth, auth)
+
+	if services.MailQueue != nil {
+		handler.NewMailAdminHandler(r, services.MailQueue, auth)
+	}
+	if services.Mailbox != nil {
+		return handler.NewDevMailboxHandler(r, services.Mailbox, services.Environment)
+	}
+	return nil
+}
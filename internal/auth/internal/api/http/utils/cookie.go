@@ -2,38 +2,77 @@ package utils
 
 import (
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	RefreshTokenCookieName = "refresh_token"
-	RefreshTokenPath       = "/auth/refresh"  // Only send on this path
-	RefreshTokenMaxAge     = 7 * 24 * 60 * 60 // 7 days
+	RefreshTokenPath       = "/auth"          // only sent to the auth endpoints, which include refresh and logout
+	RefreshTokenMaxAge     = 7 * 24 * 60 * 60 // 7 days, unless the refresh token lifetime is configured
 )
 
+// CookieConfig controls the attributes of the refresh token cookie
+type CookieConfig struct {
+	Domain string `mapstructure:"domain"` // empty scopes the cookie to the exact host that set it
+	Secure bool   `mapstructure:"secure"` // only send over HTTPS; enable everywhere but local development
+	// MaxAge matches the cookie to the refresh token it carries; set from jwt.refresh_expiry
+	MaxAge time.Duration `mapstructure:"-"`
+}
+
+var cookieConfig atomic.Pointer[CookieConfig]
+
+func init() {
+	cookieConfig.Store(&CookieConfig{})
+}
+
+func (c *CookieConfig) maxAge() int {
+	if c.MaxAge <= 0 {
+		return RefreshTokenMaxAge
+	}
+	return int(c.MaxAge / time.Second)
+}
+
+// SetCookieConfig replaces the cookie attributes used by SetRefreshTokenCookie,
+// ClearRefreshTokenCookie and SetCookie
+func SetCookieConfig(config CookieConfig) {
+	cookieConfig.Store(&config)
+}
+
+// SetCookie sets cookie with the configured domain and Secure attribute
+func SetCookie(w http.ResponseWriter, cookie *http.Cookie) {
+	config := cookieConfig.Load()
+	cookie.Domain = config.Domain
+	cookie.Secure = config.Secure
+	http.SetCookie(w, cookie)
+}
+
 // SetRefreshTokenCookie sets the refresh token securely as an HTTP-only cookie
-func SetRefreshTokenCookie(w http.ResponseWriter, token string, domain string) {
+func SetRefreshTokenCookie(w http.ResponseWriter, token string) {
+	config := cookieConfig.Load()
 	http.SetCookie(w, &http.Cookie{
 		Name:     RefreshTokenCookieName,
 		Value:    token,
 		Path:     RefreshTokenPath,
-		Domain:   domain, // e.g. "myapp.com"
-		MaxAge:   RefreshTokenMaxAge,
+		Domain:   config.Domain, // e.g. "myapp.com"
+		MaxAge:   config.maxAge(),
 		HttpOnly: true,
-		Secure:   false, // Set to true if using HTTPS
+		Secure:   config.Secure,
 		SameSite: http.SameSiteStrictMode,
 	})
 }
 
 // ClearRefreshTokenCookie deletes the cookie
-func ClearRefreshTokenCookie(w http.ResponseWriter, domain string) {
+func ClearRefreshTokenCookie(w http.ResponseWriter) {
+	config := cookieConfig.Load()
 	http.SetCookie(w, &http.Cookie{
 		Name:     RefreshTokenCookieName,
 		Value:    "",
 		Path:     RefreshTokenPath,
-		Domain:   domain,
+		Domain:   config.Domain,
 		MaxAge:   -1, // Delete immediately
 		HttpOnly: true,
-		Secure:   false, // Set to true if using HTTPS
+		Secure:   config.Secure,
 		SameSite: http.SameSiteStrictMode,
 	})
 }
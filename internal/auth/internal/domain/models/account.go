@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Session is an active refresh token as shown to its owner. ID is a
+// fingerprint of the token, never the token itself.
+type Session struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DataExport is the personal data archive handed to a user on request
+type DataExport struct {
+	ExportedAt  time.Time         `json:"exported_at"`
+	Profile     *User             `json:"profile"`
+	Identities  []*OauthProviders `json:"linked_identities"`
+	Sessions    []*Session        `json:"sessions"`
+	AuditEvents []*AuditEvent     `json:"audit_events"`
+}
+
+// AccountDeletion describes a scheduled account deletion
+type AccountDeletion struct {
+	UserID      string    `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+	PurgeAfter  time.Time `json:"purge_after"`
+}
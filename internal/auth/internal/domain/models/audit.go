@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Audit actions
+const (
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonatedRequest  = "impersonation.request"
+	AuditDataExported         = "account.data_exported"
+	AuditDeletionRequested    = "account.deletion_requested"
+)
+
+// AuditEvent records a security relevant action taken on a user's account.
+// ActorID differs from UserID when someone else, such as a super admin, acted.
+type AuditEvent struct {
+	ID        string            `json:"id" db:"id"`
+	UserID    string            `json:"user_id" db:"user_id"`
+	ActorID   string            `json:"actor_id,omitempty" db:"actor_id"`
+	Action    string            `json:"action" db:"action"`
+	Metadata  map[string]string `json:"metadata,omitempty" db:"metadata"`
+	IPAddress string            `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent string            `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+// ImpersonationGrant is the result of starting an impersonation session
+type ImpersonationGrant struct {
+	AccessToken string    `json:"access_token"`
+	UserID      string    `json:"user_id"`
+	ActorID     string    `json:"actor_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CapturedMail is an email kept by the development mailbox instead of being sent
+type CapturedMail struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	To        Recipient `json:"to"`
+	Subject   string    `json:"subject"`
+	HTML      string    `json:"html"`
+	Text      string    `json:"text"`
+	Links     []string  `json:"links"` // every link in the HTML body, in order
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "context"
+
+type clientInfoKey struct{}
+
+// ClientInfo describes the client a request came from
+type ClientInfo struct {
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	DeviceID  string `json:"-"` // value of the long-lived device cookie, if any
+}
+
+// ContextWithClientInfo returns a context carrying the client info
+func ContextWithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the client info, or a zero value when the context has none
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
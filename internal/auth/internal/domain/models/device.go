@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Device is a client a user has logged in from. Devices are recognised by
+// their device cookie, or failing that by browser, platform and IP prefix.
+type Device struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	CookieHash  string    `json:"-" db:"cookie_hash"`
+	Name        string    `json:"name" db:"name"` // e.g. "Firefox on Linux"
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	IPPrefix    string    `json:"ip_prefix" db:"ip_prefix"`
+	Location    string    `json:"location,omitempty" db:"location"`
+	FirstSeenAt time.Time `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// NewDeviceNotice is the content of a new-device login email
+type NewDeviceNotice struct {
+	Device    string    `json:"device"`
+	IPAddress string    `json:"ip_address"`
+	Location  string    `json:"location,omitempty"`
+	LoginAt   time.Time `json:"login_at"`
+	ReportURL string    `json:"report_url"` // "this wasn't me" link
+}
@@ -0,0 +1,14 @@
+package models
+
+import "errors"
+
+var (
+	// ErrNotFound is returned by repositories when a record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrNoTenant is returned by tenant scoped repositories when the context has no organization
+	ErrNoTenant = errors.New("no organization selected")
+	// ErrConflict is returned by repositories when a unique constraint is violated
+	ErrConflict = errors.New("record already exists")
+	// ErrLastOwner is returned by organization repositories instead of removing an organization's last owner
+	ErrLastOwner = errors.New("an organization must keep at least one owner")
+)
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Domain event types published to other services
+const (
+	EventUserRegistered  = "user.registered"
+	EventEmailVerified   = "user.email_verified"
+	EventPasswordChanged = "user.password_changed"
+	EventUserDeactivated = "user.deactivated"
+	EventUserDeleted     = "user.deleted"
+)
+
+// DomainEvent is a fact about the auth domain that other services may react to
+type DomainEvent struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	AggregateID string            `json:"aggregate_id"`
+	Payload     map[string]string `json:"payload,omitempty"`
+	OccurredAt  time.Time         `json:"occurred_at"`
+}
+
+// OutboxMessage is a domain event waiting in the outbox to be relayed to the broker
+type OutboxMessage struct {
+	Event         DomainEvent
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+}
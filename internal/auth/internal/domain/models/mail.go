@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kinds of email the auth service sends
+const (
+	MailVerification  = "verification"
+	MailPasswordReset = "password_reset"
+	MailNewDevice     = "new_device"
+)
+
+// Delivery states of a queued email
+const (
+	MailPending = "pending"
+	MailSent    = "sent"
+	MailDead    = "dead" // gave up after the maximum number of attempts
+)
+
+// QueuedMail is an email waiting in, or kept by, the durable mail queue
+type QueuedMail struct {
+	ID            string          `json:"id" db:"id"`
+	Kind          string          `json:"kind" db:"kind"`
+	Recipient     Recipient       `json:"recipient"`
+	Payload       json.RawMessage `json:"-" db:"payload"` // kind specific, e.g. the link to send; never shown, as links are live credentials
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	SentAt        *time.Time      `json:"sent_at,omitempty" db:"sent_at"`
+}
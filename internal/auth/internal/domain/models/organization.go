@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Organization roles, from most to least privileged
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+// Organization is a tenant that groups users managing the same portfolios
+type Organization struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Membership links a user to an organization with a per-organization role
+type Membership struct {
+	OrganizationID string    `json:"organization_id" db:"organization_id"`
+	UserID         string    `json:"user_id" db:"user_id"`
+	Role           string    `json:"role" db:"role" validate:"oneof=owner admin editor viewer"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// OrganizationMembership is an organization as seen by one of its members
+type OrganizationMembership struct {
+	Organization
+	Role string `json:"role" db:"role"`
+}
+
+// MemberDetails is a membership together with the member's public profile
+type MemberDetails struct {
+	Membership
+	Email     string `json:"email" db:"email"`
+	FirstName string `json:"first_name,omitempty" db:"first_name"`
+	LastName  string `json:"last_name,omitempty" db:"last_name"`
+}
+
+// IsOrgRole reports whether role is a known organization role
+func IsOrgRole(role string) bool {
+	switch role {
+	case OrgRoleOwner, OrgRoleAdmin, OrgRoleEditor, OrgRoleViewer:
+		return true
+	}
+	return false
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant scopes a context to an organization
+func ContextWithTenant(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, organizationID)
+}
+
+// TenantFromContext returns the organization a context is scoped to
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PasswordHistoryEntry records a password hash a user has used, to prevent reuse
+type PasswordHistoryEntry struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Scopes that can be granted to a personal access token
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeContentRead  = "content:read"
+	ScopeContentWrite = "content:write"
+)
+
+// PersonalAccessTokenScopes lists every scope a user may request for a token
+var PersonalAccessTokenScopes = []string{
+	ScopeProfileRead,
+	ScopeProfileWrite,
+	ScopeContentRead,
+	ScopeContentWrite,
+}
+
+// PersonalAccessToken is a long-lived credential used by scripts and CI.
+// Only the SHA-256 hash of the token is persisted; the plain value is shown once.
+type PersonalAccessToken struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsActive reports whether the token is neither revoked nor expired
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// HasScope reports whether the token was granted the given scope
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
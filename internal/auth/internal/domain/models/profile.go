@@ -0,0 +1,31 @@
+package models
+
+// ProfileUpdate holds the profile fields a user may edit themselves.
+// Nil fields are left unchanged; an empty string clears the field.
+type ProfileUpdate struct {
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Locale      *string `json:"locale"`
+	Timezone    *string `json:"timezone"`
+}
+
+// Apply copies the set fields onto the user
+func (u ProfileUpdate) Apply(user *User) {
+	for _, f := range []struct {
+		value *string
+		field *string
+	}{
+		{u.FirstName, &user.FirstName},
+		{u.LastName, &user.LastName},
+		{u.DisplayName, &user.DisplayName},
+		{u.AvatarURL, &user.AvatarURL},
+		{u.Locale, &user.Locale},
+		{u.Timezone, &user.Timezone},
+	} {
+		if f.value != nil {
+			*f.field = *f.value
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+// Recipient is the person an email is addressed to. Locale and Timezone are
+// optional and select the template language and how times are shown.
+type Recipient struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// Recipient addresses an email to the user, preferring their display name
+func (u *User) Recipient() Recipient {
+	name := u.DisplayName
+	if name == "" {
+		name = u.FirstName
+	}
+	return Recipient{
+		Email:    u.Email,
+		Name:     name,
+		Locale:   u.Locale,
+		Timezone: u.Timezone,
+	}
+}
@@ -8,8 +8,27 @@ type TokenPair struct {
 	ExpiresIn    int    `json:"expires_in"`
 }
 
+// TokenLifetimes are how long issued access and refresh tokens stay valid
+type TokenLifetimes struct {
+	AccessExpiry  time.Duration `mapstructure:"access_expiry" validate:"required"`
+	RefreshExpiry time.Duration `mapstructure:"refresh_expiry" validate:"required,gtfield=AccessExpiry"`
+}
+
+// Pair bundles freshly issued tokens with the lifetime of the access token
+func (l TokenLifetimes) Pair(access, refresh string) *TokenPair {
+	return &TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int(l.AccessExpiry / time.Second)}
+}
+
+// Verification token purposes. A token can only be redeemed for its own purpose.
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+	PurposeDeviceReport      = "device_report"
+)
+
 type VerificationToken struct {
 	Token     string    `json:"token"`
+	Purpose   string    `json:"purpose"`
 	UserID    string    `json:"user_id"`
 	ExpiresAt time.Time `json:"expires_at"`
 	IPAddress string    `json:"ip_address,omitempty"`
@@ -2,21 +2,31 @@ package models
 
 import "time"
 
+// RoleSuperAdmin is the role of operators allowed into the admin API. The
+// role, carried in access tokens, is the only thing that makes a super admin;
+// the IsAdmin and IsSuperAdmin flags are not used for authorization.
+const RoleSuperAdmin = "super_admin"
+
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email" validate:"required,email"`
-	PasswordHash string    `json:"-" db:"password_hash" validate:"required,min=8"`
-	GoogleID     string    `json:"-" db:"google_id"`
-	GitHubID     string    `json:"-" db:"github_id"`
-	Role         string    `json:"role" db:"role" validate:"oneof=admin visitor super_admin"`
-	FirstName    string    `json:"first_name,omitempty" db:"first_name"`
-	LastName     string    `json:"last_name,omitempty" db:"last_name"`
-	IsActive     bool      `json:"is_active" db:"is_active"`
-	IsVerified   bool      `json:"is_verified" db:"is_verified"`
-	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
-	IsSuperAdmin bool      `json:"is_super_admin" db:"is_super_admin"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID           string     `json:"id" db:"id"`
+	Email        string     `json:"email" db:"email" validate:"required,email"`
+	PasswordHash string     `json:"-" db:"password_hash" validate:"required,min=8"`
+	GoogleID     string     `json:"-" db:"google_id"`
+	GitHubID     string     `json:"-" db:"github_id"`
+	Role         string     `json:"role" db:"role" validate:"oneof=admin visitor super_admin"`
+	FirstName    string     `json:"first_name,omitempty" db:"first_name"`
+	LastName     string     `json:"last_name,omitempty" db:"last_name"`
+	DisplayName  string     `json:"display_name,omitempty" db:"display_name"`
+	AvatarURL    string     `json:"avatar_url,omitempty" db:"avatar_url"`
+	Locale       string     `json:"locale,omitempty" db:"locale"`
+	Timezone     string     `json:"timezone,omitempty" db:"timezone"`
+	IsActive     bool       `json:"is_active" db:"is_active"`
+	IsVerified   bool       `json:"is_verified" db:"is_verified"`
+	IsAdmin      bool       `json:"is_admin" db:"is_admin"`
+	IsSuperAdmin bool       `json:"is_super_admin" db:"is_super_admin"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // set while a deletion awaits its grace period
 }
 
 // EmailVerification represents an email verification request
@@ -0,0 +1,33 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+// EventPublisher delivers domain events to other services
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.DomainEvent) error
+}
+
+// OutboxRepository stores domain events alongside the state changes that
+// produced them, so an event is recorded if and only if its change commits
+type OutboxRepository interface {
+	Add(ctx context.Context, event models.DomainEvent) error
+	// ListPending locks and returns up to limit events that are due for
+	// delivery, oldest first. It must be called inside a transaction, which
+	// should only last until the events are leased.
+	ListPending(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	// Lease hides an event from ListPending until the given time
+	Lease(ctx context.Context, eventID string, until time.Time) error
+	MarkPublished(ctx context.Context, eventID string, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, eventID, reason string, nextAttemptAt time.Time) error
+}
+
+// Transactor runs a function in a single database transaction. Repositories
+// called with the context passed to fn take part in that transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
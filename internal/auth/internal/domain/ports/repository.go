@@ -9,29 +9,110 @@ import (
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
 )
 
-// UserRepository defines the interface for user persistence
+// UserRepository defines the interface for user persistence. The single user
+// lookups return models.ErrNotFound, never a nil user, when no live user
+// matches; soft-deleted users are not found.
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id string) (*models.User, error)
+	FindByIDs(ctx context.Context, ids []string) ([]*models.User, error)
 	FindByGoogleID(ctx context.Context, googleID string) (*models.User, error)
 	FindByGitHubID(ctx context.Context, githubID string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	ListIdentities(ctx context.Context, userID string) ([]*models.OauthProviders, error)
+	// SoftDelete deactivates the user and hides them from lookups until they are purged
+	SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error)
+	// HardDelete erases a user with everything stored about them: identities,
+	// verification and refresh tokens, personal access tokens, devices,
+	// memberships, audit events, queued mail and outbox events. An organization
+	// the user is the only owner of passes to its longest-standing admin, or
+	// member when it has no admin, and is deleted when nobody else is left.
+	// It joins the transaction carried by ctx.
+	HardDelete(ctx context.Context, userID string) error
 }
 
 // VerificationRepository defines the interface for verification token persistence
 type VerificationRepository interface {
 	Create(ctx context.Context, token *models.VerificationToken) error
+	// Get returns models.ErrNotFound when the token does not exist
 	Get(ctx context.Context, token string) (*models.VerificationToken, error)
 	Delete(ctx context.Context, token string) error
 }
 
 type TokenRepository interface {
 	GenerateRefreshToken(ctx context.Context, token valueobjects.Token) (string, error)
-	StoreRefreshToken(ctx context.Context, userID string, token valueobjects.Token, expiresAt time.Time) error
+	// StoreRefreshToken stores a token of userID. tenantID is the organization
+	// the token is scoped to, empty for tokens not scoped to one.
+	StoreRefreshToken(ctx context.Context, userID, tenantID string, token valueobjects.Token, expiresAt time.Time) error
 	VerifyRefreshToken(ctx context.Context, token valueobjects.Token) (string, error)
 	RevokeRefreshToken(ctx context.Context, token valueobjects.Token) error
-	RotateRefreshToken(ctx context.Context, oldToken valueobjects.Token) (valueobjects.Token, error)
+	// RotateRefreshToken replaces a token with one scoped to the same
+	// organization, which it returns with the new token
+	RotateRefreshToken(ctx context.Context, oldToken valueobjects.Token) (valueobjects.Token, string, error)
 	RevokeAllForUser(ctx context.Context, userID string) error
 	GetRefreshToken(ctx context.Context, token valueobjects.Token) (string, error)
+	ListSessions(ctx context.Context, userID string) ([]*models.Session, error)
+}
+
+// PersonalAccessTokenRepository defines the interface for personal access token persistence
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	ListByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+// PasswordHistoryRepository defines the interface for password history persistence
+type PasswordHistoryRepository interface {
+	Add(ctx context.Context, entry *models.PasswordHistoryEntry) error
+	ListRecent(ctx context.Context, userID string, limit int) ([]*models.PasswordHistoryEntry, error)
+}
+
+// AuditRepository defines the interface for audit trail persistence
+type AuditRepository interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+	ListByUser(ctx context.Context, userID string) ([]*models.AuditEvent, error)
+}
+
+// OrganizationRepository defines the interface for organization persistence.
+// Member queries are scoped to the organization carried by the context
+// (see models.ContextWithTenant) and fail with models.ErrNoTenant without one.
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *models.Organization, owner *models.Membership) error
+	FindByID(ctx context.Context, id string) (*models.Organization, error)
+	ListForUser(ctx context.Context, userID string) ([]*models.OrganizationMembership, error)
+	FindMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error)
+	AddMember(ctx context.Context, member *models.Membership) error
+	ListMembers(ctx context.Context) ([]*models.MemberDetails, error)
+	// RemoveMember fails with models.ErrLastOwner instead of removing the
+	// organization's last owner, also when owners are removed concurrently
+	RemoveMember(ctx context.Context, userID string) error
+}
+
+// MailQueueRepository defines the interface for durable mail queue persistence
+type MailQueueRepository interface {
+	Enqueue(ctx context.Context, mail *models.QueuedMail) error
+	// ClaimDue locks and returns up to limit pending emails that are due,
+	// oldest first. It must be called inside a transaction, which should only
+	// last until the emails are leased with Update.
+	ClaimDue(ctx context.Context, limit int) ([]*models.QueuedMail, error)
+	// Update persists the delivery state: status, attempts, last error, next attempt and sent time
+	Update(ctx context.Context, mail *models.QueuedMail) error
+	FindByID(ctx context.Context, id string) (*models.QueuedMail, error)
+	ListByStatus(ctx context.Context, status string, limit int) ([]*models.QueuedMail, error)
+	// DeleteFinishedBefore deletes sent and dead emails created before the given time
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// DeviceRepository defines the interface for known device persistence
+type DeviceRepository interface {
+	Create(ctx context.Context, device *models.Device) error
+	ListByUser(ctx context.Context, userID string) ([]*models.Device, error)
+	// Touch records a new sighting of the device, and its cookie if it did not have one yet
+	Touch(ctx context.Context, id, cookieHash string, seenAt time.Time) error
+	DeleteAllForUser(ctx context.Context, userID string) error
 }
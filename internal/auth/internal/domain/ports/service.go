@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
@@ -14,6 +15,14 @@ type AuthService interface {
 	VerifyEmail(ctx context.Context, token valueobjects.Token) error
 	RefreshToken(ctx context.Context, refreshToken valueobjects.Token) (*models.TokenPair, error)
 	Logout(ctx context.Context, refreshToken valueobjects.Token) error
+	ChangePassword(ctx context.Context, userID string, current, next valueobjects.Password) error
+	RequestPasswordReset(ctx context.Context, email valueobjects.Email) error
+	ResetPassword(ctx context.Context, token valueobjects.Token, password valueobjects.Password) error
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	// GetUsersByIDs returns the users that exist, in no particular order
+	GetUsersByIDs(ctx context.Context, userIDs []string) ([]*models.User, error)
+	RevokeUserSessions(ctx context.Context, userID string) error
+	DeactivateUser(ctx context.Context, userID string) error
 }
 
 type OAuthService interface {
@@ -22,6 +31,84 @@ type OAuthService interface {
 	RegisterOrLoginGoogle(ctx context.Context, email, name string) (*models.TokenPair, error)
 }
 
+// PersonalAccessTokenService manages personal access tokens for scripts and CI
+type PersonalAccessTokenService interface {
+	Create(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (*models.PersonalAccessToken, string, error)
+	List(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+	// Authenticate returns the token and its owner, whose role the token acts with
+	Authenticate(ctx context.Context, rawToken string) (*models.PersonalAccessToken, *models.User, error)
+}
+
+// ImpersonationService lets super admins act as another user for support purposes
+type ImpersonationService interface {
+	Impersonate(ctx context.Context, actorID, userID, reason string, duration time.Duration) (*models.ImpersonationGrant, error)
+}
+
+// OrganizationService manages organizations and memberships. Member operations
+// act on the organization carried by the context.
+type OrganizationService interface {
+	Create(ctx context.Context, userID, name string) (*models.Organization, error)
+	ListForUser(ctx context.Context, userID string) ([]*models.OrganizationMembership, error)
+	AddMember(ctx context.Context, actorID, email, role string) (*models.Membership, error)
+	ListMembers(ctx context.Context) ([]*models.MemberDetails, error)
+	RemoveMember(ctx context.Context, actorID, userID string) error
+	Switch(ctx context.Context, userID, organizationID string) (*models.TokenPair, error)
+}
+
+// ProfileService lets users read and edit their own profile
+type ProfileService interface {
+	Get(ctx context.Context, userID string) (*models.User, error)
+	Update(ctx context.Context, userID string, update models.ProfileUpdate) (*models.User, error)
+}
+
+// AccountService handles personal data requests: export and self-deletion
+type AccountService interface {
+	Export(ctx context.Context, userID string) (*models.DataExport, error)
+	RequestDeletion(ctx context.Context, userID string, password valueobjects.Password) (*models.AccountDeletion, error)
+	// PurgeDeleted hard-deletes accounts whose grace period has ended and reports how many were purged
+	PurgeDeleted(ctx context.Context) (int, error)
+}
+
+// DeviceService detects logins from devices a user has not used before
+type DeviceService interface {
+	// CheckLogin records the device from the context's client info and emails
+	// the user when it is new. Logins are keyed by email for both password and OAuth.
+	CheckLogin(ctx context.Context, email string) error
+	// ReportUnrecognized handles a "this wasn't me" link: it signs the user out
+	// everywhere and forgets their devices. It returns the affected user.
+	ReportUnrecognized(ctx context.Context, token valueobjects.Token) (*models.User, error)
+}
+
+// GeoLocator resolves an IP address to an approximate, human readable location
+type GeoLocator interface {
+	Locate(ctx context.Context, ip string) (string, error)
+}
+
 type Mailer interface {
-	SendVerificationEmail(email, name, verificationURL string) error
+	SendVerificationEmail(ctx context.Context, to models.Recipient, verificationURL string) error
+	SendPasswordResetEmail(ctx context.Context, to models.Recipient, resetURL string) error
+	SendNewDeviceEmail(ctx context.Context, to models.Recipient, notice models.NewDeviceNotice) error
+}
+
+// Mailbox lists emails captured instead of sent, for local development
+type Mailbox interface {
+	List(ctx context.Context) ([]*models.CapturedMail, error)
+	Get(ctx context.Context, id string) (*models.CapturedMail, error)
+	Clear(ctx context.Context) error
+}
+
+// MailQueue is a Mailer that stores emails durably and delivers them in the
+// background, retrying failures and dead-lettering what cannot be delivered
+type MailQueue interface {
+	Mailer
+	// DeliverDue sends a batch of emails that are due and reports how many were attempted
+	DeliverDue(ctx context.Context) (int, error)
+	List(ctx context.Context, status string, limit int) ([]*models.QueuedMail, error)
+	Get(ctx context.Context, id string) (*models.QueuedMail, error)
+	// Redrive schedules a dead email for immediate delivery with a fresh attempt budget
+	Redrive(ctx context.Context, id string) (*models.QueuedMail, error)
+	// PurgeFinished deletes sent and dead emails older than the retention period
+	// and reports how many were deleted
+	PurgeFinished(ctx context.Context) (int, error)
 }
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
 )
@@ -9,5 +10,7 @@ import (
 type JWTService interface {
 	GenerateAccessToken(userID, role string) (signed string, jti string, err error)
 	GenerateRefreshToken(userID string) (signed string, jti string, err error)
+	GenerateTenantAccessToken(userID, role, tenantID, tenantRole string) (signed string, jti string, err error)
+	GenerateImpersonationToken(userID, role, actorID string, ttl time.Duration) (signed string, jti string, err error)
 	VerifyAccessToken(ctx context.Context, tokenStr string) (*token.CustomClaims, error)
 }
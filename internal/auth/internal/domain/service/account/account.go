@@ -0,0 +1,185 @@
+package account_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+const (
+	DefaultGracePeriod = 30 * 24 * time.Hour
+	purgeBatchSize     = 100
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("password is incorrect")
+)
+
+type accountService struct {
+	users       ports.UserRepository
+	tokens      ports.TokenRepository
+	pats        ports.PersonalAccessTokenRepository
+	audit       ports.AuditRepository
+	events      ports.EventPublisher
+	gracePeriod time.Duration
+}
+
+// NewAccountService creates the account service. Deleted accounts are kept
+// for gracePeriod before they are purged; zero selects DefaultGracePeriod.
+func NewAccountService(
+	users ports.UserRepository,
+	tokens ports.TokenRepository,
+	pats ports.PersonalAccessTokenRepository,
+	audit ports.AuditRepository,
+	events ports.EventPublisher,
+	gracePeriod time.Duration,
+) ports.AccountService {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+	return &accountService{
+		users:       users,
+		tokens:      tokens,
+		pats:        pats,
+		audit:       audit,
+		events:      events,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// Export collects everything stored about a user
+func (s *accountService) Export(ctx context.Context, userID string) (*models.DataExport, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.users.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.tokens.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.audit.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.record(ctx, userID, models.AuditDataExported, now); err != nil {
+		return nil, err
+	}
+	return &models.DataExport{
+		ExportedAt:  now,
+		Profile:     user,
+		Identities:  identities,
+		Sessions:    sessions,
+		AuditEvents: events,
+	}, nil
+}
+
+// RequestDeletion soft-deletes the account and revokes all of its tokens. Users
+// with a password must confirm it; accounts created through OAuth have none.
+func (s *accountService) RequestDeletion(ctx context.Context, userID string, password valueobjects.Password) (*models.AccountDeletion, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != "" && !password.Matches(user.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if err := s.users.SoftDelete(ctx, userID, now); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.pats.RevokeAllForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, userID, models.AuditDeletionRequested, now); err != nil {
+		return nil, err
+	}
+
+	return &models.AccountDeletion{
+		UserID:      userID,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(s.gracePeriod),
+	}, nil
+}
+
+// PurgeDeleted hard-deletes accounts whose grace period has ended. The
+// deletion event is published before the user is removed, so a failure in
+// between leads to a repeated event rather than a lost one.
+func (s *accountService) PurgeDeleted(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		users, err := s.users.ListDeletedBefore(ctx, time.Now().Add(-s.gracePeriod), purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, user := range users {
+			if err := s.events.Publish(ctx, deletionEvent(user)); err != nil {
+				return purged, err
+			}
+			// refresh tokens may be kept apart from the users, e.g. in Redis
+			if err := s.tokens.RevokeAllForUser(ctx, user.ID); err != nil {
+				return purged, err
+			}
+			if err := s.users.HardDelete(ctx, user.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (s *accountService) record(ctx context.Context, userID, action string, at time.Time) error {
+	client := models.ClientInfoFromContext(ctx)
+	return s.audit.Record(ctx, &models.AuditEvent{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Action:    action,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		CreatedAt: at,
+	})
+}
+
+// deletionEvent tells other services to erase their data about the user.
+// It deliberately carries no personal data.
+func deletionEvent(user *models.User) models.DomainEvent {
+	payload := map[string]string{}
+	if user.DeletedAt != nil {
+		payload["requested_at"] = user.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return models.DomainEvent{
+		ID:          uuid.New().String(),
+		Type:        models.EventUserDeleted,
+		AggregateID: user.ID,
+		Payload:     payload,
+		OccurredAt:  time.Now(),
+	}
+}
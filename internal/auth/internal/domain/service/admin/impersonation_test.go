@@ -0,0 +1,170 @@
+package admin_service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/memory"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+)
+
+// auditTrail keeps recorded events in memory, or fails with err
+type auditTrail struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+	err    error
+}
+
+func (a *auditTrail) Record(_ context.Context, event *models.AuditEvent) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	return nil
+}
+
+func (a *auditTrail) ListByUser(_ context.Context, userID string) ([]*models.AuditEvent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var events []*models.AuditEvent
+	for _, event := range a.events {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type testImpersonation struct {
+	users *memory.UserRepository
+	jwt   *token.JWTTokenManager
+	audit *auditTrail
+	ids   map[string]string
+}
+
+func newTestImpersonation(t *testing.T) *testImpersonation {
+	t.Helper()
+	jwt, err := token.NewEphemeralTokenManager("test", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &testImpersonation{users: memory.NewUserRepository(), jwt: jwt, audit: &auditTrail{}, ids: map[string]string{}}
+	for _, u := range []struct {
+		name, role string
+		active     bool
+	}{
+		{"root", models.RoleSuperAdmin, true},
+		{"other-root", models.RoleSuperAdmin, true},
+		{"ada", "creator", true},
+		{"grace", "visitor", true},
+		{"linus", "creator", false},
+	} {
+		user := &models.User{Email: u.name + "@example.com", Role: u.role, IsActive: u.active}
+		if err := h.users.Create(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+		h.ids[u.name] = user.ID
+	}
+	return h
+}
+
+func TestImpersonateIssuesAnAuditedShortLivedToken(t *testing.T) {
+	h := newTestImpersonation(t)
+	service := NewImpersonationService(h.users, h.jwt, h.audit)
+	ctx := models.ContextWithClientInfo(context.Background(), models.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "support-console"})
+
+	grant, err := service.Impersonate(ctx, h.ids["root"], h.ids["ada"], "  ticket #4711  ", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	if grant.UserID != h.ids["ada"] || grant.ActorID != h.ids["root"] {
+		t.Errorf("grant for %s by %s, want ada by root", grant.UserID, grant.ActorID)
+	}
+	if ttl := time.Until(grant.ExpiresAt); ttl < 9*time.Minute || ttl > 10*time.Minute {
+		t.Errorf("grant expires in %v, want 10m", ttl)
+	}
+
+	claims, err := h.jwt.VerifyAccessToken(ctx, grant.AccessToken)
+	if err != nil {
+		t.Fatalf("the grant's token does not verify: %v", err)
+	}
+	if claims.Subject != h.ids["ada"] || claims.Role != "creator" || !claims.IsImpersonated() || claims.Actor.Subject != h.ids["root"] {
+		t.Errorf("claims %+v do not name ada as subject and root as actor", claims)
+	}
+	if claims.ExpiresAt.Time.After(grant.ExpiresAt.Add(time.Second)) {
+		t.Errorf("token outlives the grant: %v > %v", claims.ExpiresAt.Time, grant.ExpiresAt)
+	}
+
+	events, _ := h.audit.ListByUser(ctx, h.ids["ada"])
+	if len(events) != 1 {
+		t.Fatalf("got %d audit events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Action != models.AuditImpersonationStarted || event.ActorID != h.ids["root"] {
+		t.Errorf("audited %s by %s", event.Action, event.ActorID)
+	}
+	if event.Metadata["reason"] != "ticket #4711" || event.Metadata["jti"] != claims.ID {
+		t.Errorf("audit metadata %v lacks the reason or the token ID %s", event.Metadata, claims.ID)
+	}
+	if event.IPAddress != "203.0.113.7" || event.UserAgent != "support-console" {
+		t.Errorf("audited client %s %q", event.IPAddress, event.UserAgent)
+	}
+}
+
+func TestImpersonateRefusals(t *testing.T) {
+	tests := []struct {
+		name     string
+		actor    string
+		target   string
+		reason   string
+		duration time.Duration
+		wantErr  error
+	}{
+		{"no reason", "root", "ada", " ", 0, ErrReasonRequired},
+		{"too short", "root", "ada", "ticket", 30 * time.Second, ErrInvalidImpersonation},
+		{"too long", "root", "ada", "ticket", 2 * time.Hour, ErrInvalidImpersonation},
+		{"actor is not a super admin", "grace", "ada", "ticket", 0, ErrNotSuperAdmin},
+		{"actor is unknown", "", "ada", "ticket", 0, ErrNotSuperAdmin},
+		{"target is unknown", "root", "", "ticket", 0, ErrUserNotFound},
+		{"target is a super admin", "root", "other-root", "ticket", 0, ErrCannotImpersonate},
+		{"target is yourself", "root", "root", "ticket", 0, ErrCannotImpersonate},
+		{"target is deactivated", "root", "linus", "ticket", 0, ErrCannotImpersonate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestImpersonation(t)
+			service := NewImpersonationService(h.users, h.jwt, h.audit)
+			actor, target := h.ids[tt.actor], h.ids[tt.target]
+			if actor == "" {
+				actor = "unknown"
+			}
+			if target == "" {
+				target = "unknown"
+			}
+
+			grant, err := service.Impersonate(context.Background(), actor, target, tt.reason, tt.duration)
+			if !errors.Is(err, tt.wantErr) || grant != nil {
+				t.Errorf("got %v, %v; want %v", grant, err, tt.wantErr)
+			}
+			if len(h.audit.events) != 0 {
+				t.Errorf("a refused impersonation was audited: %+v", h.audit.events[0])
+			}
+		})
+	}
+}
+
+func TestImpersonateWithoutAuditFails(t *testing.T) {
+	h := newTestImpersonation(t)
+	h.audit.err = errors.New("audit table unavailable")
+	service := NewImpersonationService(h.users, h.jwt, h.audit)
+
+	if grant, err := service.Impersonate(context.Background(), h.ids["root"], h.ids["ada"], "ticket", 0); err == nil || grant != nil {
+		t.Errorf("got %v, %v; want no token without an audit record", grant, err)
+	}
+}
@@ -3,12 +3,15 @@ package auth_service
 import (
 	"context"
 	"errors"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
 )
 
 var (
@@ -17,14 +20,84 @@ var (
 	ErrUserNotVerified    = errors.New("user not verified")
 	ErrTokenExpired       = errors.New("token expired")
 	ErrTokenInvalid       = errors.New("invalid token")
+	ErrUserNotFound       = errors.New("user not found")
 )
 
+const passwordResetTTL = time.Hour
+
 type authService struct {
 	users         ports.UserRepository
 	verifications ports.VerificationRepository
 	tokens        ports.TokenRepository
 	jwt           ports.JWTService
+	lifetimes     models.TokenLifetimes
 	mailer        ports.Mailer
+
+	passwordHistory  ports.PasswordHistoryRepository
+	devices          ports.DeviceService
+	passwordResetURL string
+	verificationURL  string
+	transactor       ports.Transactor
+	outbox           ports.OutboxRepository
+	organizations    ports.OrganizationRepository
+}
+
+// Option configures optional collaborators of the auth service
+type Option func(*authService)
+
+// WithPasswordHistory enables password history, so users cannot reuse their
+// last passwords. How many are remembered is set by the password policy.
+func WithPasswordHistory(history ports.PasswordHistoryRepository) Option {
+	return func(s *authService) {
+		s.passwordHistory = history
+	}
+}
+
+// WithDeviceTracking emails users when they log in from a device they have not used before
+func WithDeviceTracking(devices ports.DeviceService) Option {
+	return func(s *authService) {
+		s.devices = devices
+	}
+}
+
+// WithPasswordResetURL sets the page password reset emails link to; the reset
+// token is appended as the token query parameter
+func WithPasswordResetURL(resetURL string) Option {
+	return func(s *authService) {
+		s.passwordResetURL = resetURL
+	}
+}
+
+// WithVerificationURL sets the page verification emails link to; the
+// verification token is appended as the token query parameter. Without it
+// the email carries the bare token.
+func WithVerificationURL(verificationURL string) Option {
+	return func(s *authService) {
+		s.verificationURL = verificationURL
+	}
+}
+
+// WithTransactor makes multi-step state changes, such as registration, atomic
+func WithTransactor(transactor ports.Transactor) Option {
+	return func(s *authService) {
+		s.transactor = transactor
+	}
+}
+
+// WithOutbox records domain events in the outbox, in the same transaction as
+// the change they describe. It should be combined with WithTransactor.
+func WithOutbox(outbox ports.OutboxRepository) Option {
+	return func(s *authService) {
+		s.outbox = outbox
+	}
+}
+
+// WithOrganizations keeps refreshed access tokens scoped to the organization
+// the user switched to, for as long as they remain a member of it
+func WithOrganizations(organizations ports.OrganizationRepository) Option {
+	return func(s *authService) {
+		s.organizations = organizations
+	}
 }
 
 func NewAuthService(
@@ -32,9 +105,22 @@ func NewAuthService(
 	verifications ports.VerificationRepository,
 	tokens ports.TokenRepository,
 	jwt ports.JWTService,
+	lifetimes models.TokenLifetimes,
 	mailer ports.Mailer,
+	opts ...Option,
 ) ports.AuthService {
-	return &authService{users, verifications, tokens, jwt, mailer}
+	s := &authService{
+		users:         users,
+		verifications: verifications,
+		tokens:        tokens,
+		jwt:           jwt,
+		lifetimes:     lifetimes,
+		mailer:        mailer,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *authService) Register(ctx context.Context, email valueobjects.Email, password valueobjects.Password, name string) (*models.User, error) {
@@ -43,8 +129,8 @@ func (s *authService) Register(ctx context.Context, email valueobjects.Email, pa
 		return nil, err
 	}
 
-	existingUser, err := s.users.FindByEmail(ctx, email.String())
-	if existingUser != nil || err != nil {
+	// a lookup error is not conclusive; the unique email constraint has the final say
+	if existingUser, _ := s.users.FindByEmail(ctx, email.String()); existingUser != nil {
 		return nil, ErrEmailExists
 	}
 
@@ -59,65 +145,130 @@ func (s *authService) Register(ctx context.Context, email valueobjects.Email, pa
 		FirstName:    name,
 		Role:         "visitor",
 	}
-	if err := s.users.Create(ctx, user); err != nil {
-		return nil, err
-	}
 	token := valueobjects.NewToken()
-	verif := &models.VerificationToken{
-		Token:     token.String(),
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-	}
-	if err := s.verifications.Create(ctx, verif); err != nil {
+	verificationLink := s.linkWithToken(s.verificationURL, token)
+	_, queued := s.mailer.(ports.MailQueue)
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Create(ctx, user); err != nil {
+			if errors.Is(err, models.ErrConflict) {
+				return ErrEmailExists
+			}
+			return err
+		}
+		if err := s.recordPassword(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		verif := &models.VerificationToken{
+			Token:     token.String(),
+			Purpose:   models.PurposeEmailVerification,
+			UserID:    user.ID,
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}
+		if err := s.verifications.Create(ctx, verif); err != nil {
+			return err
+		}
+		if err := s.emit(ctx, models.EventUserRegistered, user.ID, map[string]string{"email": user.Email}); err != nil {
+			return err
+		}
+		// a durable mail queue enqueues in the same transaction, so a user that
+		// cannot be sent a verification email is not created
+		if queued {
+			return s.mailer.SendVerificationEmail(ctx, user.Recipient(), verificationLink)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	_ = s.mailer.SendVerificationEmail(user.Email, user.FirstName, token.String())
+	// any other mailer talks to the mail server, which must not happen while
+	// the transaction is open
+	if !queued {
+		_ = s.mailer.SendVerificationEmail(ctx, user.Recipient(), verificationLink)
+	}
 	return user, nil
 }
 
 func (s *authService) Login(ctx context.Context, email valueobjects.Email, password valueobjects.Password) (*models.TokenPair, error) {
 	user, err := s.users.FindByEmail(ctx, email.String())
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
 		return nil, err
 	}
-	if !password.Matches(user.PasswordHash) {
-		return nil, errors.New("invalid credentials")
+	// deactivated accounts are indistinguishable from wrong passwords
+	if !user.IsActive || !password.Matches(user.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// transparently upgrade hashes produced under an older hashing policy
+	if password.NeedsRehash(user.PasswordHash) {
+		if hash, err := password.Hash(); err == nil {
+			user.PasswordHash = hash
+			user.UpdatedAt = time.Now()
+			_ = s.users.Update(ctx, user)
+		}
 	}
 
-	access, jti, err := s.jwt.GenerateAccessToken(user.ID, user.Role)
+	access, _, err := s.jwt.GenerateAccessToken(user.ID, user.Role)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken := valueobjects.NewTokenWithJTI(jti)
+	// the signed refresh token is stored as is, since that is what clients present on refresh
 	refresh, _, err := s.jwt.GenerateRefreshToken(user.ID)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.tokens.StoreRefreshToken(ctx, user.ID, refreshToken, time.Now().Add(7*24*time.Hour)); err != nil {
+	if err := s.tokens.StoreRefreshToken(ctx, user.ID, "", valueobjects.Token{TokenString: refresh}, time.Now().Add(s.lifetimes.RefreshExpiry)); err != nil {
 		return nil, err
 	}
-	return &models.TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: 3600}, nil
+	// the device check may send an email; it must never slow down or block an
+	// otherwise valid login, so it runs on its own
+	if s.devices != nil {
+		go s.checkDevice(context.WithoutCancel(ctx), user.Email)
+	}
+	return s.lifetimes.Pair(access, refresh), nil
+}
+
+func (s *authService) checkDevice(ctx context.Context, email string) {
+	if err := s.devices.CheckLogin(ctx, email); err != nil {
+		logger.FromContext(ctx).Warn("Failed to check the login device", zap.Error(err))
+	}
 }
 
 func (s *authService) VerifyEmail(ctx context.Context, token valueobjects.Token) error {
 	verif, err := s.verifications.Get(ctx, token.String())
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrTokenInvalid
+	}
 	if err != nil {
 		return err
 	}
+	if verif.Purpose != models.PurposeEmailVerification {
+		return ErrTokenInvalid
+	}
 	if verif.ExpiresAt.Before(time.Now()) {
-		return errors.New("verification token expired")
+		return ErrTokenExpired
 	}
 	user, err := s.users.FindByID(ctx, verif.UserID)
+	// the account was deleted after the link was sent
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrTokenInvalid
+	}
 	if err != nil {
 		return err
 	}
 	user.IsVerified = true
 	user.UpdatedAt = time.Now()
-	if err := s.users.Update(ctx, user); err != nil {
-		return err
-	}
-	_ = s.verifications.Delete(ctx, token.String())
-	return nil
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.verifications.Delete(ctx, token.String()); err != nil {
+			return err
+		}
+		return s.emit(ctx, models.EventEmailVerified, user.ID, map[string]string{"email": user.Email})
+	})
 }
 
 func (s *authService) RefreshToken(ctx context.Context, refreshToken valueobjects.Token) (*models.TokenPair, error) {
@@ -125,15 +276,270 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken valueobject
 	if err != nil {
 		return nil, err
 	}
-	newToken, err := s.tokens.RotateRefreshToken(ctx, refreshToken)
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserNotFound
+	}
+	newToken, tenantID, err := s.tokens.RotateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
-	access, _, err := s.jwt.GenerateAccessToken(userID, "visitor")
+	access, err := s.refreshedAccessToken(ctx, user, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	return &models.TokenPair{AccessToken: access, RefreshToken: newToken.String(), ExpiresIn: 3600}, nil
+	return s.lifetimes.Pair(access, newToken.String()), nil
+}
+
+// refreshedAccessToken issues an access token scoped to tenantID, the
+// organization the refresh token was issued for, unless the user has left it
+func (s *authService) refreshedAccessToken(ctx context.Context, user *models.User, tenantID string) (string, error) {
+	if tenantID != "" && s.organizations != nil {
+		member, err := s.organizations.FindMembership(ctx, tenantID, user.ID)
+		if err != nil {
+			return "", err
+		}
+		if member != nil {
+			access, _, err := s.jwt.GenerateTenantAccessToken(user.ID, user.Role, member.OrganizationID, member.Role)
+			return access, err
+		}
+	}
+	access, _, err := s.jwt.GenerateAccessToken(user.ID, user.Role)
+	return access, err
+}
+
+// ChangePassword replaces the password of a user after verifying the current one.
+// The new password must already satisfy the password policy.
+func (s *authService) ChangePassword(ctx context.Context, userID string, current, next valueobjects.Password) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !current.Matches(user.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+	if err := next.CheckEmail(user.Email); err != nil {
+		return err
+	}
+	if next.Matches(user.PasswordHash) {
+		return valueobjects.NewPasswordReusedError(max(1, valueobjects.CurrentPasswordPolicy().HistorySize))
+	}
+	if err := s.ensureNotReused(ctx, user.ID, next); err != nil {
+		return err
+	}
+
+	hash, err := next.Hash()
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.recordPassword(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		return s.emit(ctx, models.EventPasswordChanged, user.ID, map[string]string{"reason": "change"})
+	})
+}
+
+// RequestPasswordReset emails a password reset link. Unknown emails are
+// silently ignored so the endpoint cannot be used to discover accounts.
+func (s *authService) RequestPasswordReset(ctx context.Context, email valueobjects.Email) error {
+	user, err := s.users.FindByEmail(ctx, email.String())
+	if err != nil {
+		return nil
+	}
+
+	token := valueobjects.NewToken()
+	client := models.ClientInfoFromContext(ctx)
+	if err := s.verifications.Create(ctx, &models.VerificationToken{
+		Token:     token.String(),
+		Purpose:   models.PurposePasswordReset,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+	}); err != nil {
+		return err
+	}
+	return s.mailer.SendPasswordResetEmail(ctx, user.Recipient(), s.linkWithToken(s.passwordResetURL, token))
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out of every session. The new password must already satisfy the password policy.
+func (s *authService) ResetPassword(ctx context.Context, token valueobjects.Token, password valueobjects.Password) error {
+	verif, err := s.verifications.Get(ctx, token.String())
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if verif.Purpose != models.PurposePasswordReset {
+		return ErrTokenInvalid
+	}
+	if verif.ExpiresAt.Before(time.Now()) {
+		return ErrTokenExpired
+	}
+	user, err := s.users.FindByID(ctx, verif.UserID)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := password.CheckEmail(user.Email); err != nil {
+		return err
+	}
+	if err := s.ensureNotReused(ctx, user.ID, password); err != nil {
+		return err
+	}
+
+	hash, err := password.Hash()
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.verifications.Delete(ctx, token.String()); err != nil {
+			return err
+		}
+		if err := s.recordPassword(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		return s.emit(ctx, models.EventPasswordChanged, user.ID, map[string]string{"reason": "reset"})
+	})
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllForUser(ctx, user.ID)
+}
+
+// DeactivateUser disables an account and signs it out everywhere. The account
+// is kept and can be reactivated by an administrator.
+func (s *authService) DeactivateUser(ctx context.Context, userID string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	user.IsActive = false
+	user.UpdatedAt = time.Now()
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.emit(ctx, models.EventUserDeactivated, user.ID, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllForUser(ctx, user.ID)
+}
+
+// linkWithToken appends a token to an email link, or returns the bare token without a link
+func (s *authService) linkWithToken(link string, token valueobjects.Token) string {
+	if link == "" {
+		return token.String()
+	}
+	return link + "?token=" + url.QueryEscape(token.String())
+}
+
+// inTransaction runs fn atomically when a transactor is configured
+func (s *authService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fn(ctx)
+	}
+	return s.transactor.WithinTransaction(ctx, fn)
+}
+
+// emit records a domain event in the outbox when one is configured
+func (s *authService) emit(ctx context.Context, eventType, userID string, payload map[string]string) error {
+	if s.outbox == nil {
+		return nil
+	}
+	return s.outbox.Add(ctx, models.DomainEvent{
+		ID:          uuid.New().String(),
+		Type:        eventType,
+		AggregateID: userID,
+		Payload:     payload,
+		OccurredAt:  time.Now(),
+	})
+}
+
+// ensureNotReused rejects a password matching one of the user's remembered passwords
+func (s *authService) ensureNotReused(ctx context.Context, userID string, password valueobjects.Password) error {
+	size := valueobjects.CurrentPasswordPolicy().HistorySize
+	if s.passwordHistory == nil || size == 0 {
+		return nil
+	}
+	entries, err := s.passwordHistory.ListRecent(ctx, userID, size)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if password.Matches(entry.PasswordHash) {
+			return valueobjects.NewPasswordReusedError(size)
+		}
+	}
+	return nil
+}
+
+// recordPassword remembers a password hash when password history is enabled
+func (s *authService) recordPassword(ctx context.Context, userID, hash string) error {
+	if s.passwordHistory == nil || valueobjects.CurrentPasswordPolicy().HistorySize == 0 {
+		return nil
+	}
+	return s.passwordHistory.Add(ctx, &models.PasswordHistoryEntry{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	})
+}
+
+func (s *authService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) GetUsersByIDs(ctx context.Context, userIDs []string) ([]*models.User, error) {
+	if len(userIDs) == 0 {
+		return []*models.User{}, nil
+	}
+	return s.users.FindByIDs(ctx, userIDs)
+}
+
+func (s *authService) RevokeUserSessions(ctx context.Context, userID string) error {
+	return s.tokens.RevokeAllForUser(ctx, userID)
 }
 
 func (s *authService) Logout(ctx context.Context, refreshToken valueobjects.Token) error {
@@ -0,0 +1,408 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/memory"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+const testPassword = "Str0ng!Passw0rd"
+
+// recordingMailer keeps the links it is asked to send
+type recordingMailer struct {
+	mu            sync.Mutex
+	verifications []string
+	resets        []string
+}
+
+func (m *recordingMailer) SendVerificationEmail(_ context.Context, _ models.Recipient, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifications = append(m.verifications, link)
+	return nil
+}
+
+func (m *recordingMailer) SendPasswordResetEmail(_ context.Context, _ models.Recipient, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resets = append(m.resets, link)
+	return nil
+}
+
+func (m *recordingMailer) SendNewDeviceEmail(context.Context, models.Recipient, models.NewDeviceNotice) error {
+	return nil
+}
+
+// lastVerification is the token of the last verification email; without a
+// verification URL the email carries the bare token
+func (m *recordingMailer) lastVerification(t *testing.T) valueobjects.Token {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.verifications) == 0 {
+		t.Fatal("no verification email sent")
+	}
+	return valueobjects.Token{TokenString: m.verifications[len(m.verifications)-1]}
+}
+
+type testAuth struct {
+	service ports.AuthService
+	users   *memory.UserRepository
+	tokens  *memory.TokenRepository
+	mailer  *recordingMailer
+}
+
+func newTestAuth(t *testing.T, opts ...Option) *testAuth {
+	t.Helper()
+	lifetimes := models.TokenLifetimes{AccessExpiry: 15 * time.Minute, RefreshExpiry: time.Hour}
+	jwt, err := token.NewEphemeralTokenManager("test", lifetimes.AccessExpiry, lifetimes.RefreshExpiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &testAuth{
+		users:  memory.NewUserRepository(),
+		tokens: memory.NewTokenRepository(),
+		mailer: &recordingMailer{},
+	}
+	h.service = NewAuthService(h.users, memory.NewVerificationRepository(), h.tokens, jwt, lifetimes, h.mailer, opts...)
+	return h
+}
+
+func (h *testAuth) register(t *testing.T, email string) *models.User {
+	t.Helper()
+	address, err := valueobjects.NewEmail(email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := h.service.Register(context.Background(), address, valueobjects.PasswordFromInput(testPassword), "Ada")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return user
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verifies the user", func(t *testing.T) {
+		h := newTestAuth(t)
+		user := h.register(t, "ada@example.com")
+		if err := h.service.VerifyEmail(ctx, h.mailer.lastVerification(t)); err != nil {
+			t.Fatalf("VerifyEmail: %v", err)
+		}
+		stored, _ := h.users.FindByID(ctx, user.ID)
+		if !stored.IsVerified {
+			t.Error("user is not verified")
+		}
+		if err := h.service.VerifyEmail(ctx, h.mailer.lastVerification(t)); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("reused token: got %v, want ErrTokenInvalid", err)
+		}
+	})
+
+	t.Run("rejects an unknown token", func(t *testing.T) {
+		h := newTestAuth(t)
+		if err := h.service.VerifyEmail(ctx, valueobjects.NewToken()); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("got %v, want ErrTokenInvalid", err)
+		}
+	})
+
+	t.Run("rejects the link of a deleted account", func(t *testing.T) {
+		h := newTestAuth(t)
+		user := h.register(t, "ada@example.com")
+		if err := h.users.SoftDelete(ctx, user.ID, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.service.VerifyEmail(ctx, h.mailer.lastVerification(t)); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("got %v, want ErrTokenInvalid", err)
+		}
+	})
+}
+
+// expiryRecorder remembers when stored refresh tokens expire
+type expiryRecorder struct {
+	*memory.TokenRepository
+	expiresAt time.Time
+}
+
+func (r *expiryRecorder) StoreRefreshToken(ctx context.Context, userID, tenantID string, token valueobjects.Token, expiresAt time.Time) error {
+	r.expiresAt = expiresAt
+	return r.TokenRepository.StoreRefreshToken(ctx, userID, tenantID, token, expiresAt)
+}
+
+func TestLoginUsesConfiguredLifetimes(t *testing.T) {
+	ctx := context.Background()
+	lifetimes := models.TokenLifetimes{AccessExpiry: 10 * time.Minute, RefreshExpiry: 720 * time.Hour}
+	jwt, err := token.NewEphemeralTokenManager("test", lifetimes.AccessExpiry, lifetimes.RefreshExpiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := &expiryRecorder{TokenRepository: memory.NewTokenRepository()}
+	service := NewAuthService(memory.NewUserRepository(), memory.NewVerificationRepository(), tokens, jwt, lifetimes, &recordingMailer{})
+
+	email, _ := valueobjects.NewEmail("ada@example.com")
+	if _, err := service.Register(ctx, email, valueobjects.PasswordFromInput(testPassword), "Ada"); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := service.Login(ctx, email, valueobjects.PasswordFromInput(testPassword))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if pair.ExpiresIn != 600 {
+		t.Errorf("ExpiresIn = %d, want 600", pair.ExpiresIn)
+	}
+	if ttl := time.Until(tokens.expiresAt); ttl < 719*time.Hour || ttl > 720*time.Hour {
+		t.Errorf("refresh token stored for %v, want 720h", ttl)
+	}
+
+	refreshed, err := service.RefreshToken(ctx, valueobjects.Token{TokenString: pair.RefreshToken})
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if refreshed.ExpiresIn != 600 {
+		t.Errorf("refreshed ExpiresIn = %d, want 600", refreshed.ExpiresIn)
+	}
+}
+
+// stalledDevices blocks device checks until released
+type stalledDevices struct {
+	ports.DeviceService
+	release chan struct{}
+	checked chan string
+}
+
+func (d *stalledDevices) CheckLogin(_ context.Context, email string) error {
+	<-d.release
+	d.checked <- email
+	return errors.New("smtp: connection refused")
+}
+
+func TestLoginDoesNotWaitForTheDeviceCheck(t *testing.T) {
+	devices := &stalledDevices{release: make(chan struct{}), checked: make(chan string, 1)}
+	h := newTestAuth(t, WithDeviceTracking(devices))
+	h.register(t, "ada@example.com")
+
+	email, _ := valueobjects.NewEmail("ada@example.com")
+	if _, err := h.service.Login(context.Background(), email, valueobjects.PasswordFromInput(testPassword)); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	close(devices.release)
+	select {
+	case got := <-devices.checked:
+		if got != "ada@example.com" {
+			t.Errorf("checked %q, want ada@example.com", got)
+		}
+	case <-time.After(time.Second):
+		t.Error("device was never checked")
+	}
+}
+
+// useHashing switches the process-wide hashing policy for the test
+func useHashing(t *testing.T, policy hashing.Policy) {
+	t.Helper()
+	hasher, err := hashing.New(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := hashing.Default()
+	t.Cleanup(func() { hashing.SetDefault(previous) })
+	hashing.SetDefault(hasher)
+}
+
+func TestLoginUpgradesOutdatedHashes(t *testing.T) {
+	ctx := context.Background()
+
+	// registered while bcrypt was the policy
+	useHashing(t, hashing.Policy{Algorithm: hashing.AlgorithmBcrypt, Bcrypt: hashing.BcryptParams{Cost: 4}})
+	h := newTestAuth(t)
+	user := h.register(t, "ada@example.com")
+	stored, _ := h.users.FindByID(ctx, user.ID)
+	if !strings.HasPrefix(stored.PasswordHash, "$2a$") {
+		t.Fatalf("registered with hash %q, want bcrypt", stored.PasswordHash)
+	}
+
+	useHashing(t, hashing.Policy{Algorithm: hashing.AlgorithmArgon2id, Argon2: hashing.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}})
+	email, _ := valueobjects.NewEmail("ada@example.com")
+	if _, err := h.service.Login(ctx, email, valueobjects.PasswordFromInput("wr0ng!Passw0rd")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if stored, _ := h.users.FindByID(ctx, user.ID); !strings.HasPrefix(stored.PasswordHash, "$2a$") {
+		t.Errorf("hash upgraded by a failed login: %q", stored.PasswordHash)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := h.service.Login(ctx, email, valueobjects.PasswordFromInput(testPassword)); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+		if stored, _ := h.users.FindByID(ctx, user.ID); !strings.HasPrefix(stored.PasswordHash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+			t.Errorf("after login %d the hash is %q, want argon2id", i+1, stored.PasswordHash)
+		}
+	}
+}
+
+// passwordHistory keeps password hashes in memory
+type passwordHistory struct {
+	mu      sync.Mutex
+	entries []*models.PasswordHistoryEntry
+}
+
+func (h *passwordHistory) Add(_ context.Context, entry *models.PasswordHistoryEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append([]*models.PasswordHistoryEntry{entry}, h.entries...)
+	return nil
+}
+
+func (h *passwordHistory) ListRecent(_ context.Context, userID string, limit int) ([]*models.PasswordHistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var recent []*models.PasswordHistoryEntry
+	for _, entry := range h.entries {
+		if entry.UserID == userID && len(recent) < limit {
+			recent = append(recent, entry)
+		}
+	}
+	return recent, nil
+}
+
+func TestChangePasswordEnforcesThePolicy(t *testing.T) {
+	ctx := context.Background()
+	useHashing(t, hashing.Policy{Algorithm: hashing.AlgorithmBcrypt, Bcrypt: hashing.BcryptParams{Cost: 4}})
+	policy := valueobjects.DefaultPasswordPolicy()
+	policy.HistorySize = 2
+	policy.ForbidEmailLocalPart = true
+	previous := valueobjects.CurrentPasswordPolicy()
+	t.Cleanup(func() { valueobjects.SetPasswordPolicy(previous) })
+	if err := valueobjects.SetPasswordPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newTestAuth(t, WithPasswordHistory(&passwordHistory{}))
+	user := h.register(t, "ada@example.com")
+	current := testPassword
+	change := func(next string) error {
+		err := h.service.ChangePassword(ctx, user.ID, valueobjects.PasswordFromInput(current), valueobjects.PasswordFromInput(next))
+		if err == nil {
+			current = next
+		}
+		return err
+	}
+
+	if err := h.service.ChangePassword(ctx, user.ID, valueobjects.PasswordFromInput("Wr0ng!Passw0rd"), valueobjects.PasswordFromInput("Fresh!Passw0rd1")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong current password: got %v, want ErrInvalidCredentials", err)
+	}
+
+	steps := []struct {
+		next string
+		rule string // the violated rule, empty when the change is accepted
+	}{
+		{"My!Ada-Passw0rd", valueobjects.RuleEmailLocalPart},
+		{testPassword, valueobjects.RuleHistory},
+		{"Fresh!Passw0rd1", ""},
+		{testPassword, valueobjects.RuleHistory},
+		{"Fresh!Passw0rd2", ""},
+		// two changes later the first password has left the history
+		{testPassword, ""},
+		{"Fresh!Passw0rd2", valueobjects.RuleHistory},
+	}
+	for i, step := range steps {
+		err := change(step.next)
+		var policyErr *valueobjects.PasswordPolicyError
+		switch {
+		case step.rule == "" && err != nil:
+			t.Errorf("step %d: changing to %s: %v", i+1, step.next, err)
+		case step.rule != "" && (!errors.As(err, &policyErr) || !policyErr.HasRule(step.rule)):
+			t.Errorf("step %d: changing to %s: got %v, want a %s violation", i+1, step.next, err, step.rule)
+		}
+	}
+
+	email, _ := valueobjects.NewEmail("ada@example.com")
+	if _, err := h.service.Login(ctx, email, valueobjects.PasswordFromInput(current)); err != nil {
+		t.Errorf("login with the last accepted password: %v", err)
+	}
+}
+
+// failingOutbox refuses to record events
+type failingOutbox struct {
+	ports.OutboxRepository
+}
+
+func (failingOutbox) Add(context.Context, models.DomainEvent) error {
+	return errors.New("outbox is full")
+}
+
+func TestEventsAreRecordedWithTheChange(t *testing.T) {
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	lifetimes := models.TokenLifetimes{AccessExpiry: 15 * time.Minute, RefreshExpiry: time.Hour}
+	jwt, err := token.NewEphemeralTokenManager("test", lifetimes.AccessExpiry, lifetimes.RefreshExpiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newService := func(outbox ports.OutboxRepository, mailer ports.Mailer) ports.AuthService {
+		return NewAuthService(sqlite.NewUserRepository(db), sqlite.NewVerificationRepository(db), sqlite.NewTokenRepository(db), jwt, lifetimes, mailer,
+			WithTransactor(sqlite.NewTransactor(db, log)), WithOutbox(outbox))
+	}
+	ctx := context.Background()
+
+	// a change whose event cannot be recorded does not happen
+	grace, _ := valueobjects.NewEmail("grace@example.com")
+	if _, err := newService(failingOutbox{}, &recordingMailer{}).Register(ctx, grace, valueobjects.PasswordFromInput(testPassword), "Grace"); err == nil {
+		t.Fatal("Register succeeded without recording its event")
+	}
+	if _, err := sqlite.NewUserRepository(db).FindByEmail(ctx, grace.String()); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("user created although the event was not recorded: %v", err)
+	}
+
+	mailer := &recordingMailer{}
+	service := newService(sqlite.NewOutboxRepository(db), mailer)
+	ada, _ := valueobjects.NewEmail("ada@example.com")
+	user, err := service.Register(ctx, ada, valueobjects.PasswordFromInput(testPassword), "Ada")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := service.VerifyEmail(ctx, mailer.lastVerification(t)); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+
+	var events []struct {
+		Type        string `db:"event_type"`
+		AggregateID string `db:"aggregate_id"`
+		Payload     string `db:"payload"`
+	}
+	if err := db.GetDB().SelectContext(ctx, &events, `SELECT event_type, aggregate_id, payload FROM outbox_events ORDER BY occurred_at`); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{models.EventUserRegistered, models.EventEmailVerified}
+	if len(events) != len(want) {
+		t.Fatalf("recorded %d events, want %v", len(events), want)
+	}
+	for i, event := range events {
+		if event.Type != want[i] || event.AggregateID != user.ID || !strings.Contains(event.Payload, "ada@example.com") {
+			t.Errorf("event %d = %+v, want %s of %s", i, event, want[i], user.ID)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package device_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+const reportTokenTTL = 7 * 24 * time.Hour
+
+var (
+	ErrTokenInvalid = errors.New("invalid or expired link")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+type deviceService struct {
+	devices       ports.DeviceRepository
+	users         ports.UserRepository
+	tokens        ports.TokenRepository
+	verifications ports.VerificationRepository
+	mailer        ports.Mailer
+	geo           ports.GeoLocator
+	reportURL     string
+}
+
+// NewDeviceService creates the device service. reportURL is the "this wasn't
+// me" endpoint; the report token is appended as the token query parameter.
+func NewDeviceService(
+	devices ports.DeviceRepository,
+	users ports.UserRepository,
+	tokens ports.TokenRepository,
+	verifications ports.VerificationRepository,
+	mailer ports.Mailer,
+	geo ports.GeoLocator,
+	reportURL string,
+) ports.DeviceService {
+	return &deviceService{
+		devices:       devices,
+		users:         users,
+		tokens:        tokens,
+		verifications: verifications,
+		mailer:        mailer,
+		geo:           geo,
+		reportURL:     reportURL,
+	}
+}
+
+func (s *deviceService) CheckLogin(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	client := models.ClientInfoFromContext(ctx)
+	now := time.Now()
+	seen := &models.Device{
+		UserID:    user.ID,
+		Name:      DescribeUserAgent(client.UserAgent),
+		UserAgent: client.UserAgent,
+		IPPrefix:  IPPrefix(client.IPAddress),
+	}
+	if client.DeviceID != "" {
+		seen.CookieHash = hashDeviceID(client.DeviceID)
+	}
+
+	known, err := s.devices.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if match := findDevice(known, seen); match != nil {
+		return s.devices.Touch(ctx, match.ID, seen.CookieHash, now)
+	}
+
+	if s.geo != nil {
+		if location, err := s.geo.Locate(ctx, client.IPAddress); err == nil {
+			seen.Location = location
+		}
+	}
+	seen.ID = uuid.New().String()
+	seen.FirstSeenAt = now
+	seen.LastSeenAt = now
+	if err := s.devices.Create(ctx, seen); err != nil {
+		return err
+	}
+
+	// the device used to sign up is not news to the user
+	if len(known) == 0 {
+		return nil
+	}
+	return s.notify(ctx, user, seen, client.IPAddress)
+}
+
+func (s *deviceService) notify(ctx context.Context, user *models.User, device *models.Device, ip string) error {
+	token := valueobjects.NewToken()
+	if err := s.verifications.Create(ctx, &models.VerificationToken{
+		Token:     token.String(),
+		Purpose:   models.PurposeDeviceReport,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(reportTokenTTL),
+		IPAddress: ip,
+		UserAgent: device.UserAgent,
+	}); err != nil {
+		return err
+	}
+
+	return s.mailer.SendNewDeviceEmail(ctx, user.Recipient(), models.NewDeviceNotice{
+		Device:    device.Name,
+		IPAddress: ip,
+		Location:  device.Location,
+		LoginAt:   device.FirstSeenAt,
+		ReportURL: s.reportURL + "?token=" + url.QueryEscape(token.String()),
+	})
+}
+
+// ReportUnrecognized revokes every session of the user, not just the reported
+// one, since whoever logged in may already have refreshed into new sessions.
+func (s *deviceService) ReportUnrecognized(ctx context.Context, token valueobjects.Token) (*models.User, error) {
+	verif, err := s.verifications.Get(ctx, token.String())
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if verif.Purpose != models.PurposeDeviceReport || verif.ExpiresAt.Before(time.Now()) {
+		return nil, ErrTokenInvalid
+	}
+	user, err := s.users.FindByID(ctx, verif.UserID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.devices.DeleteAllForUser(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	_ = s.verifications.Delete(ctx, token.String())
+	return user, nil
+}
+
+// findDevice matches by device cookie first. Without a cookie match it falls
+// back to the browser, platform and network the user logged in from before.
+func findDevice(known []*models.Device, seen *models.Device) *models.Device {
+	if seen.CookieHash != "" {
+		for _, d := range known {
+			if d.CookieHash == seen.CookieHash {
+				return d
+			}
+		}
+	}
+	for _, d := range known {
+		if d.Name == seen.Name && d.IPPrefix == seen.IPPrefix && d.IPPrefix != "" {
+			return d
+		}
+	}
+	return nil
+}
+
+func hashDeviceID(deviceID string) string {
+	sum := sha256.Sum256([]byte(deviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// IPPrefix returns the /24 network of an IPv4 address or the /48 of an IPv6
+// address, which stays stable while a user's address changes within their ISP
+func IPPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,52 @@
+package device_service
+
+import "strings"
+
+// uaPattern maps a user agent substring to a display name. Order matters:
+// many browsers include the tokens of the browsers they are derived from.
+type uaPattern struct {
+	token string
+	name  string
+}
+
+var browsers = []uaPattern{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+}
+
+var platforms = []uaPattern{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DescribeUserAgent reduces a user agent to "Browser on Platform". Version
+// numbers are dropped on purpose so browser updates do not look like new devices.
+func DescribeUserAgent(ua string) string {
+	browser := match(ua, browsers, "Unknown browser")
+	platform := match(ua, platforms, "")
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
+
+func match(ua string, patterns []uaPattern, fallback string) string {
+	for _, p := range patterns {
+		if strings.Contains(ua, p.token) {
+			return p.name
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,252 @@
+package mailqueue_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = 6 * time.Hour
+	DefaultBatchSize   = 50
+	DefaultLease       = 10 * time.Minute
+	DefaultRetention   = 7 * 24 * time.Hour
+
+	maxListLimit = 500
+)
+
+var (
+	ErrMailNotFound  = errors.New("queued mail not found")
+	ErrMailNotDead   = errors.New("only dead mail can be re-driven")
+	ErrUnknownStatus = errors.New("unknown mail status")
+	ErrUnknownKind   = errors.New("unknown mail kind")
+)
+
+// Config tunes retries. Zero values select the defaults.
+type Config struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseBackoff time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`
+	BatchSize   int           `mapstructure:"batch_size"`
+	// Lease is how long a claimed email is hidden from other workers while it
+	// is sent; one whose worker died is retried once the lease runs out
+	Lease time.Duration `mapstructure:"lease"`
+	// Retention is how long sent and dead emails are kept, with the links
+	// they carry, before they are deleted
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+type mailQueue struct {
+	repo       ports.MailQueueRepository
+	transactor ports.Transactor
+	delivery   ports.Mailer
+	config     Config
+}
+
+// NewMailQueue creates a durable queue in front of delivery, the mailer that
+// actually sends. Emails are enqueued in the caller's transaction, if any.
+func NewMailQueue(repo ports.MailQueueRepository, transactor ports.Transactor, delivery ports.Mailer, config Config) ports.MailQueue {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultBaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.Lease <= 0 {
+		config.Lease = DefaultLease
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultRetention
+	}
+	return &mailQueue{
+		repo:       repo,
+		transactor: transactor,
+		delivery:   delivery,
+		config:     config,
+	}
+}
+
+// linkPayload is the payload of emails built around a single link
+type linkPayload struct {
+	URL string `json:"url"`
+}
+
+func (q *mailQueue) SendVerificationEmail(ctx context.Context, to models.Recipient, verificationURL string) error {
+	return q.enqueue(ctx, models.MailVerification, to, linkPayload{URL: verificationURL})
+}
+
+func (q *mailQueue) SendPasswordResetEmail(ctx context.Context, to models.Recipient, resetURL string) error {
+	return q.enqueue(ctx, models.MailPasswordReset, to, linkPayload{URL: resetURL})
+}
+
+func (q *mailQueue) SendNewDeviceEmail(ctx context.Context, to models.Recipient, notice models.NewDeviceNotice) error {
+	return q.enqueue(ctx, models.MailNewDevice, to, notice)
+}
+
+func (q *mailQueue) enqueue(ctx context.Context, kind string, to models.Recipient, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return q.repo.Enqueue(ctx, &models.QueuedMail{
+		ID:            uuid.New().String(),
+		Kind:          kind,
+		Recipient:     to,
+		Payload:       raw,
+		Status:        models.MailPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+// DeliverDue sends one batch of due emails. Failed emails are retried with
+// exponential backoff and dead-lettered once they run out of attempts. No
+// transaction is held open while sending: the batch is claimed in one short
+// transaction and the outcome of each email is recorded on its own.
+func (q *mailQueue) DeliverDue(ctx context.Context) (int, error) {
+	mails, err := q.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, mail := range mails {
+		q.attempt(ctx, mail)
+		if err := q.repo.Update(ctx, mail); err != nil {
+			// the lease runs out and the email is sent again
+			errs = append(errs, fmt.Errorf("failed to record delivery of mail %s: %w", mail.ID, err))
+		}
+	}
+	return len(mails), errors.Join(errs...)
+}
+
+// claim leases a batch of due emails to this worker. The attempt is counted
+// up front so that an email whose worker keeps dying mid-send still runs out
+// of attempts.
+func (q *mailQueue) claim(ctx context.Context) ([]*models.QueuedMail, error) {
+	var mails []*models.QueuedMail
+	err := q.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := q.repo.ClaimDue(ctx, q.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		leasedUntil := time.Now().Add(q.config.Lease)
+		for _, mail := range due {
+			mail.Attempts++
+			mail.NextAttemptAt = leasedUntil
+			if err := q.repo.Update(ctx, mail); err != nil {
+				return err
+			}
+		}
+		mails = due
+		return nil
+	})
+	return mails, err
+}
+
+// attempt delivers a claimed mail once and records the outcome on it
+func (q *mailQueue) attempt(ctx context.Context, mail *models.QueuedMail) {
+	err := q.deliver(ctx, mail)
+	if err == nil {
+		now := time.Now()
+		mail.Status = models.MailSent
+		mail.SentAt = &now
+		mail.LastError = ""
+		return
+	}
+
+	mail.LastError = err.Error()
+	if mail.Attempts >= q.config.MaxAttempts || errors.Is(err, ErrUnknownKind) {
+		mail.Status = models.MailDead
+		return
+	}
+	mail.NextAttemptAt = time.Now().Add(q.backoff(mail.Attempts))
+}
+
+func (q *mailQueue) deliver(ctx context.Context, mail *models.QueuedMail) error {
+	switch mail.Kind {
+	case models.MailVerification, models.MailPasswordReset:
+		var payload linkPayload
+		if err := json.Unmarshal(mail.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", mail.Kind, err)
+		}
+		if mail.Kind == models.MailVerification {
+			return q.delivery.SendVerificationEmail(ctx, mail.Recipient, payload.URL)
+		}
+		return q.delivery.SendPasswordResetEmail(ctx, mail.Recipient, payload.URL)
+	case models.MailNewDevice:
+		var notice models.NewDeviceNotice
+		if err := json.Unmarshal(mail.Payload, &notice); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", mail.Kind, err)
+		}
+		return q.delivery.SendNewDeviceEmail(ctx, mail.Recipient, notice)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownKind, mail.Kind)
+	}
+}
+
+// backoff doubles the delay after every failed attempt, up to MaxBackoff
+func (q *mailQueue) backoff(attempts int) time.Duration {
+	delay := q.config.BaseBackoff
+	for i := 1; i < attempts && delay < q.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.config.MaxBackoff)
+}
+
+func (q *mailQueue) List(ctx context.Context, status string, limit int) ([]*models.QueuedMail, error) {
+	switch status {
+	case models.MailPending, models.MailSent, models.MailDead:
+	default:
+		return nil, ErrUnknownStatus
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return q.repo.ListByStatus(ctx, status, limit)
+}
+
+func (q *mailQueue) Get(ctx context.Context, id string) (*models.QueuedMail, error) {
+	mail, err := q.repo.FindByID(ctx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrMailNotFound
+	}
+	return mail, err
+}
+
+func (q *mailQueue) Redrive(ctx context.Context, id string) (*models.QueuedMail, error) {
+	mail, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// pending emails are retried anyway, and a sent one would reach the user twice
+	if mail.Status != models.MailDead {
+		return nil, ErrMailNotDead
+	}
+	mail.Status = models.MailPending
+	mail.Attempts = 0
+	mail.NextAttemptAt = time.Now()
+	if err := q.repo.Update(ctx, mail); err != nil {
+		return nil, err
+	}
+	return mail, nil
+}
+
+func (q *mailQueue) PurgeFinished(ctx context.Context) (int, error) {
+	return q.repo.DeleteFinishedBefore(ctx, time.Now().Add(-q.config.Retention))
+}
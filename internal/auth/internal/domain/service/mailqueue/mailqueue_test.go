@@ -0,0 +1,140 @@
+package mailqueue_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+const testLink = "https://myfolio.dev/verify?token=secret"
+
+type testQueue struct {
+	queue ports.MailQueue
+	repo  *sqlite.MailQueueRepository
+	db    *database.Client
+}
+
+func newTestQueue(t *testing.T, config Config) *testQueue {
+	t.Helper()
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	repo := sqlite.NewMailQueueRepository(db)
+	return &testQueue{
+		queue: NewMailQueue(repo, sqlite.NewTransactor(db, log), nil, config),
+		repo:  repo,
+		db:    db,
+	}
+}
+
+// add queues a verification email in the given state, created age ago
+func (h *testQueue) add(t *testing.T, status string, age time.Duration) *models.QueuedMail {
+	t.Helper()
+	ctx := context.Background()
+	if err := h.queue.SendVerificationEmail(ctx, models.Recipient{Email: "ada@example.com"}, testLink); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	if err := h.db.GetDB().GetContext(ctx, &id, `SELECT id FROM mail_queue ORDER BY rowid DESC LIMIT 1`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.db.GetDB().ExecContext(ctx, `UPDATE mail_queue SET status = ?, created_at = ? WHERE id = ?`,
+		status, time.Now().Add(-age).UTC(), id); err != nil {
+		t.Fatal(err)
+	}
+	mail, err := h.queue.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mail
+}
+
+func TestRedriveOnlyDeadMail(t *testing.T) {
+	h := newTestQueue(t, Config{})
+	ctx := context.Background()
+
+	for _, status := range []string{models.MailPending, models.MailSent} {
+		mail := h.add(t, status, 0)
+		if _, err := h.queue.Redrive(ctx, mail.ID); !errors.Is(err, ErrMailNotDead) {
+			t.Errorf("re-driving %s mail: got %v, want ErrMailNotDead", status, err)
+		}
+	}
+
+	dead := h.add(t, models.MailDead, 0)
+	redriven, err := h.queue.Redrive(ctx, dead.ID)
+	if err != nil {
+		t.Fatalf("re-driving dead mail: %v", err)
+	}
+	if redriven.Status != models.MailPending || redriven.Attempts != 0 {
+		t.Errorf("re-driven mail is %s after %d attempts, want pending after 0", redriven.Status, redriven.Attempts)
+	}
+}
+
+func TestPurgeFinishedKeepsPendingAndRecentMail(t *testing.T) {
+	h := newTestQueue(t, Config{Retention: 24 * time.Hour})
+	ctx := context.Background()
+
+	old := 48 * time.Hour
+	kept := []*models.QueuedMail{
+		h.add(t, models.MailPending, old),
+		h.add(t, models.MailSent, time.Hour),
+		h.add(t, models.MailDead, time.Hour),
+	}
+	purged := []*models.QueuedMail{
+		h.add(t, models.MailSent, old),
+		h.add(t, models.MailDead, old),
+	}
+
+	deleted, err := h.queue.PurgeFinished(ctx)
+	if err != nil {
+		t.Fatalf("PurgeFinished: %v", err)
+	}
+	if deleted != len(purged) {
+		t.Errorf("deleted %d emails, want %d", deleted, len(purged))
+	}
+	for _, mail := range kept {
+		if _, err := h.queue.Get(ctx, mail.ID); err != nil {
+			t.Errorf("%s mail created %s ago: %v", mail.Status, time.Since(mail.CreatedAt).Round(time.Hour), err)
+		}
+	}
+	for _, mail := range purged {
+		if _, err := h.queue.Get(ctx, mail.ID); !errors.Is(err, ErrMailNotFound) {
+			t.Errorf("%s mail past its retention: got %v, want ErrMailNotFound", mail.Status, err)
+		}
+	}
+}
+
+func TestQueuedMailJSONLeavesOutTheLink(t *testing.T) {
+	h := newTestQueue(t, Config{})
+	mail := h.add(t, models.MailDead, 0)
+	if !strings.Contains(string(mail.Payload), testLink) {
+		t.Fatalf("payload %s does not carry the link", mail.Payload)
+	}
+
+	body, err := json.Marshal(mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "token=secret") {
+		t.Errorf("JSON carries the link: %s", body)
+	}
+}
@@ -0,0 +1,206 @@
+package organization_service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+const maxNameLength = 100
+
+var (
+	ErrInvalidName     = errors.New("organization name is required and must be at most 100 characters")
+	ErrInvalidRole     = errors.New("invalid organization role")
+	ErrNotMember       = errors.New("you are not a member of this organization")
+	ErrForbidden       = errors.New("your organization role does not allow this")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrAlreadyMember   = errors.New("user is already a member of this organization")
+	ErrLastOwner       = models.ErrLastOwner
+	ErrNoOrganization  = errors.New("no organization selected, switch to an organization first")
+	nonSlugCharacters  = regexp.MustCompile(`[^a-z0-9]+`)
+	privilegedOrgRoles = []string{models.OrgRoleOwner, models.OrgRoleAdmin}
+)
+
+type organizationService struct {
+	orgs   ports.OrganizationRepository
+	users  ports.UserRepository
+	tokens ports.TokenRepository
+	jwt    ports.JWTService
+	// lifetimes of the tokens issued when switching organizations
+	lifetimes models.TokenLifetimes
+}
+
+func NewOrganizationService(
+	orgs ports.OrganizationRepository,
+	users ports.UserRepository,
+	tokens ports.TokenRepository,
+	jwt ports.JWTService,
+	lifetimes models.TokenLifetimes,
+) ports.OrganizationService {
+	return &organizationService{orgs, users, tokens, jwt, lifetimes}
+}
+
+// Create creates an organization owned by userID
+func (s *organizationService) Create(ctx context.Context, userID, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, ErrInvalidName
+	}
+
+	now := time.Now()
+	org := &models.Organization{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Slug:      slugify(name) + "-" + uuid.New().String()[:8],
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	owner := &models.Membership{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           models.OrgRoleOwner,
+		CreatedAt:      now,
+	}
+	if err := s.orgs.Create(ctx, org, owner); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) ListForUser(ctx context.Context, userID string) ([]*models.OrganizationMembership, error) {
+	return s.orgs.ListForUser(ctx, userID)
+}
+
+// AddMember adds the user with the given email to the current organization
+func (s *organizationService) AddMember(ctx context.Context, actorID, email, role string) (*models.Membership, error) {
+	if !models.IsOrgRole(role) {
+		return nil, ErrInvalidRole
+	}
+	actor, err := s.currentMembership(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole(actor, privilegedOrgRoles...) || (role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner) {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	member := &models.Membership{
+		OrganizationID: actor.OrganizationID,
+		UserID:         user.ID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.orgs.AddMember(ctx, member); err != nil {
+		if errors.Is(err, models.ErrConflict) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// ListMembers lists the members of the current organization
+func (s *organizationService) ListMembers(ctx context.Context) ([]*models.MemberDetails, error) {
+	if _, ok := models.TenantFromContext(ctx); !ok {
+		return nil, ErrNoOrganization
+	}
+	return s.orgs.ListMembers(ctx)
+}
+
+// RemoveMember removes a user from the current organization. Admins may only
+// remove non-owners, and the last owner can never be removed.
+func (s *organizationService) RemoveMember(ctx context.Context, actorID, userID string) error {
+	actor, err := s.currentMembership(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	if !hasRole(actor, privilegedOrgRoles...) {
+		return ErrForbidden
+	}
+	target, err := s.orgs.FindMembership(ctx, actor.OrganizationID, userID)
+	if err != nil || target == nil {
+		return ErrNotMember
+	}
+	if target.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner {
+		return ErrForbidden
+	}
+	// the repository refuses to remove the last owner, atomically with the removal
+	err = s.orgs.RemoveMember(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return ErrNotMember
+	}
+	return err
+}
+
+// Switch re-issues the user's tokens scoped to organizationID
+func (s *organizationService) Switch(ctx context.Context, userID, organizationID string) (*models.TokenPair, error) {
+	member, err := s.orgs.FindMembership(ctx, organizationID, userID)
+	if err != nil || member == nil {
+		return nil, ErrNotMember
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	access, _, err := s.jwt.GenerateTenantAccessToken(user.ID, user.Role, member.OrganizationID, member.Role)
+	if err != nil {
+		return nil, err
+	}
+	refresh, _, err := s.jwt.GenerateRefreshToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.StoreRefreshToken(ctx, user.ID, member.OrganizationID, valueobjects.Token{TokenString: refresh}, time.Now().Add(s.lifetimes.RefreshExpiry)); err != nil {
+		return nil, err
+	}
+	return s.lifetimes.Pair(access, refresh), nil
+}
+
+// currentMembership resolves the actor's membership in the organization carried by the context
+func (s *organizationService) currentMembership(ctx context.Context, actorID string) (*models.Membership, error) {
+	orgID, ok := models.TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoOrganization
+	}
+	member, err := s.orgs.FindMembership(ctx, orgID, actorID)
+	if err != nil || member == nil {
+		return nil, ErrNotMember
+	}
+	return member, nil
+}
+
+func hasRole(member *models.Membership, roles ...string) bool {
+	for _, role := range roles {
+		if member.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func slugify(name string) string {
+	slug := strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return "org"
+	}
+	return slug
+}
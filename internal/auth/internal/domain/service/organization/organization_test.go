@@ -0,0 +1,205 @@
+package organization_service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	auth_service "github.com/istiak-004/myFolio-microservices/auth/internal/domain/service/auth"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/memory"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/token"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+var testLifetimes = models.TokenLifetimes{AccessExpiry: 15 * time.Minute, RefreshExpiry: time.Hour}
+
+type testOrgs struct {
+	service ports.OrganizationService
+	orgs    *sqlite.OrganizationRepository
+	users   *sqlite.UserRepository
+	tokens  *memory.TokenRepository
+	jwt     *token.JWTTokenManager
+	ids     map[string]string
+}
+
+// newTestOrgs creates ada, grace, linus and margaret. ada owns acme, where
+// grace is an admin and linus a viewer; margaret owns globex.
+func newTestOrgs(t *testing.T) (*testOrgs, *models.Organization, *models.Organization) {
+	t.Helper()
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := token.NewEphemeralTokenManager("test", testLifetimes.AccessExpiry, testLifetimes.RefreshExpiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &testOrgs{
+		orgs:   sqlite.NewOrganizationRepository(db),
+		users:  sqlite.NewUserRepository(db),
+		tokens: memory.NewTokenRepository(),
+		jwt:    jwt,
+		ids:    map[string]string{},
+	}
+	h.service = NewOrganizationService(h.orgs, h.users, h.tokens, jwt, testLifetimes)
+
+	ctx := context.Background()
+	for _, name := range []string{"ada", "grace", "linus", "margaret"} {
+		user := &models.User{Email: name + "@example.com", PasswordHash: "hash", Role: "creator", IsActive: true}
+		if err := h.users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		h.ids[name] = user.ID
+	}
+	acme, err := h.service.Create(ctx, h.ids["ada"], "Acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globex, err := h.service.Create(ctx, h.ids["margaret"], "Globex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inAcme := models.ContextWithTenant(ctx, acme.ID)
+	for name, role := range map[string]string{"grace": models.OrgRoleAdmin, "linus": models.OrgRoleViewer} {
+		if _, err := h.service.AddMember(inAcme, h.ids["ada"], name+"@example.com", role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h, acme, globex
+}
+
+func TestMembersAreScopedToTheCurrentOrganization(t *testing.T) {
+	h, acme, globex := newTestOrgs(t)
+	inAcme := models.ContextWithTenant(context.Background(), acme.ID)
+	inGlobex := models.ContextWithTenant(context.Background(), globex.ID)
+
+	members, err := h.service.ListMembers(inAcme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 3 {
+		t.Errorf("acme lists %d members, want 3", len(members))
+	}
+	for _, member := range members {
+		if member.OrganizationID != acme.ID || member.UserID == h.ids["margaret"] {
+			t.Errorf("acme lists %s of organization %s", member.UserID, member.OrganizationID)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"listing without an organization", func() error {
+			_, err := h.service.ListMembers(context.Background())
+			return err
+		}, ErrNoOrganization},
+		{"adding to an organization you are not in", func() error {
+			_, err := h.service.AddMember(inGlobex, h.ids["ada"], "linus@example.com", models.OrgRoleViewer)
+			return err
+		}, ErrNotMember},
+		{"removing a member of another organization", func() error {
+			return h.service.RemoveMember(inAcme, h.ids["ada"], h.ids["margaret"])
+		}, ErrNotMember},
+		{"removing from an organization you are not in", func() error {
+			return h.service.RemoveMember(inGlobex, h.ids["ada"], h.ids["margaret"])
+		}, ErrNotMember},
+		{"viewer adding a member", func() error {
+			_, err := h.service.AddMember(inAcme, h.ids["linus"], "margaret@example.com", models.OrgRoleViewer)
+			return err
+		}, ErrForbidden},
+		{"admin adding an owner", func() error {
+			_, err := h.service.AddMember(inAcme, h.ids["grace"], "margaret@example.com", models.OrgRoleOwner)
+			return err
+		}, ErrForbidden},
+		{"admin removing the owner", func() error {
+			return h.service.RemoveMember(inAcme, h.ids["grace"], h.ids["ada"])
+		}, ErrForbidden},
+		{"owner removing themselves as the last owner", func() error {
+			return h.service.RemoveMember(inAcme, h.ids["ada"], h.ids["ada"])
+		}, ErrLastOwner},
+		{"adding a member twice", func() error {
+			_, err := h.service.AddMember(inAcme, h.ids["ada"], "linus@example.com", models.OrgRoleEditor)
+			return err
+		}, ErrAlreadyMember},
+		{"adding an unknown user", func() error {
+			_, err := h.service.AddMember(inAcme, h.ids["ada"], "nobody@example.com", models.OrgRoleViewer)
+			return err
+		}, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if members, _ := h.service.ListMembers(inGlobex); len(members) != 1 || members[0].UserID != h.ids["margaret"] {
+		t.Errorf("globex members changed: %+v", members)
+	}
+	if err := h.service.RemoveMember(inAcme, h.ids["grace"], h.ids["linus"]); err != nil {
+		t.Errorf("admin removing a viewer: %v", err)
+	}
+}
+
+func TestSwitchScopesTokensToTheOrganization(t *testing.T) {
+	h, acme, globex := newTestOrgs(t)
+	ctx := context.Background()
+
+	if _, err := h.service.Switch(ctx, h.ids["ada"], globex.ID); !errors.Is(err, ErrNotMember) {
+		t.Errorf("switching to a foreign organization: got %v, want ErrNotMember", err)
+	}
+
+	pair, err := h.service.Switch(ctx, h.ids["grace"], acme.ID)
+	if err != nil {
+		t.Fatalf("Switch: %v", err)
+	}
+	claims, err := h.jwt.VerifyAccessToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != h.ids["grace"] || claims.TenantID != acme.ID || claims.TenantRole != models.OrgRoleAdmin {
+		t.Errorf("access token scoped to %s as %s, want acme as admin", claims.TenantID, claims.TenantRole)
+	}
+	if pair.ExpiresIn != int(testLifetimes.AccessExpiry.Seconds()) {
+		t.Errorf("ExpiresIn = %d, want %v", pair.ExpiresIn, testLifetimes.AccessExpiry)
+	}
+
+	// refreshing keeps the scope, until the user leaves the organization
+	auth := auth_service.NewAuthService(h.users, memory.NewVerificationRepository(), h.tokens, h.jwt, testLifetimes, nil, auth_service.WithOrganizations(h.orgs))
+	refreshed, err := auth.RefreshToken(ctx, valueobjects.Token{TokenString: pair.RefreshToken})
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if claims, _ := h.jwt.VerifyAccessToken(ctx, refreshed.AccessToken); claims.TenantID != acme.ID || claims.TenantRole != models.OrgRoleAdmin {
+		t.Errorf("refreshed token scoped to %q as %q, want acme as admin", claims.TenantID, claims.TenantRole)
+	}
+
+	if err := h.service.RemoveMember(models.ContextWithTenant(ctx, acme.ID), h.ids["ada"], h.ids["grace"]); err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err = auth.RefreshToken(ctx, valueobjects.Token{TokenString: refreshed.RefreshToken})
+	if err != nil {
+		t.Fatalf("RefreshToken after leaving: %v", err)
+	}
+	if claims, _ := h.jwt.VerifyAccessToken(ctx, refreshed.AccessToken); claims.TenantID != "" || claims.TenantRole != "" {
+		t.Errorf("refreshed token still scoped to %q as %q after leaving", claims.TenantID, claims.TenantRole)
+	}
+}
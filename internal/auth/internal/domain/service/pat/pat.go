@@ -0,0 +1,168 @@
+package pat_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+// TokenPrefix marks a bearer credential as a personal access token rather than a JWT
+const TokenPrefix = "mfp_"
+
+const (
+	maxNameLength = 100
+	maxTTL        = 365 * 24 * time.Hour
+	displayLength = len(TokenPrefix) + 8
+)
+
+var (
+	ErrInvalidName     = errors.New("token name is required and must be at most 100 characters")
+	ErrInvalidScope    = errors.New("invalid token scope")
+	ErrNoScopes        = errors.New("at least one scope is required")
+	ErrInvalidTTL      = errors.New("token expiry must be between 1 day and 365 days")
+	ErrTokenNotFound   = errors.New("personal access token not found")
+	ErrTokenInvalid    = errors.New("invalid personal access token")
+	ErrTokenExpired    = errors.New("personal access token expired")
+	ErrTokenRevoked    = errors.New("personal access token revoked")
+	ErrNotAccessToken  = errors.New("not a personal access token")
+	ErrDuplicateScopes = errors.New("duplicate token scope")
+)
+
+type patService struct {
+	tokens ports.PersonalAccessTokenRepository
+	users  ports.UserRepository
+}
+
+// NewPersonalAccessTokenService manages personal access tokens. users is
+// consulted on every authentication so that tokens stop working as soon as
+// their owner is deactivated or deleted.
+func NewPersonalAccessTokenService(tokens ports.PersonalAccessTokenRepository, users ports.UserRepository) ports.PersonalAccessTokenService {
+	return &patService{tokens: tokens, users: users}
+}
+
+// IsPersonalAccessToken reports whether a raw bearer credential looks like a personal access token
+func IsPersonalAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, TokenPrefix)
+}
+
+// HashToken returns the hex encoded SHA-256 hash under which a token is stored
+func HashToken(raw string) string {
+	h := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(h[:])
+}
+
+// Create issues a new token and returns it together with the plain value,
+// which is never persisted and cannot be recovered later.
+func (s *patService) Create(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (*models.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, "", ErrInvalidName
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	if ttl < 24*time.Hour || ttl > maxTTL {
+		return nil, "", ErrInvalidTTL
+	}
+
+	raw := TokenPrefix + valueobjects.NewToken().String()
+	now := time.Now()
+	pat := &models.PersonalAccessToken{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   HashToken(raw),
+		TokenPrefix: raw[:displayLength],
+		Scopes:      scopes,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	if err := s.tokens.Create(ctx, pat); err != nil {
+		return nil, "", err
+	}
+	return pat, raw, nil
+}
+
+func (s *patService) List(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	return s.tokens.ListByUser(ctx, userID)
+}
+
+func (s *patService) Revoke(ctx context.Context, userID, id string) error {
+	if err := s.tokens.Revoke(ctx, userID, id); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return ErrTokenNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Authenticate resolves a raw token to its record and owner, rejecting
+// revoked and expired tokens and those of inactive or deleted users
+func (s *patService) Authenticate(ctx context.Context, rawToken string) (*models.PersonalAccessToken, *models.User, error) {
+	if !IsPersonalAccessToken(rawToken) {
+		return nil, nil, ErrNotAccessToken
+	}
+	pat, err := s.tokens.FindByHash(ctx, HashToken(rawToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if pat == nil {
+		return nil, nil, ErrTokenInvalid
+	}
+
+	now := time.Now()
+	if pat.RevokedAt != nil {
+		return nil, nil, ErrTokenRevoked
+	}
+	if !now.Before(pat.ExpiresAt) {
+		return nil, nil, ErrTokenExpired
+	}
+	// soft-deleted users are not found
+	owner, err := s.users.FindByID(ctx, pat.UserID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !owner.IsActive || owner.DeletedAt != nil {
+		return nil, nil, ErrTokenInvalid
+	}
+	_ = s.tokens.TouchLastUsed(ctx, pat.ID, now)
+	return pat, owner, nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrNoScopes
+	}
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if seen[scope] {
+			return ErrDuplicateScopes
+		}
+		seen[scope] = true
+		if !isKnownScope(scope) {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range models.PersonalAccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
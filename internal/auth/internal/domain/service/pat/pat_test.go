@@ -0,0 +1,200 @@
+package pat_service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+const day = 24 * time.Hour
+
+type testPATs struct {
+	service ports.PersonalAccessTokenService
+	users   *sqlite.UserRepository
+	db      *database.Client
+}
+
+func newTestPATs(t *testing.T) *testPATs {
+	t.Helper()
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	users := sqlite.NewUserRepository(db)
+	return &testPATs{
+		service: NewPersonalAccessTokenService(sqlite.NewPersonalAccessTokenRepository(db), users),
+		users:   users,
+		db:      db,
+	}
+}
+
+func (h *testPATs) user(t *testing.T, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email, PasswordHash: "hash", Role: "creator", IsActive: true}
+	if err := h.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestCreateValidatesTheRequest(t *testing.T) {
+	h := newTestPATs(t)
+	owner := h.user(t, "ada@example.com")
+	read := []string{models.ScopeContentRead}
+
+	tests := []struct {
+		name    string
+		token   string
+		scopes  []string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{"blank name", "  ", read, 30 * day, ErrInvalidName},
+		{"long name", strings.Repeat("x", maxNameLength+1), read, 30 * day, ErrInvalidName},
+		{"no scopes", "ci", nil, 30 * day, ErrNoScopes},
+		{"unknown scope", "ci", []string{"admin:all"}, 30 * day, ErrInvalidScope},
+		{"duplicate scope", "ci", []string{models.ScopeContentRead, models.ScopeContentRead}, 30 * day, ErrDuplicateScopes},
+		{"shorter than a day", "ci", read, time.Hour, ErrInvalidTTL},
+		{"longer than a year", "ci", read, 366 * day, ErrInvalidTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := h.service.Create(context.Background(), owner.ID, tt.token, tt.scopes, tt.ttl); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateStoresOnlyTheHash(t *testing.T) {
+	h := newTestPATs(t)
+	owner := h.user(t, "ada@example.com")
+
+	pat, raw, err := h.service.Create(context.Background(), owner.ID, "ci", []string{models.ScopeContentWrite}, 30*day)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !IsPersonalAccessToken(raw) {
+		t.Errorf("token %q lacks the %s prefix", raw, TokenPrefix)
+	}
+	if pat.TokenHash != HashToken(raw) || !strings.HasPrefix(raw, pat.TokenPrefix) {
+		t.Errorf("stored hash %s and prefix %s do not match the token", pat.TokenHash, pat.TokenPrefix)
+	}
+	var stored int
+	if err := h.db.GetDB().Get(&stored, `SELECT COUNT(*) FROM personal_access_tokens WHERE token_hash = ? OR token_prefix = ?`, raw, raw); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 0 {
+		t.Error("the plain token was persisted")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("resolves the token and its owner", func(t *testing.T) {
+		h := newTestPATs(t)
+		owner := h.user(t, "ada@example.com")
+		created, raw, err := h.service.Create(ctx, owner.ID, "ci", []string{models.ScopeContentRead}, 30*day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pat, user, err := h.service.Authenticate(ctx, raw)
+		if err != nil {
+			t.Fatalf("Authenticate: %v", err)
+		}
+		if pat.ID != created.ID || user.ID != owner.ID || !pat.HasScope(models.ScopeContentRead) {
+			t.Errorf("got token %s of %s with scopes %v", pat.ID, user.ID, pat.Scopes)
+		}
+	})
+
+	t.Run("rejects unusable tokens", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			spoil   func(h *testPATs, owner *models.User, pat *models.PersonalAccessToken) string
+			wantErr error
+		}{
+			{"not a personal access token", func(*testPATs, *models.User, *models.PersonalAccessToken) string {
+				return "eyJhbGciOiJSUzI1NiJ9.e30.sig"
+			}, ErrNotAccessToken},
+			{"unknown", func(*testPATs, *models.User, *models.PersonalAccessToken) string {
+				return TokenPrefix + "guessed"
+			}, ErrTokenInvalid},
+			{"revoked", func(h *testPATs, owner *models.User, pat *models.PersonalAccessToken) string {
+				if err := h.service.Revoke(ctx, owner.ID, pat.ID); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			}, ErrTokenRevoked},
+			{"expired", func(h *testPATs, _ *models.User, pat *models.PersonalAccessToken) string {
+				if _, err := h.db.GetDB().Exec(`UPDATE personal_access_tokens SET expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute).UTC(), pat.ID); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			}, ErrTokenExpired},
+			{"owner deactivated", func(h *testPATs, owner *models.User, _ *models.PersonalAccessToken) string {
+				owner.IsActive = false
+				if err := h.users.Update(ctx, owner); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			}, ErrTokenInvalid},
+			{"owner deleted", func(h *testPATs, owner *models.User, _ *models.PersonalAccessToken) string {
+				if err := h.users.SoftDelete(ctx, owner.ID, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			}, ErrTokenInvalid},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				h := newTestPATs(t)
+				owner := h.user(t, "ada@example.com")
+				pat, raw, err := h.service.Create(ctx, owner.ID, "ci", []string{models.ScopeContentRead}, 30*day)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if other := tt.spoil(h, owner, pat); other != "" {
+					raw = other
+				}
+				if pat, user, err := h.service.Authenticate(ctx, raw); !errors.Is(err, tt.wantErr) || pat != nil || user != nil {
+					t.Errorf("got %v, %v, %v; want %v", pat, user, err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
+
+func TestRevokeOnlyOwnTokens(t *testing.T) {
+	h := newTestPATs(t)
+	ctx := context.Background()
+	ada, grace := h.user(t, "ada@example.com"), h.user(t, "grace@example.com")
+	pat, raw, err := h.service.Create(ctx, ada.ID, "ci", []string{models.ScopeContentRead}, 30*day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.service.Revoke(ctx, grace.ID, pat.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("revoking another user's token: got %v, want ErrTokenNotFound", err)
+	}
+	if _, _, err := h.service.Authenticate(ctx, raw); err != nil {
+		t.Errorf("token stopped working after a foreign revoke: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package profile_service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+)
+
+const (
+	maxNameLength      = 100
+	maxAvatarURLLength = 2048
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrInvalidName      = errors.New("names must be at most 100 characters")
+	ErrInvalidAvatarURL = errors.New("avatar URL must be an absolute http or https URL")
+	ErrInvalidLocale    = errors.New("locale must be a BCP 47 language tag such as en or bn-BD")
+	ErrInvalidTimezone  = errors.New("timezone must be an IANA time zone such as Asia/Dhaka")
+
+	// languageTag accepts the common BCP 47 shapes: language, optional script, region and variants
+	languageTag = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-([a-zA-Z]{2}|[0-9]{3}))?(-[a-zA-Z0-9]{5,8})*$`)
+)
+
+type profileService struct {
+	users ports.UserRepository
+}
+
+func NewProfileService(users ports.UserRepository) ports.ProfileService {
+	return &profileService{users: users}
+}
+
+func (s *profileService) Get(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update validates and applies a partial profile update
+func (s *profileService) Update(ctx context.Context, userID string, update models.ProfileUpdate) (*models.User, error) {
+	normalize(&update)
+	if err := validate(update); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	update.Apply(user)
+	user.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, user); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func normalize(update *models.ProfileUpdate) {
+	for _, field := range []*string{
+		update.FirstName, update.LastName, update.DisplayName,
+		update.AvatarURL, update.Locale, update.Timezone,
+	} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+}
+
+func validate(update models.ProfileUpdate) error {
+	for _, name := range []*string{update.FirstName, update.LastName, update.DisplayName} {
+		if name != nil && utf8.RuneCountInString(*name) > maxNameLength {
+			return ErrInvalidName
+		}
+	}
+	if v := update.AvatarURL; v != nil && *v != "" && !isAvatarURL(*v) {
+		return ErrInvalidAvatarURL
+	}
+	if v := update.Locale; v != nil && *v != "" && !languageTag.MatchString(*v) {
+		return ErrInvalidLocale
+	}
+	if v := update.Timezone; v != nil && *v != "" {
+		// LoadLocation also accepts "Local", which means nothing to other clients
+		if _, err := time.LoadLocation(*v); err != nil || *v == "Local" {
+			return ErrInvalidTimezone
+		}
+	}
+	return nil
+}
+
+func isAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
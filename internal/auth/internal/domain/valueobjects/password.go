@@ -2,26 +2,76 @@ package valueobjects
 
 import (
 	"errors"
-	"unicode"
+	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
 )
 
 var (
-	ErrWeakPassword = errors.New("password must be at least 8 chars with upper, lower, digit, special")
+	ErrWeakPassword     = errors.New("password does not meet the password policy")
+	ErrBreachedPassword = errors.New("password has appeared in a data breach, please choose another one")
 )
 
+// BreachedPasswordChecker reports whether a password appears in a list of breached passwords.
+// Implementations must answer locally and never call out to a network service.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+var breachedPasswords BreachedPasswordChecker
+
+// SetBreachedPasswordChecker enables breached-password screening in NewPassword.
+// It is meant to be called once at startup; a nil checker disables screening.
+func SetBreachedPasswordChecker(checker BreachedPasswordChecker) {
+	breachedPasswords = checker
+}
+
 type Password struct {
 	plainText string
 }
 
+// NewPassword validates a new password against the configured policy and the
+// breached-password corpus. Use NewPasswordForEmail when the owner's email is known.
 func NewPassword(pwd string) (Password, error) {
-	if !isStrongPassword(pwd) {
-		return Password{}, ErrWeakPassword
+	return NewPasswordForEmail(pwd, Email{})
+}
+
+// NewPasswordForEmail validates a new password for the owner of email, which
+// additionally enforces the policy's email local part rule.
+func NewPasswordForEmail(pwd string, email Email) (Password, error) {
+	violations := CurrentPasswordPolicy().Check(pwd, email.String())
+	if breachedPasswords != nil {
+		breached, err := breachedPasswords.IsBreached(pwd)
+		if err != nil {
+			return Password{}, fmt.Errorf("failed to screen password: %w", err)
+		}
+		if breached {
+			violations = append(violations, PolicyViolation{RuleBreached, "has appeared in a data breach, please choose another one"})
+		}
+	}
+	if len(violations) > 0 {
+		return Password{}, &PasswordPolicyError{Violations: violations}
 	}
 	return Password{plainText: pwd}, nil
 }
 
+// PasswordFromInput wraps a password supplied to authenticate, such as on login.
+// It is not checked against the policy, so policy changes never lock out existing users.
+func PasswordFromInput(pwd string) Password {
+	return Password{plainText: pwd}
+}
+
+// CheckEmail enforces the email local part rule for a password created before the email was known
+func (p Password) CheckEmail(email string) error {
+	policy := CurrentPasswordPolicy()
+	if policy.ForbidEmailLocalPart && containsLocalPart(p.plainText, email) {
+		return &PasswordPolicyError{Violations: []PolicyViolation{
+			{RuleEmailLocalPart, "must not contain your email address"},
+		}}
+	}
+	return nil
+}
+
 func (p Password) String() string {
 	return p.plainText
 }
@@ -31,40 +81,18 @@ func (p Password) Bytes() []byte {
 	return []byte(p.plainText)
 }
 
-func isStrongPassword(password string) bool {
-	var (
-		hasMinLen = false
-		hasUpper  = false
-		hasLower  = false
-		hasNumber = false
-		hasSymbol = false
-	)
-
-	if len(password) >= 8 {
-		hasMinLen = true
-	}
-	for _, c := range password {
-		switch {
-		case unicode.IsUpper(c):
-			hasUpper = true
-		case unicode.IsLower(c):
-			hasLower = true
-		case unicode.IsDigit(c):
-			hasNumber = true
-		case unicode.IsPunct(c) || unicode.IsSymbol(c):
-			hasSymbol = true
-		}
-	}
-	return hasMinLen && hasUpper && hasLower && hasNumber && hasSymbol
-}
-
+// Hash encodes the password with the configured hashing policy
 func (p Password) Hash() (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(p.String()), bcrypt.DefaultCost)
-	return string(bytes), err
+	return hashing.Default().Hash(p.String())
 }
 
+// Matches reports whether the password matches a hash produced by any supported algorithm
 func (p Password) Matches(hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(p.String()))
-	return err == nil
+	ok, err := hashing.Default().Verify(p.String(), hash)
+	return err == nil && ok
 }
 
+// NeedsRehash reports whether the hash should be upgraded to the current hashing policy
+func (p Password) NeedsRehash(hash string) bool {
+	return hashing.Default().NeedsRehash(hash)
+}
@@ -0,0 +1,209 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy rule identifiers reported in violations
+const (
+	RuleMinLength      = "min_length"
+	RuleMaxLength      = "max_length"
+	RuleUppercase      = "require_upper"
+	RuleLowercase      = "require_lower"
+	RuleDigit          = "require_digit"
+	RuleSymbol         = "require_symbol"
+	RuleMaxRepeated    = "max_repeated"
+	RuleEmailLocalPart = "email_local_part"
+	RuleBreached       = "breached"
+	RuleHistory        = "history"
+)
+
+// minLocalPartLength avoids rejecting passwords for containing very short local parts such as "jo"
+const minLocalPartLength = 3
+
+// PasswordPolicy describes the rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength            int  `mapstructure:"min_length"`
+	MaxLength            int  `mapstructure:"max_length"`
+	RequireUpper         bool `mapstructure:"require_upper"`
+	RequireLower         bool `mapstructure:"require_lower"`
+	RequireDigit         bool `mapstructure:"require_digit"`
+	RequireSymbol        bool `mapstructure:"require_symbol"`
+	MaxRepeated          int  `mapstructure:"max_repeated"` // longest run of one character, 0 disables the rule
+	ForbidEmailLocalPart bool `mapstructure:"forbid_email_local_part"`
+	HistorySize          int  `mapstructure:"history_size"` // number of previous passwords that cannot be reused
+}
+
+// DefaultPasswordPolicy mirrors the rules enforced before the policy became configurable
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     128,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+}
+
+// Validate checks that the policy itself is consistent
+func (p PasswordPolicy) Validate() error {
+	if p.MinLength < 1 {
+		return errors.New("password policy min_length must be at least 1")
+	}
+	if p.MaxLength < p.MinLength {
+		return errors.New("password policy max_length must not be lower than min_length")
+	}
+	if p.MaxRepeated < 0 || p.HistorySize < 0 {
+		return errors.New("password policy max_repeated and history_size must not be negative")
+	}
+	return nil
+}
+
+// Check returns every rule the password breaks. email may be empty when unknown.
+func (p PasswordPolicy) Check(password, email string) []PolicyViolation {
+	var violations []PolicyViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PolicyViolation{RuleMinLength, fmt.Sprintf("must be at least %d characters", p.MinLength)})
+	}
+	if length > p.MaxLength {
+		violations = append(violations, PolicyViolation{RuleMaxLength, fmt.Sprintf("must be at most %d characters", p.MaxLength)})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, PolicyViolation{RuleUppercase, "must contain an uppercase letter"})
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, PolicyViolation{RuleLowercase, "must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, PolicyViolation{RuleDigit, "must contain a digit"})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, PolicyViolation{RuleSymbol, "must contain a special character"})
+	}
+
+	if p.MaxRepeated > 0 && longestRun(password) > p.MaxRepeated {
+		violations = append(violations, PolicyViolation{RuleMaxRepeated, fmt.Sprintf("must not repeat a character more than %d times in a row", p.MaxRepeated)})
+	}
+
+	if p.ForbidEmailLocalPart && containsLocalPart(password, email) {
+		violations = append(violations, PolicyViolation{RuleEmailLocalPart, "must not contain your email address"})
+	}
+	return violations
+}
+
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune = -1
+	for _, c := range s {
+		if c == prev {
+			run++
+		} else {
+			run = 1
+			prev = c
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func containsLocalPart(password, email string) bool {
+	local, _, found := strings.Cut(email, "@")
+	if !found || utf8.RuneCountInString(local) < minLocalPartLength {
+		return false
+	}
+	return strings.Contains(strings.ToLower(password), strings.ToLower(local))
+}
+
+// PolicyViolation describes a single broken password rule
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password breaks. It matches
+// ErrWeakPassword and, for breached passwords, ErrBreachedPassword with errors.Is.
+type PasswordPolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return "password " + strings.Join(messages, ", ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	switch target {
+	case ErrWeakPassword:
+		return true
+	case ErrBreachedPassword:
+		return e.HasRule(RuleBreached)
+	}
+	return false
+}
+
+// HasRule reports whether the given rule was violated
+func (e *PasswordPolicyError) HasRule(rule string) bool {
+	for _, v := range e.Violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPasswordReusedError reports that a password matches one of the user's previous passwords
+func NewPasswordReusedError(historySize int) error {
+	return &PasswordPolicyError{Violations: []PolicyViolation{
+		{RuleHistory, fmt.Sprintf("must not match any of your last %d passwords", historySize)},
+	}}
+}
+
+var (
+	passwordPolicy   = DefaultPasswordPolicy()
+	passwordPolicyMu sync.RWMutex
+)
+
+// SetPasswordPolicy replaces the policy used by NewPassword. It is meant to be called at startup.
+func SetPasswordPolicy(policy PasswordPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = policy
+	return nil
+}
+
+// CurrentPasswordPolicy returns the policy in force
+func CurrentPasswordPolicy() PasswordPolicy {
+	passwordPolicyMu.RLock()
+	defer passwordPolicyMu.RUnlock()
+	return passwordPolicy
+}
@@ -0,0 +1,110 @@
+package valueobjects
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func rules(violations []PolicyViolation) []string {
+	out := make([]string, 0, len(violations))
+	for _, v := range violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	strict := PasswordPolicy{
+		MinLength:            10,
+		MaxLength:            20,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		MaxRepeated:          2,
+		ForbidEmailLocalPart: true,
+	}
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		email    string
+		want     []string
+	}{
+		{"compliant", strict, "Zebra!Quilt7", "ada@example.com", nil},
+		{"too short", strict, "Ze!Qu7", "", []string{RuleMinLength}},
+		{"too long", strict, "Zebra!Quilt7Zebra!Quilt7", "", []string{RuleMaxLength}},
+		{"length counts characters, not bytes", strict, "Ünïcödé!Pâss7", "", nil},
+		{"no uppercase", strict, "zebra!quilt7", "", []string{RuleUppercase}},
+		{"no lowercase", strict, "ZEBRA!QUILT7", "", []string{RuleLowercase}},
+		{"no digit", strict, "Zebra!Quilts", "", []string{RuleDigit}},
+		{"no symbol", strict, "ZebraQuilt77", "", []string{RuleSymbol}},
+		{"repeated characters", strict, "Zebra!Quiiilt7", "", []string{RuleMaxRepeated}},
+		{"contains the email local part", strict, "Zebra!ADA-7x", "ada@example.com", []string{RuleEmailLocalPart}},
+		{"short local parts are ignored", strict, "Zebra!Jo-7xy", "jo@example.com", nil},
+		{"unknown email", strict, "Zebra!Ada-7x", "", nil},
+		{"every violation is reported", strict, "aaa", "", []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol, RuleMaxRepeated}},
+		{"lenient policy", PasswordPolicy{MinLength: 4, MaxLength: 64}, "aaaa", "aaaa@example.com", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(tt.policy.Check(tt.password, tt.email)); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PasswordPolicy
+		wantErr bool
+	}{
+		{"default", DefaultPasswordPolicy(), false},
+		{"no minimum", PasswordPolicy{MaxLength: 10}, true},
+		{"maximum below minimum", PasswordPolicy{MinLength: 12, MaxLength: 8}, true},
+		{"negative history", PasswordPolicy{MinLength: 8, MaxLength: 64, HistorySize: -1}, true},
+		{"negative repeat limit", PasswordPolicy{MinLength: 8, MaxLength: 64, MaxRepeated: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetPasswordPolicy(t *testing.T) {
+	previous := CurrentPasswordPolicy()
+	t.Cleanup(func() { SetPasswordPolicy(previous) })
+
+	if err := SetPasswordPolicy(PasswordPolicy{MinLength: 12, MaxLength: 8}); err == nil {
+		t.Fatal("an inconsistent policy was accepted")
+	}
+	if CurrentPasswordPolicy() != previous {
+		t.Fatal("a rejected policy replaced the current one")
+	}
+
+	if err := SetPasswordPolicy(PasswordPolicy{MinLength: 16, MaxLength: 64, ForbidEmailLocalPart: true}); err != nil {
+		t.Fatal(err)
+	}
+	email, _ := NewEmail("grace@example.com")
+	_, err := NewPasswordForEmail("grace-hopper-1906", email)
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) || !slices.Equal(rules(policyErr.Violations), []string{RuleEmailLocalPart}) {
+		t.Errorf("got %v, want only the email local part violation", err)
+	}
+	if !strings.Contains(err.Error(), "must not contain your email address") {
+		t.Errorf("message %q does not explain the violation", err)
+	}
+	if _, err := NewPassword("a long passphrase"); err != nil {
+		t.Errorf("passphrase under the lenient policy: %v", err)
+	}
+	if err := PasswordFromInput("grace-hopper-1906").CheckEmail("grace@example.com"); err == nil {
+		t.Error("CheckEmail accepted a password containing the email")
+	}
+}
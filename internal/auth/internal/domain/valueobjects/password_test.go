@@ -0,0 +1,56 @@
+package valueobjects
+
+import (
+	"errors"
+	"testing"
+)
+
+// breachList flags the passwords it holds, or fails with err
+type breachList struct {
+	breached map[string]bool
+	err      error
+}
+
+func (l breachList) IsBreached(password string) (bool, error) {
+	return l.breached[password], l.err
+}
+
+func useBreachedPasswordChecker(t *testing.T, checker BreachedPasswordChecker) {
+	t.Helper()
+	previous := breachedPasswords
+	t.Cleanup(func() { SetBreachedPasswordChecker(previous) })
+	SetBreachedPasswordChecker(checker)
+}
+
+func TestNewPasswordScreensBreachedPasswords(t *testing.T) {
+	useBreachedPasswordChecker(t, breachList{breached: map[string]bool{"P@ssw0rd": true, "short": true}})
+
+	_, err := NewPassword("P@ssw0rd")
+	if !errors.Is(err, ErrBreachedPassword) || !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("breached password: got %v, want ErrBreachedPassword", err)
+	}
+
+	// reported together with the other broken rules
+	var policyErr *PasswordPolicyError
+	if _, err := NewPassword("short"); !errors.As(err, &policyErr) || !policyErr.HasRule(RuleBreached) || !policyErr.HasRule(RuleMinLength) {
+		t.Errorf("short breached password: got %v, want the breach and the length violation", err)
+	}
+
+	if _, err := NewPassword("Un1que!Passphrase"); err != nil {
+		t.Errorf("safe password: %v", err)
+	}
+
+	// passwords presented to log in are never screened
+	if PasswordFromInput("P@ssw0rd").String() != "P@ssw0rd" {
+		t.Error("login password was altered")
+	}
+}
+
+func TestNewPasswordFailsWhenScreeningFails(t *testing.T) {
+	useBreachedPasswordChecker(t, breachList{err: errors.New("corpus unreadable")})
+
+	_, err := NewPassword("Un1que!Passphrase")
+	if err == nil || errors.Is(err, ErrWeakPassword) {
+		t.Errorf("got %v, want a screening error rather than a policy violation", err)
+	}
+}
@@ -0,0 +1,148 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomMagic identifies a compiled breached-password Bloom filter file
+var bloomMagic = [4]byte{'M', 'F', 'B', 'F'}
+
+const bloomVersion uint32 = 1
+
+var errInvalidBloomFile = errors.New("invalid bloom filter file")
+
+// BloomFilter is a compact, probabilistic breach corpus. It never misses a
+// breached password but may flag a small fraction of safe ones.
+//
+// The full HIBP corpus does not fit in memory as a sorted set, so it is
+// compiled once into a Bloom filter with CompileBloomFilter and loaded at startup.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint32 // number of hash functions
+}
+
+// NewBloomFilter sizes a filter for n entries at the given false positive rate
+func NewBloomFilter(n uint64, falsePositiveRate float64) (*BloomFilter, error) {
+	if n == 0 {
+		return nil, errors.New("bloom filter needs at least one entry")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return newBloomFilter(m, k), nil
+}
+
+func newBloomFilter(m uint64, k uint32) *BloomFilter {
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add inserts a SHA-1 digest
+func (b *BloomFilter) Add(digest [sha1.Size]byte) {
+	h1, h2 := splitDigest(digest)
+	for i := uint32(0); i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *BloomFilter) Contains(digest [sha1.Size]byte) (bool, error) {
+	h1, h2 := splitDigest(digest)
+	for i := uint32(0); i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// splitDigest derives the two base hashes for double hashing. SHA-1 output is
+// already uniformly distributed, so no further hashing is needed.
+func splitDigest(digest [sha1.Size]byte) (uint64, uint64) {
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1 // keep odd so probes never collapse
+	return h1, h2
+}
+
+// WriteTo serializes the filter as: magic, version, m, k, then the bit array
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := struct {
+		Magic   [4]byte
+		Version uint32
+		M       uint64
+		K       uint32
+	}{bloomMagic, bloomVersion, b.m, b.k}
+
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, b.bits); err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return int64(binary.Size(header) + 8*len(b.bits)), nil
+}
+
+// ReadBloomFilter deserializes a filter written by WriteTo
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	br := bufio.NewReader(r)
+
+	var header struct {
+		Magic   [4]byte
+		Version uint32
+		M       uint64
+		K       uint32
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBloomFile, err)
+	}
+	if header.Magic != bloomMagic || header.Version != bloomVersion || header.M == 0 || header.K == 0 {
+		return nil, errInvalidBloomFile
+	}
+
+	b := newBloomFilter(header.M, header.K)
+	if err := binary.Read(br, binary.LittleEndian, b.bits); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBloomFile, err)
+	}
+	return b, nil
+}
+
+// LoadBloomFilter reads a compiled filter from disk
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bloom filter: %w", err)
+	}
+	defer f.Close()
+	return ReadBloomFilter(f)
+}
+
+// CompileBloomFilter builds a filter from a HIBP "ordered by hash" file.
+// expected is the number of entries to size the filter for, typically the line count.
+func CompileBloomFilter(hibp io.Reader, expected uint64, falsePositiveRate float64, minCount int) (*BloomFilter, error) {
+	filter, err := NewBloomFilter(expected, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	if err := scanHIBP(hibp, "", minCount, filter.Add); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,181 @@
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corpus maps breached passwords to how often they were seen
+var corpus = map[string]int{
+	"password":  9545824,
+	"123456":    37359195,
+	"letmein":   1,
+	"Tr0ub4dor": 3,
+}
+
+func hexDigest(password string) string {
+	digest := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(digest[:]))
+}
+
+// writeHIBPFile writes the corpus as a HIBP "ordered by hash" file
+func writeHIBPFile(t *testing.T) string {
+	t.Helper()
+	var lines []string
+	for password, count := range corpus {
+		lines = append(lines, fmt.Sprintf("%s:%d", hexDigest(password), count))
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeHIBPRangeDir writes the corpus as one SUFFIX:COUNT file per prefix
+func writeHIBPRangeDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for password, count := range corpus {
+		full := hexDigest(password)
+		path := filepath.Join(dir, full[:rangePrefixLength]+".txt")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(f, "%s:%d\n", full[rangePrefixLength:], count)
+		f.Close()
+	}
+	return dir
+}
+
+// writeBloomFilter compiles the corpus into a filter file
+func writeBloomFilter(t *testing.T, minCount int) string {
+	t.Helper()
+	hibp, err := os.Open(writeHIBPFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hibp.Close()
+	filter, err := CompileBloomFilter(hibp, uint64(len(corpus)), 0.0001, minCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := filter.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckerFindsBreachedPasswords(t *testing.T) {
+	sources := map[string]func(t *testing.T, minCount int) (string, string){
+		SourceHIBPFile:     func(t *testing.T, _ int) (string, string) { return SourceHIBPFile, writeHIBPFile(t) },
+		SourceHIBPRangeDir: func(t *testing.T, _ int) (string, string) { return SourceHIBPRangeDir, writeHIBPRangeDir(t) },
+		SourceBloomFilter: func(t *testing.T, minCount int) (string, string) {
+			return SourceBloomFilter, writeBloomFilter(t, minCount)
+		},
+	}
+	for name, write := range sources {
+		t.Run(name, func(t *testing.T) {
+			for _, minCount := range []int{0, 2} {
+				kind, path := write(t, minCount)
+				source, err := Open(kind, path, minCount)
+				if err != nil {
+					t.Fatalf("Open: %v", err)
+				}
+				checker := NewChecker(source)
+
+				for password, count := range corpus {
+					want := count >= minCount
+					if breached, err := checker.IsBreached(password); err != nil || breached != want {
+						t.Errorf("minCount %d: IsBreached(%q) = %v, %v; want %v", minCount, password, breached, err, want)
+					}
+				}
+				if breached, err := checker.IsBreached("c0rrect-h0rse-battery-st@ple"); err != nil || breached {
+					t.Errorf("minCount %d: IsBreached(safe password) = %v, %v", minCount, breached, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenRejectsBadCorpora(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.txt")
+	if err := os.WriteFile(malformed, []byte("not-a-hash:12\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	notBloom := filepath.Join(dir, "not.bloom")
+	if err := os.WriteFile(notBloom, []byte("MFBX garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		path    string
+		wantErr error
+	}{
+		{"unknown kind", "online", dir, nil},
+		{"missing file", SourceHIBPFile, filepath.Join(dir, "missing.txt"), os.ErrNotExist},
+		{"malformed line", SourceHIBPFile, malformed, errMalformedLine},
+		{"range dir is a file", SourceHIBPRangeDir, malformed, nil},
+		{"not a bloom filter", SourceBloomFilter, notBloom, errInvalidBloomFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.kind, tt.path, 0)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBloomFilterRoundTrip(t *testing.T) {
+	filter, err := NewBloomFilter(1000, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		filter.Add(sha1.Sum([]byte(fmt.Sprint("breached-", i))))
+	}
+
+	var buf bytes.Buffer
+	if _, err := filter.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadBloomFilter(&buf)
+	if err != nil {
+		t.Fatalf("ReadBloomFilter: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if ok, _ := loaded.Contains(sha1.Sum([]byte(fmt.Sprint("breached-", i)))); !ok {
+			t.Fatalf("breached-%d is missing after the round trip", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if ok, _ := loaded.Contains(sha1.Sum([]byte(fmt.Sprint("safe-", i)))); ok {
+			falsePositives++
+		}
+	}
+	// 0.1% expected; allow for variance
+	if falsePositives > 50 {
+		t.Errorf("%d false positives in 10000 lookups, want about 10", falsePositives)
+	}
+}
@@ -0,0 +1,48 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// Source answers whether a SHA-1 password digest is present in a breach corpus
+type Source interface {
+	Contains(digest [sha1.Size]byte) (bool, error)
+}
+
+// Source kinds that can be selected in configuration
+const (
+	SourceHIBPFile     = "hibp_file"
+	SourceHIBPRangeDir = "hibp_range_dir"
+	SourceBloomFilter  = "bloom"
+)
+
+// Checker screens plain-text passwords against a local breach corpus.
+// It implements valueobjects.BreachedPasswordChecker.
+type Checker struct {
+	source Source
+}
+
+func NewChecker(source Source) *Checker {
+	return &Checker{source: source}
+}
+
+// IsBreached hashes the password with SHA-1, as HIBP does, and looks it up in the corpus
+func (c *Checker) IsBreached(password string) (bool, error) {
+	return c.source.Contains(sha1.Sum([]byte(password)))
+}
+
+// Open loads a breach corpus of the given kind from path.
+// minCount drops HIBP entries seen fewer times than that; it is ignored for Bloom filters.
+func Open(kind, path string, minCount int) (Source, error) {
+	switch kind {
+	case SourceHIBPFile:
+		return LoadHIBPFile(path, minCount)
+	case SourceHIBPRangeDir:
+		return NewHIBPRangeDir(path, minCount)
+	case SourceBloomFilter:
+		return LoadBloomFilter(path)
+	default:
+		return nil, fmt.Errorf("unknown breached password source %q", kind)
+	}
+}
@@ -0,0 +1,143 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rangePrefixLength = 5
+
+var errMalformedLine = errors.New("malformed HIBP line")
+
+// HIBPSet is an in-memory, sorted set of SHA-1 digests loaded from a
+// Have I Been Pwned "ordered by hash" file, where each line is HASH:COUNT.
+type HIBPSet struct {
+	digests [][sha1.Size]byte
+}
+
+// LoadHIBPFile reads a HIBP SHA-1 file into memory
+func LoadHIBPFile(path string, minCount int) (*HIBPSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HIBP file: %w", err)
+	}
+	defer f.Close()
+
+	set := &HIBPSet{}
+	err = scanHIBP(f, "", minCount, func(digest [sha1.Size]byte) {
+		set.digests = append(set.digests, digest)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HIBP file %s: %w", path, err)
+	}
+
+	// the download is already ordered by hash, but don't rely on it
+	sort.Slice(set.digests, func(i, j int) bool {
+		return bytes.Compare(set.digests[i][:], set.digests[j][:]) < 0
+	})
+	return set, nil
+}
+
+// Len returns the number of digests in the set
+func (s *HIBPSet) Len() int {
+	return len(s.digests)
+}
+
+func (s *HIBPSet) Contains(digest [sha1.Size]byte) (bool, error) {
+	i := sort.Search(len(s.digests), func(i int) bool {
+		return bytes.Compare(s.digests[i][:], digest[:]) >= 0
+	})
+	return i < len(s.digests) && s.digests[i] == digest, nil
+}
+
+// HIBPRangeDir looks digests up in a directory of HIBP range files, as written
+// by the PwnedPasswordsDownloader in single-file-per-prefix mode. Each file is
+// named after a 5 character hash prefix and holds SUFFIX:COUNT lines, so only
+// one small file is read per lookup and nothing is kept in memory.
+type HIBPRangeDir struct {
+	dir      string
+	minCount int
+}
+
+func NewHIBPRangeDir(dir string, minCount int) (*HIBPRangeDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HIBP range directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &HIBPRangeDir{dir: dir, minCount: minCount}, nil
+}
+
+func (d *HIBPRangeDir) Contains(digest [sha1.Size]byte) (bool, error) {
+	full := strings.ToUpper(hex.EncodeToString(digest[:]))
+	prefix := full[:rangePrefixLength]
+
+	f, err := d.openRange(prefix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	found := false
+	err = scanHIBP(f, prefix, d.minCount, func(candidate [sha1.Size]byte) {
+		if candidate == digest {
+			found = true
+		}
+	})
+	return found, err
+}
+
+func (d *HIBPRangeDir) openRange(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(d.dir, prefix))
+	}
+	return f, err
+}
+
+// scanHIBP parses HASH:COUNT lines. When prefix is set the lines only hold
+// the hash suffix, as in range files, and the prefix is prepended.
+func scanHIBP(r io.Reader, prefix string, minCount int, fn func([sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		hash, countStr, _ := strings.Cut(line, ":")
+		if minCount > 0 && countStr != "" {
+			count, err := strconv.Atoi(countStr)
+			if err != nil {
+				return fmt.Errorf("%w: %q", errMalformedLine, line)
+			}
+			if count < minCount {
+				continue
+			}
+		}
+
+		raw, err := hex.DecodeString(prefix + hash)
+		if err != nil || len(raw) != sha1.Size {
+			return fmt.Errorf("%w: %q", errMalformedLine, line)
+		}
+		var digest [sha1.Size]byte
+		copy(digest[:], raw)
+		fn(digest)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"context"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// LogPublisher writes domain events to the log. It is used when no message
+// broker is configured, so events are at least visible to operators.
+type LogPublisher struct {
+	logger *logger.Logger
+}
+
+func NewLogPublisher(logger *logger.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event models.DomainEvent) error {
+	p.logger.Info("Domain event",
+		zap.String("event_id", event.ID),
+		zap.String("event_type", event.Type),
+		zap.String("aggregate_id", event.AggregateID),
+		zap.Any("payload", event.Payload),
+		zap.Time("occurred_at", event.OccurredAt),
+	)
+	return nil
+}
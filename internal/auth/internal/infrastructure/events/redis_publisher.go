@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultStream is the Redis stream auth events are appended to
+const DefaultStream = "auth:events"
+
+// RedisStreamPublisher appends domain events to a Redis stream. Consumers read
+// it through consumer groups; events may be delivered more than once, so
+// consumers should deduplicate on the event ID.
+type RedisStreamPublisher struct {
+	rdb    *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher creates a publisher for stream, trimming it to about
+// maxLen entries; zero keeps every entry
+func NewRedisStreamPublisher(rdb *database.RedisClient, stream string, maxLen int64) *RedisStreamPublisher {
+	if stream == "" {
+		stream = DefaultStream
+	}
+	return &RedisStreamPublisher{rdb: rdb.GetClient(), stream: stream, maxLen: maxLen}
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event models.DomainEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	err = p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: map[string]any{
+			"id":           event.ID,
+			"type":         event.Type,
+			"aggregate_id": event.AggregateID,
+			"payload":      payload,
+			"occurred_at":  event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package geo
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// NoopLocator is used when no IP database is configured. Every address resolves
+// to an empty location, which emails render as "Unknown location".
+type NoopLocator struct{}
+
+func (NoopLocator) Locate(ctx context.Context, ip string) (string, error) {
+	return "", nil
+}
+
+type ipRange struct {
+	start    [16]byte
+	end      [16]byte
+	location string
+}
+
+// CSVLocator resolves addresses with an offline IP range database in the
+// DB-IP "lite" CSV layout. Both the country edition (start,end,country) and
+// the city edition (start,end,continent,country,region,city,...) are accepted.
+type CSVLocator struct {
+	ranges []ipRange
+}
+
+// LoadCSVLocator reads an IP range database into memory
+func LoadCSVLocator(path string) (*CSVLocator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IP database: %w", err)
+	}
+	defer f.Close()
+
+	locator, err := ReadCSVLocator(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IP database %s: %w", path, err)
+	}
+	return locator, nil
+}
+
+func ReadCSVLocator(r io.Reader) (*CSVLocator, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	locator := &CSVLocator{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("malformed IP range %q", strings.Join(record, ","))
+		}
+
+		start, okStart := to16(record[0])
+		end, okEnd := to16(record[1])
+		if !okStart || !okEnd {
+			return nil, fmt.Errorf("malformed IP range %q", strings.Join(record, ","))
+		}
+		locator.ranges = append(locator.ranges, ipRange{start: start, end: end, location: describe(record)})
+	}
+
+	sort.Slice(locator.ranges, func(i, j int) bool {
+		return bytes.Compare(locator.ranges[i].start[:], locator.ranges[j].start[:]) < 0
+	})
+	return locator, nil
+}
+
+func (l *CSVLocator) Locate(ctx context.Context, ip string) (string, error) {
+	addr, ok := to16(ip)
+	if !ok {
+		return "", nil
+	}
+	// find the last range starting at or before the address
+	i := sort.Search(len(l.ranges), func(i int) bool {
+		return bytes.Compare(l.ranges[i].start[:], addr[:]) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(addr[:], l.ranges[i].end[:]) > 0 {
+		return "", nil
+	}
+	return l.ranges[i].location, nil
+}
+
+// describe formats a record as "City, Region, CC" or just the country code
+func describe(record []string) string {
+	if len(record) < 6 {
+		return record[2]
+	}
+	parts := make([]string, 0, 3)
+	for _, p := range []string{record[5], record[4], record[3]} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// to16 parses an address into its 16 byte form, so IPv4 and IPv6 sort together
+func to16(s string) ([16]byte, bool) {
+	var out [16]byte
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return out, false
+	}
+	copy(out[:], ip.To16())
+	return out, true
+}
@@ -0,0 +1,253 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+// Capture storage backends
+const (
+	CaptureMemory = "memory"
+	CaptureDisk   = "disk"
+)
+
+const defaultCaptureMaxMessages = 200
+
+// CaptureConfig configures the development mailbox
+type CaptureConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Storage     string `mapstructure:"storage" validate:"omitempty,oneof=memory disk"`
+	Dir         string `mapstructure:"dir" validate:"required_if=Storage disk"` // where disk storage keeps messages
+	MaxMessages int    `mapstructure:"max_messages"`                            // oldest messages are dropped beyond this
+}
+
+// CaptureMailer renders emails like the SMTP mailer but keeps them in a
+// mailbox instead of sending them. It is meant for local development only.
+type CaptureMailer struct {
+	templates *Templates
+	store     captureStore
+}
+
+// captureStore keeps captured messages, newest first
+type captureStore interface {
+	save(mail *models.CapturedMail) error
+	list() ([]*models.CapturedMail, error)
+	get(id string) (*models.CapturedMail, error)
+	clear() error
+}
+
+func NewCaptureMailer(config CaptureConfig, templates *Templates) (*CaptureMailer, error) {
+	maxMessages := orDefault(config.MaxMessages, defaultCaptureMaxMessages)
+
+	var store captureStore
+	switch config.Storage {
+	case "", CaptureMemory:
+		store = &memoryCaptureStore{max: maxMessages}
+	case CaptureDisk:
+		if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create mailbox directory: %w", err)
+		}
+		store = &diskCaptureStore{dir: config.Dir, max: maxMessages}
+	default:
+		return nil, fmt.Errorf("unknown mailbox storage %q", config.Storage)
+	}
+	return &CaptureMailer{templates: templates, store: store}, nil
+}
+
+func (m *CaptureMailer) SendVerificationEmail(_ context.Context, to models.Recipient, verificationURL string) error {
+	return m.capture(to, TemplateVerification, LinkData{Name: to.Name, URL: verificationURL})
+}
+
+func (m *CaptureMailer) SendPasswordResetEmail(_ context.Context, to models.Recipient, resetURL string) error {
+	return m.capture(to, TemplatePasswordReset, LinkData{Name: to.Name, URL: resetURL})
+}
+
+func (m *CaptureMailer) SendNewDeviceEmail(_ context.Context, to models.Recipient, notice models.NewDeviceNotice) error {
+	notice.LoginAt = inTimezone(notice.LoginAt, to.Timezone)
+	return m.capture(to, TemplateNewDevice, NewDeviceData{Name: to.Name, Notice: notice})
+}
+
+func (m *CaptureMailer) List(_ context.Context) ([]*models.CapturedMail, error) {
+	return m.store.list()
+}
+
+func (m *CaptureMailer) Get(_ context.Context, id string) (*models.CapturedMail, error) {
+	return m.store.get(id)
+}
+
+func (m *CaptureMailer) Clear(_ context.Context) error {
+	return m.store.clear()
+}
+
+func (m *CaptureMailer) capture(to models.Recipient, template string, data any) error {
+	msg, err := m.templates.Render(template, to.Locale, data)
+	if err != nil {
+		return err
+	}
+	return m.store.save(&models.CapturedMail{
+		ID:        uuid.New().String(),
+		Kind:      template,
+		To:        to,
+		Subject:   msg.Subject,
+		HTML:      msg.HTML,
+		Text:      msg.Text,
+		Links:     extractLinks(msg.HTML),
+		CreatedAt: time.Now(),
+	})
+}
+
+// memoryCaptureStore keeps messages until the process exits
+type memoryCaptureStore struct {
+	mu       sync.RWMutex
+	messages []*models.CapturedMail
+	max      int
+}
+
+func (s *memoryCaptureStore) save(mail *models.CapturedMail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append([]*models.CapturedMail{mail}, s.messages...)
+	if len(s.messages) > s.max {
+		s.messages = s.messages[:s.max]
+	}
+	return nil
+}
+
+func (s *memoryCaptureStore) list() ([]*models.CapturedMail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.messages), nil
+}
+
+func (s *memoryCaptureStore) get(id string) (*models.CapturedMail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, mail := range s.messages {
+		if mail.ID == id {
+			return mail, nil
+		}
+	}
+	return nil, models.ErrNotFound
+}
+
+func (s *memoryCaptureStore) clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	return nil
+}
+
+// diskCaptureStore keeps one JSON file per message, so the mailbox survives
+// restarts and can be shared by several local instances
+type diskCaptureStore struct {
+	mu  sync.Mutex
+	dir string
+	max int
+}
+
+func (s *diskCaptureStore) save(mail *models.CapturedMail) error {
+	data, err := json.MarshalIndent(mail, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the timestamp prefix keeps file names in capture order
+	name := fmt.Sprintf("%020d-%s.json", mail.CreatedAt.UnixNano(), mail.ID)
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return err
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files[min(len(files), s.max):] {
+		os.Remove(file)
+	}
+	return nil
+}
+
+func (s *diskCaptureStore) list() ([]*models.CapturedMail, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	mails := make([]*models.CapturedMail, 0, len(files))
+	for _, file := range files {
+		mail, err := readCapturedMail(file)
+		if err != nil {
+			continue // skip files removed or half written meanwhile
+		}
+		mails = append(mails, mail)
+	}
+	return mails, nil
+}
+
+func (s *diskCaptureStore) get(id string) (*models.CapturedMail, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, models.ErrNotFound
+	}
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*-"+id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, models.ErrNotFound
+	}
+	return readCapturedMail(matches[0])
+}
+
+func (s *diskCaptureStore) clear() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// files lists the message files, newest first
+func (s *diskCaptureStore) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	slices.Sort(files)
+	slices.Reverse(files)
+	return files, nil
+}
+
+func readCapturedMail(file string) (*models.CapturedMail, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var mail models.CapturedMail
+	if err := json.Unmarshal(data, &mail); err != nil {
+		return nil, err
+	}
+	return &mail, nil
+}
@@ -0,0 +1,146 @@
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders are signed when present, in this order
+var dkimSignedHeaders = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}
+
+// DKIMConfig configures DKIM signing. Signing is disabled without a domain.
+type DKIMConfig struct {
+	Domain         string `mapstructure:"domain"`
+	Selector       string `mapstructure:"selector" validate:"required_with=Domain"`
+	PrivateKeyPath string `mapstructure:"private_key_path" validate:"required_with=Domain"` // PEM encoded RSA key, PKCS#1 or PKCS#8
+}
+
+// DKIMSigner signs messages with rsa-sha256 and relaxed/relaxed canonicalization (RFC 6376)
+type DKIMSigner struct {
+	domain   string
+	selector string
+	key      *rsa.PrivateKey
+}
+
+// NewDKIMSigner loads the signing key. It returns nil when DKIM is not configured.
+func NewDKIMSigner(config DKIMConfig) (*DKIMSigner, error) {
+	if config.Domain == "" {
+		return nil, nil
+	}
+	if config.Selector == "" {
+		return nil, errors.New("dkim selector is required")
+	}
+	data, err := os.ReadFile(config.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dkim key: %w", err)
+	}
+	key, err := parseRSAPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMSigner{domain: config.Domain, selector: config.Selector, key: key}, nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("dkim key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dkim key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("dkim key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Sign returns the DKIM-Signature header for the message
+func (s *DKIMSigner) Sign(headers []header, body []byte, now time.Time) (header, error) {
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	signed := make([]string, 0, len(dkimSignedHeaders))
+	hash := sha256.New()
+	for _, name := range dkimSignedHeaders {
+		for _, h := range headers {
+			if strings.EqualFold(h.name, name) {
+				hash.Write([]byte(relaxedHeader(h.name, h.value) + "\r\n"))
+				signed = append(signed, strings.ToLower(name))
+				break
+			}
+		}
+	}
+
+	value := fmt.Sprintf("v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.domain, s.selector, now.Unix(), strings.Join(signed, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	// the signature header itself is signed with an empty b= and no trailing CRLF
+	hash.Write([]byte(relaxedHeader("DKIM-Signature", value)))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash.Sum(nil))
+	if err != nil {
+		return header{}, fmt.Errorf("failed to sign message: %w", err)
+	}
+	return header{name: "DKIM-Signature", value: value + base64.StdEncoding.EncodeToString(signature)}, nil
+}
+
+// relaxedHeader canonicalizes a header: lowercase name, unfolded value with
+// whitespace runs collapsed and trimmed
+func relaxedHeader(name, value string) string {
+	value = strings.NewReplacer("\r\n", "", "\n", "").Replace(value)
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.Join(strings.Fields(value), " ")
+}
+
+// relaxedBody canonicalizes a body: trailing whitespace removed from every
+// line, whitespace runs collapsed and trailing empty lines dropped
+func relaxedBody(body []byte) []byte {
+	lines := bytes.Split(body, []byte("\r\n"))
+	var out bytes.Buffer
+	blank := 0
+	for _, line := range lines {
+		line = collapseWhitespace(bytes.TrimRight(line, " \t"))
+		if len(line) == 0 {
+			blank++
+			continue
+		}
+		for ; blank > 0; blank-- {
+			out.WriteString("\r\n")
+		}
+		out.Write(line)
+		out.WriteString("\r\n")
+	}
+	return out.Bytes()
+}
+
+func collapseWhitespace(line []byte) []byte {
+	out := make([]byte, 0, len(line))
+	space := false
+	for _, b := range line {
+		if b == ' ' || b == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			out = append(out, ' ')
+			space = false
+		}
+		out = append(out, b)
+	}
+	return out
+}
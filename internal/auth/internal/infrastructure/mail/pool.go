@@ -0,0 +1,160 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// TLS modes of the SMTP connection
+const (
+	TLSImplicit      = "implicit"      // TLS from the first byte, usually port 465
+	TLSStartTLS      = "starttls"      // upgrade with STARTTLS, fail if the server does not offer it
+	TLSOpportunistic = "opportunistic" // upgrade with STARTTLS when offered
+	TLSNone          = "none"          // plaintext, only for local development servers
+)
+
+var (
+	errStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+	errAuthUnsupported     = errors.New("smtp server does not support AUTH")
+)
+
+// conn is a pooled SMTP session
+type conn struct {
+	client   *smtp.Client
+	lastUsed time.Time
+	reused   bool // taken from the pool rather than freshly dialed
+}
+
+// pool keeps authenticated SMTP sessions open for reuse. Idle sessions older
+// than idleTimeout are closed instead of reused, as servers drop them anyway.
+type pool struct {
+	addr        string
+	host        string
+	heloName    string
+	tlsMode     string
+	tlsConfig   *tls.Config
+	auth        smtp.Auth
+	dialTimeout time.Duration
+	idleTimeout time.Duration
+	maxIdle     int
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// get returns an idle session that still answers, or dials a new one
+func (p *pool) get() (*conn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errors.New("smtp pool is closed")
+		}
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			return p.dial()
+		}
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		if time.Since(c.lastUsed) < p.idleTimeout && c.client.Reset() == nil {
+			c.reused = true
+			return c, nil
+		}
+		c.client.Close()
+	}
+}
+
+// put returns a healthy session to the pool, closing it when the pool is full
+func (p *pool) put(c *conn) {
+	c.lastUsed = time.Now()
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.maxIdle {
+		p.idle = append(p.idle, c)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	c.client.Quit()
+}
+
+// discard drops a session after an error left it in an unknown state
+func (p *pool) discard(c *conn) {
+	c.client.Close()
+}
+
+func (p *pool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.client.Quit()
+	}
+}
+
+func (p *pool) dial() (*conn, error) {
+	dialer := &net.Dialer{Timeout: p.dialTimeout}
+
+	var (
+		netConn net.Conn
+		err     error
+	)
+	if p.tlsMode == TLSImplicit {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", p.addr, p.tlsConfig)
+	} else {
+		netConn, err = dialer.Dial("tcp", p.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+
+	client, err := smtp.NewClient(netConn, p.host)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	if err := p.setup(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &conn{client: client, lastUsed: time.Now()}, nil
+}
+
+// setup greets the server, negotiates TLS and authenticates
+func (p *pool) setup(client *smtp.Client) error {
+	if err := client.Hello(p.heloName); err != nil {
+		return fmt.Errorf("smtp greeting failed: %w", err)
+	}
+
+	if p.tlsMode == TLSStartTLS || p.tlsMode == TLSOpportunistic {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(p.tlsConfig); err != nil {
+				return fmt.Errorf("smtp STARTTLS failed: %w", err)
+			}
+		} else if p.tlsMode == TLSStartTLS {
+			return errStartTLSUnsupported
+		}
+	}
+
+	// with credentials configured, sending unauthenticated would only fail
+	// later, or worse, be relayed anyway by a misconfigured server
+	if p.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errAuthUnsupported
+		}
+		if err := client.Auth(p.auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+	return nil
+}
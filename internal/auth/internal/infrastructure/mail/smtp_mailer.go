@@ -1,74 +1,255 @@
 package mail
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"html/template"
+	"net"
+	netmail "net/mail"
 	"net/smtp"
+	"os"
+	"strconv"
+	"time"
 
-	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
 )
 
+// Email template names
+const (
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+	TemplateNewDevice     = "new_device"
+)
+
+const (
+	defaultPoolSize    = 4
+	defaultDialTimeout = 10 * time.Second
+	defaultIdleTimeout = 30 * time.Second
+)
+
+// SMTPConfig configures the SMTP transport
+type SMTPConfig struct {
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     int    `mapstructure:"port" validate:"required"`
+	Username string `mapstructure:"username" validate:"required"`
+	Password string `mapstructure:"password" validate:"required" secret:"true"`
+	From     string `mapstructure:"from" validate:"required"` // address, optionally with a display name: "myFolio <no-reply@myfolio.dev>"
+
+	// TLSMode is implicit, starttls, opportunistic or none. Empty selects
+	// implicit on port 465 and starttls otherwise.
+	TLSMode     string        `mapstructure:"tls_mode" validate:"omitempty,oneof=implicit starttls opportunistic none"`
+	CAFile      string        `mapstructure:"ca_file"` // PEM bundle trusted in addition to the system roots
+	HeloName    string        `mapstructure:"helo_name"`
+	PoolSize    int           `mapstructure:"pool_size"` // idle connections kept open for reuse
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"` // idle connections older than this are not reused
+
+	DKIM DKIMConfig `mapstructure:"dkim"`
+}
+
 type SMTPMailer struct {
-	host     string
-	port     int
-	username string
-	password string
-	from     string
+	from      *netmail.Address
+	pool      *pool
+	dkim      *DKIMSigner
+	templates *Templates
 }
 
-func NewSMTPMailer(host string, port int, username, password, from string) ports.Mailer {
+// NewSMTPMailer creates a mailer that keeps a small pool of SMTP connections
+// open. Close releases them.
+func NewSMTPMailer(config SMTPConfig, templates *Templates) (*SMTPMailer, error) {
+	from, err := netmail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+
+	tlsMode := config.TLSMode
+	if tlsMode == "" {
+		tlsMode = TLSStartTLS
+		if config.Port == 465 {
+			tlsMode = TLSImplicit
+		}
+	}
+	tlsConfig := &tls.Config{ServerName: config.Host, MinVersion: tls.VersionTLS12}
+	if config.CAFile != "" {
+		roots, err := loadCertPool(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	dkim, err := NewDKIMSigner(config.DKIM)
+	if err != nil {
+		return nil, err
+	}
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	heloName := config.HeloName
+	if heloName == "" {
+		heloName = "localhost"
+	}
+
 	return &SMTPMailer{
-		host:     host,
-		port:     port,
-		username: username,
-		password: password,
-		from:     from,
-	}
-}
-
-func (m *SMTPMailer) SendVerificationEmail(email, name, verificationURL string) error {
-	// Load email template
-	tmpl, err := template.New("verification").Parse(`
-    <html>
-    <body>
-        <h1>Welcome, {{.Name}}!</h1>
-        <p>Please verify your email by clicking the link below:</p>
-        <a href="{{.URL}}">Verify Email</a>
-        <p>This link will expire in 24 hours.</p>
-    </body>
-    </html>
-    `)
+		from: from,
+		pool: &pool{
+			addr:        net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+			host:        config.Host,
+			heloName:    heloName,
+			tlsMode:     tlsMode,
+			tlsConfig:   tlsConfig,
+			auth:        auth,
+			dialTimeout: orDefault(config.DialTimeout, defaultDialTimeout),
+			idleTimeout: orDefault(config.IdleTimeout, defaultIdleTimeout),
+			maxIdle:     orDefault(config.PoolSize, defaultPoolSize),
+		},
+		dkim:      dkim,
+		templates: templates,
+	}, nil
+}
+
+// LinkData is the template data of emails built around a single link
+type LinkData struct {
+	Name string
+	URL  string
+}
+
+// NewDeviceData is the template data of new-device emails
+type NewDeviceData struct {
+	Name   string
+	Notice models.NewDeviceNotice
+}
+
+func (m *SMTPMailer) SendVerificationEmail(_ context.Context, to models.Recipient, verificationURL string) error {
+	return m.send(to, TemplateVerification, LinkData{Name: to.Name, URL: verificationURL})
+}
+
+func (m *SMTPMailer) SendPasswordResetEmail(_ context.Context, to models.Recipient, resetURL string) error {
+	return m.send(to, TemplatePasswordReset, LinkData{Name: to.Name, URL: resetURL})
+}
+
+func (m *SMTPMailer) SendNewDeviceEmail(_ context.Context, to models.Recipient, notice models.NewDeviceNotice) error {
+	notice.LoginAt = inTimezone(notice.LoginAt, to.Timezone)
+	return m.send(to, TemplateNewDevice, NewDeviceData{Name: to.Name, Notice: notice})
+}
+
+// Close closes the pooled connections
+func (m *SMTPMailer) Close() error {
+	m.pool.close()
+	return nil
+}
+
+// send renders a template in the recipient's locale and delivers it
+func (m *SMTPMailer) send(to models.Recipient, template string, data any) error {
+	msg, err := m.templates.Render(template, to.Locale, data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	recipient := &netmail.Address{Name: to.Name, Address: to.Email}
+	headers, body, err := compose(m.from, recipient, msg, now)
+	if err != nil {
+		return fmt.Errorf("failed to compose email: %w", err)
+	}
+	if m.dkim != nil {
+		signature, err := m.dkim.Sign(headers, body, now)
+		if err != nil {
+			return err
+		}
+		headers = append([]header{signature}, headers...)
+	}
+	return m.deliver(to.Email, encode(headers, body))
+}
+
+// deliver sends a message over a pooled connection. A pooled connection the
+// server has silently dropped fails before DATA and the message is resent on a
+// fresh one. Failures from DATA on are not retried, as the server may already
+// have accepted the message.
+func (m *SMTPMailer) deliver(to string, raw []byte) error {
+	c, err := m.pool.get()
+	if err != nil {
+		return err
+	}
+	err = envelope(c.client, m.from.Address, to)
+	if err != nil && c.reused {
+		m.pool.discard(c)
+		if c, err = m.pool.dial(); err != nil {
+			return err
+		}
+		err = envelope(c.client, m.from.Address, to)
+	}
+	if err == nil {
+		err = transmit(c.client, raw)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	var body bytes.Buffer
-	if err := tmpl.Execute(&body, struct {
-		Name string
-		URL  string
-	}{
-		Name: name,
-		URL:  verificationURL,
-	}); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	auth := smtp.PlainAuth("", m.username, m.password, m.host)
-	to := []string{email}
-	msg := []byte(fmt.Sprintf(
-		"To: %s\r\n"+
-			"From: %s\r\n"+
-			"Subject: Verify Your Email\r\n"+
-			"MIME-Version: 1.0\r\n"+
-			"Content-Type: text/html; charset=UTF-8\r\n\r\n%s",
-		email, m.from, body.String()))
-
-	return smtp.SendMail(
-		fmt.Sprintf("%s:%d", m.host, m.port),
-		auth,
-		m.from,
-		to,
-		msg,
-	)
+		m.pool.discard(c)
+		return err
+	}
+	m.pool.put(c)
+	return nil
+}
+
+// envelope names the sender and recipient of the next message
+func envelope(client *smtp.Client, from, to string) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+	return nil
+}
+
+// transmit sends the message of an envelope
+func transmit(client *smtp.Client, raw []byte) error {
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+	return nil
+}
+
+// loadCertPool trusts the certificates in caFile on top of the system roots
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read smtp ca file: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return roots, nil
+}
+
+// inTimezone shows a time in the recipient's timezone, or UTC when it is unknown
+func inTimezone(t time.Time, timezone string) time.Time {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return t.In(loc)
+		}
+	}
+	return t.UTC()
+}
+
+func orDefault[T comparable](value, fallback T) T {
+	var zero T
+	if value == zero {
+		return fallback
+	}
+	return value
 }
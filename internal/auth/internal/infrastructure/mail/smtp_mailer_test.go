@@ -0,0 +1,554 @@
+package mail
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+var testRecipient = models.Recipient{Email: "ada@example.com", Name: "Ada"}
+
+func TestSMTPMailerTLSModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		implicit bool // the server speaks TLS from the first byte
+		startTLS bool // the server offers STARTTLS
+		mode     string
+		wantTLS  bool
+		wantErr  error
+	}{
+		{name: "implicit", implicit: true, mode: TLSImplicit, wantTLS: true},
+		{name: "starttls offered", startTLS: true, mode: TLSStartTLS, wantTLS: true},
+		{name: "starttls required but not offered", mode: TLSStartTLS, wantErr: errStartTLSUnsupported},
+		{name: "opportunistic offered", startTLS: true, mode: TLSOpportunistic, wantTLS: true},
+		{name: "opportunistic not offered", mode: TLSOpportunistic},
+		{name: "none", startTLS: true, mode: TLSNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startSMTPServer(t, tt.implicit, tt.startTLS)
+			mailer := newTestSMTPMailer(t, server, tt.mode, DKIMConfig{})
+
+			err := mailer.SendVerificationEmail(context.Background(), testRecipient, "https://myfolio.dev/verify?token=abc")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("send: got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			sessions := server.snapshot()
+			if len(sessions) != 1 || len(sessions[0].messages) != 1 {
+				t.Fatalf("got %d sessions, want one delivering one message", len(sessions))
+			}
+			if sessions[0].tls != tt.wantTLS {
+				t.Errorf("session TLS = %v, want %v", sessions[0].tls, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerRequiresAuthWithCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		offered  bool // the server offers AUTH
+		username string
+		wantAuth bool
+		wantErr  error
+	}{
+		{name: "offered", offered: true, username: "mailer", wantAuth: true},
+		{name: "not offered", username: "mailer", wantErr: errAuthUnsupported},
+		{name: "no credentials", offered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startSMTPServer(t, false, false)
+			server.auth = tt.offered
+			mailer := newTestSMTPMailer(t, server, TLSNone, DKIMConfig{})
+			if tt.username != "" {
+				mailer.pool.auth = smtp.PlainAuth("", tt.username, "secret", "127.0.0.1")
+			}
+
+			err := mailer.SendVerificationEmail(context.Background(), testRecipient, "https://myfolio.dev/verify?token=abc")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("send: got error %v, want %v", err, tt.wantErr)
+			}
+			sessions := server.snapshot()
+			if len(sessions) != 1 {
+				t.Fatalf("got %d sessions, want 1", len(sessions))
+			}
+			if authenticated := sessions[0].count("AUTH") == 1; authenticated != tt.wantAuth {
+				t.Errorf("authenticated = %v, want %v", authenticated, tt.wantAuth)
+			}
+			if delivered := len(sessions[0].messages) == 1; delivered != (tt.wantErr == nil) {
+				t.Errorf("delivered = %v despite error %v", delivered, err)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerReusesPooledConnection(t *testing.T) {
+	server := startSMTPServer(t, false, true)
+	mailer := newTestSMTPMailer(t, server, TLSStartTLS, DKIMConfig{})
+
+	for i := 0; i < 3; i++ {
+		if err := mailer.SendPasswordResetEmail(context.Background(), testRecipient, "https://myfolio.dev/reset"); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+
+	sessions := server.snapshot()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want the pooled one reused", len(sessions))
+	}
+	if got := len(sessions[0].messages); got != 3 {
+		t.Errorf("got %d messages, want 3", got)
+	}
+	if got := sessions[0].count("RSET"); got != 2 {
+		t.Errorf("got %d RSET commands, want one per reuse", got)
+	}
+}
+
+func TestSMTPMailerRetriesDroppedConnection(t *testing.T) {
+	server := startSMTPServer(t, false, false)
+	// the server drops the session after answering RSET, as it would an idle one
+	server.drop = func(cmd string, delivered int) bool { return cmd == "MAIL" && delivered > 0 }
+	mailer := newTestSMTPMailer(t, server, TLSNone, DKIMConfig{})
+
+	for i := 0; i < 2; i++ {
+		if err := mailer.SendPasswordResetEmail(context.Background(), testRecipient, "https://myfolio.dev/reset"); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+
+	sessions := server.snapshot()
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want a fresh one after the drop", len(sessions))
+	}
+	for i, session := range sessions {
+		if got := len(session.messages); got != 1 {
+			t.Errorf("session %d delivered %d messages, want 1", i, got)
+		}
+	}
+}
+
+func TestSMTPMailerDoesNotRetryAfterData(t *testing.T) {
+	server := startSMTPServer(t, false, false)
+	// the server drops the session after receiving the message but before accepting it
+	server.drop = func(cmd string, delivered int) bool { return cmd == "." && delivered > 1 }
+	mailer := newTestSMTPMailer(t, server, TLSNone, DKIMConfig{})
+
+	ctx := context.Background()
+	if err := mailer.SendPasswordResetEmail(ctx, testRecipient, "https://myfolio.dev/reset"); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	if err := mailer.SendPasswordResetEmail(ctx, testRecipient, "https://myfolio.dev/reset"); err == nil {
+		t.Fatal("second send succeeded, want the dropped session reported")
+	}
+
+	sessions := server.snapshot()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want no retry that could deliver the message twice", len(sessions))
+	}
+}
+
+func TestSMTPMailerEncodesSubjectAndSignsDKIM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "dkim.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := startSMTPServer(t, false, false)
+	mailer := newTestSMTPMailer(t, server, TLSNone, DKIMConfig{
+		Domain:         "myfolio.dev",
+		Selector:       "mail",
+		PrivateKeyPath: keyPath,
+	})
+
+	to := testRecipient
+	to.Locale = "bn"
+	if err := mailer.SendPasswordResetEmail(context.Background(), to, "https://myfolio.dev/reset"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	sessions := server.snapshot()
+	if len(sessions) != 1 || len(sessions[0].messages) != 1 {
+		t.Fatal("message not delivered")
+	}
+	raw := sessions[0].messages[0]
+
+	headers, _ := splitMessage(raw)
+	subject := lastHeader(headers, "Subject")
+	if !strings.HasPrefix(subject, "=?UTF-8?q?") {
+		t.Errorf("Subject %q is not RFC 2047 encoded", subject)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	if want := "আপনার পাসওয়ার্ড রিসেট করুন"; decoded != want {
+		t.Errorf("Subject decodes to %q, want %q", decoded, want)
+	}
+
+	if err := verifyDKIM(raw, &key.PublicKey); err != nil {
+		t.Errorf("DKIM-Signature does not verify: %v", err)
+	}
+}
+
+func newTestSMTPMailer(t *testing.T, server *smtpServer, tlsMode string, dkim DKIMConfig) *SMTPMailer {
+	t.Helper()
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+
+	templates, err := NewTemplates("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer, err := NewSMTPMailer(SMTPConfig{
+		Host:    host,
+		Port:    portNumber,
+		From:    "myFolio <no-reply@myfolio.dev>",
+		TLSMode: tlsMode,
+		CAFile:  server.caFile,
+		DKIM:    dkim,
+	}, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mailer.Close() })
+	return mailer
+}
+
+// smtpServer is an in-process SMTP server recording what it receives
+type smtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	caFile    string // PEM of the self-signed certificate the server presents
+	startTLS  bool
+	auth      bool // the server offers AUTH PLAIN
+
+	// drop, when set, closes the connection instead of answering cmd, given
+	// the messages the session has received so far
+	drop func(cmd string, delivered int) bool
+
+	mu       sync.Mutex
+	sessions []*smtpSession
+}
+
+type smtpSession struct {
+	tls      bool
+	commands []string
+	messages [][]byte
+}
+
+func (s *smtpSession) count(cmd string) int {
+	n := 0
+	for _, c := range s.commands {
+		if c == cmd {
+			n++
+		}
+	}
+	return n
+}
+
+func startSMTPServer(t *testing.T, implicit, startTLS bool) *smtpServer {
+	t.Helper()
+	cert, caFile := selfSignedCertificate(t)
+	server := &smtpServer{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		caFile:    caFile,
+		startTLS:  startTLS,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if implicit {
+		listener = tls.NewListener(listener, server.tlsConfig)
+	}
+	server.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			session := &smtpSession{tls: implicit}
+			server.mu.Lock()
+			server.sessions = append(server.sessions, session)
+			server.mu.Unlock()
+			go server.serve(conn, session)
+		}
+	}()
+	return server
+}
+
+// snapshot copies the sessions so far
+func (s *smtpServer) snapshot() []smtpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]smtpSession, len(s.sessions))
+	for i, session := range s.sessions {
+		sessions[i] = *session
+		sessions[i].commands = append([]string(nil), session.commands...)
+		sessions[i].messages = append([][]byte(nil), session.messages...)
+	}
+	return sessions
+}
+
+func (s *smtpServer) serve(conn net.Conn, session *smtpSession) {
+	defer func() { conn.Close() }()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 localhost ESMTP test") {
+		return
+	}
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+		s.mu.Lock()
+		session.commands = append(session.commands, cmd)
+		delivered := len(session.messages)
+		s.mu.Unlock()
+		if s.drop != nil && s.drop(cmd, delivered) {
+			return
+		}
+
+		switch cmd {
+		case "EHLO":
+			extensions := []string{"localhost"}
+			if s.startTLS && !session.tls {
+				extensions = append(extensions, "STARTTLS")
+			}
+			if s.auth {
+				extensions = append(extensions, "AUTH PLAIN")
+			}
+			for i, extension := range extensions {
+				if i == len(extensions)-1 {
+					reply("250 %s", extension)
+				} else {
+					reply("250-%s", extension)
+				}
+			}
+		case "HELO":
+			reply("250 localhost")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			s.mu.Lock()
+			session.tls = true
+			s.mu.Unlock()
+		case "AUTH":
+			reply("235 authenticated")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(text.Reader)
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			session.messages = append(session.messages, data)
+			delivered = len(session.messages)
+			s.mu.Unlock()
+			if s.drop != nil && s.drop(".", delivered) {
+				return
+			}
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// readData reads a message up to the lone dot, keeping its CRLF line endings
+func readData(r textproto.Reader) ([]byte, error) {
+	var data []byte
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "." {
+			return data, nil
+		}
+		line = strings.TrimPrefix(line, ".")
+		data = append(data, line+"\r\n"...)
+	}
+}
+
+// selfSignedCertificate creates a certificate for 127.0.0.1 and writes it to a PEM file
+func selfSignedCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "smtp test"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+// splitMessage returns the unfolded header fields, name and value as sent,
+// and the body of a raw message
+func splitMessage(raw []byte) ([][2]string, []byte) {
+	head, body, _ := strings.Cut(string(raw), "\r\n\r\n")
+	var fields [][2]string
+	for _, line := range strings.Split(head, "\r\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(fields) > 0 {
+			fields[len(fields)-1][1] += "\r\n" + line
+			continue
+		}
+		name, value, _ := strings.Cut(line, ":")
+		fields = append(fields, [2]string{name, value})
+	}
+	return fields, []byte(body)
+}
+
+// lastHeader is the trimmed value of the last field named name
+func lastHeader(fields [][2]string, name string) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.EqualFold(fields[i][0], name) {
+			return strings.TrimSpace(fields[i][1])
+		}
+	}
+	return ""
+}
+
+var (
+	wsp          = regexp.MustCompile(`[ \t]+`)
+	dkimSigValue = regexp.MustCompile(`(^|;)(\s*b=)[^;]*`)
+)
+
+// verifyDKIM checks a relaxed/relaxed rsa-sha256 signature as a receiving
+// server would (RFC 6376 section 3.4 and 6.1), independently of the signer
+func verifyDKIM(raw []byte, key *rsa.PublicKey) error {
+	fields, body := splitMessage(raw)
+	signature := lastHeader(fields, "DKIM-Signature")
+	if signature == "" {
+		return errors.New("no DKIM-Signature header")
+	}
+	tags := map[string]string{}
+	for _, tag := range strings.Split(signature, ";") {
+		name, value, _ := strings.Cut(tag, "=")
+		tags[strings.TrimSpace(name)] = strings.Join(strings.Fields(value), "")
+	}
+	if tags["a"] != "rsa-sha256" || tags["c"] != "relaxed/relaxed" {
+		return fmt.Errorf("unexpected algorithm %q or canonicalization %q", tags["a"], tags["c"])
+	}
+
+	// body: whitespace runs collapsed, trailing whitespace and empty lines removed
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(wsp.ReplaceAllString(line, " "), " ")
+	}
+	canonicalBody := strings.TrimRight(strings.Join(lines, "\r\n"), "\r\n")
+	if canonicalBody != "" {
+		canonicalBody += "\r\n"
+	}
+	bodyHash := sha256.Sum256([]byte(canonicalBody))
+	if got := base64.StdEncoding.EncodeToString(bodyHash[:]); got != tags["bh"] {
+		return fmt.Errorf("body hash %s, signed %s", got, tags["bh"])
+	}
+
+	relaxed := func(name, value string) string {
+		value = strings.NewReplacer("\r\n", "").Replace(value)
+		return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(wsp.ReplaceAllString(value, " "))
+	}
+	hash := sha256.New()
+	used := map[string]int{}
+	for _, name := range strings.Split(tags["h"], ":") {
+		// repeated names select instances from the bottom up
+		seen := 0
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !strings.EqualFold(fields[i][0], name) || strings.EqualFold(name, "DKIM-Signature") {
+				continue
+			}
+			if seen == used[name] {
+				hash.Write([]byte(relaxed(fields[i][0], fields[i][1]) + "\r\n"))
+				break
+			}
+			seen++
+		}
+		used[name]++
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field[0], "DKIM-Signature") {
+			hash.Write([]byte(relaxed(field[0], dkimSigValue.ReplaceAllString(field[1], "$1$2"))))
+			break
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %w", err)
+	}
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash.Sum(nil), sig)
+}
@@ -0,0 +1,195 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when neither the recipient's locale nor its language has templates
+const DefaultLocale = "en"
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Templates renders emails from a template directory laid out as:
+//
+//	layouts/base.html        shared layout, renders the "content" and "footer" blocks
+//	<locale>/footer.html     optional "footer" block for the locale
+//	<locale>/<name>.html     defines the "subject" and "content" blocks of an email
+//	<locale>/<name>.txt      optional hand-written plaintext body
+//
+// When an email has no .txt template its plaintext body is derived from the
+// rendered HTML. Render has no side effects, so emails can be previewed in
+// tests and tools without sending anything.
+type Templates struct {
+	fsys          fs.FS
+	defaultLocale string
+
+	mu    sync.RWMutex
+	cache map[string]*compiled
+}
+
+type compiled struct {
+	subject *texttemplate.Template // a header, so not HTML escaped
+	html    *htmltemplate.Template
+	text    *texttemplate.Template // nil when the text body is derived from HTML
+}
+
+// NewTemplates loads the embedded templates. Files in overrideDir, when set,
+// take precedence over embedded files with the same path, so single emails or
+// the layout can be rebranded and new locales added without rebuilding.
+func NewTemplates(overrideDir, defaultLocale string) (*Templates, error) {
+	embedded, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	var fsys fs.FS = embedded
+	if overrideDir != "" {
+		if info, err := os.Stat(overrideDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("email template directory %q is not readable", overrideDir)
+		}
+		fsys = overlayFS{upper: os.DirFS(overrideDir), lower: embedded}
+	}
+	if defaultLocale == "" {
+		defaultLocale = DefaultLocale
+	}
+	return &Templates{
+		fsys:          fsys,
+		defaultLocale: defaultLocale,
+		cache:         make(map[string]*compiled),
+	}, nil
+}
+
+// Render renders the named email in the best matching locale: the exact
+// locale (bn-BD), then its language (bn), then the default locale
+func (t *Templates) Render(name, locale string, data any) (*Message, error) {
+	locale, err := t.resolve(name, locale)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := t.compile(name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, html bytes.Buffer
+	if err := tmpl.subject.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render subject of %s/%s: %w", locale, name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "base.html", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s/%s: %w", locale, name, err)
+	}
+
+	msg := &Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    html.String(),
+	}
+	if tmpl.text != nil {
+		var text bytes.Buffer
+		if err := tmpl.text.Execute(&text, data); err != nil {
+			return nil, fmt.Errorf("failed to render plaintext of %s/%s: %w", locale, name, err)
+		}
+		msg.Text = text.String()
+	} else {
+		msg.Text = HTMLToText(msg.HTML)
+	}
+	return msg, nil
+}
+
+// resolve picks the first locale that has the named email
+func (t *Templates) resolve(name, locale string) (string, error) {
+	candidates := []string{}
+	if locale != "" {
+		locale = strings.ReplaceAll(locale, "_", "-")
+		candidates = append(candidates, locale)
+		if language, _, found := strings.Cut(locale, "-"); found {
+			candidates = append(candidates, language)
+		}
+	}
+	candidates = append(candidates, t.defaultLocale)
+
+	for _, candidate := range candidates {
+		if t.exists(candidate + "/" + name + ".html") {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no email template %q for locale %q", name, locale)
+}
+
+func (t *Templates) compile(name, locale string) (*compiled, error) {
+	key := locale + "/" + name
+	t.mu.RLock()
+	tmpl, ok := t.cache[key]
+	t.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	funcs := map[string]any{"locale": func() string { return locale }}
+
+	files := []string{"layouts/base.html"}
+	if t.exists(locale + "/footer.html") {
+		files = append(files, locale+"/footer.html")
+	}
+	files = append(files, key+".html")
+
+	html, err := htmltemplate.New(name).Funcs(funcs).ParseFS(t.fsys, files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email template %s: %w", key, err)
+	}
+	subject, err := texttemplate.New(name).Funcs(funcs).ParseFS(t.fsys, key+".html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subject of email template %s: %w", key, err)
+	}
+	tmpl = &compiled{subject: subject, html: html}
+
+	if t.exists(key + ".txt") {
+		text, err := texttemplate.New(name+".txt").Funcs(funcs).ParseFS(t.fsys, key+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s.txt: %w", key, err)
+		}
+		tmpl.text = text
+	}
+
+	t.mu.Lock()
+	t.cache[key] = tmpl
+	t.mu.Unlock()
+	return tmpl, nil
+}
+
+func (t *Templates) exists(path string) bool {
+	_, err := fs.Stat(t.fsys, path)
+	return err == nil
+}
+
+// overlayFS serves files from upper, falling back to lower for missing files
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.Open(name)
+	}
+	return nil, err
+}
@@ -0,0 +1,169 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+func TestTemplatesRenderLocales(t *testing.T) {
+	link := LinkData{Name: "Ada", URL: "https://myfolio.dev/verify?token=abc"}
+	device := NewDeviceData{Name: "Ada", Notice: models.NewDeviceNotice{
+		Device:    "Firefox on Linux",
+		IPAddress: "203.0.113.7",
+		LoginAt:   time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC),
+		ReportURL: "https://myfolio.dev/auth/devices/report?token=xyz",
+	}}
+
+	tests := []struct {
+		name        string
+		template    string
+		locale      string
+		data        any
+		wantSubject string
+		wantHTML    []string
+	}{
+		{"en verification", TemplateVerification, "en", link, "Verify your email", []string{`lang="en"`, "Welcome, Ada!", link.URL}},
+		{"bn verification", TemplateVerification, "bn", link, "আপনার ইমেইল যাচাই করুন", []string{`lang="bn"`, "স্বাগতম, Ada!", link.URL}},
+		{"en password reset", TemplatePasswordReset, "en", link, "Reset your password", []string{"Hi Ada,", link.URL}},
+		{"bn password reset", TemplatePasswordReset, "bn", link, "আপনার পাসওয়ার্ড রিসেট করুন", []string{`lang="bn"`, link.URL}},
+		{"en new device", TemplateNewDevice, "en", device, "New sign-in to your account", []string{"Firefox on Linux", "Oct 19, 2026 08:30 UTC", "Unknown location"}},
+		{"bn new device", TemplateNewDevice, "bn", device, "আপনার অ্যাকাউন্টে নতুন সাইন-ইন", []string{`lang="bn"`, "Firefox on Linux"}},
+	}
+
+	templates, err := NewTemplates("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := templates.Render(tt.template, tt.locale, tt.data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(msg.HTML, want) {
+					t.Errorf("HTML does not contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplatesLocaleFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultLocale string
+		locale        string
+		wantSubject   string
+	}{
+		{"exact locale", "", "bn", "আপনার ইমেইল যাচাই করুন"},
+		{"language of a regional locale", "", "bn-BD", "আপনার ইমেইল যাচাই করুন"},
+		{"underscore separated locale", "", "bn_BD", "আপনার ইমেইল যাচাই করুন"},
+		{"unknown locale", "", "fr", "Verify your email"},
+		{"no locale", "", "", "Verify your email"},
+		{"configured default locale", "bn", "fr", "আপনার ইমেইল যাচাই করুন"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := NewTemplates("", tt.defaultLocale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := templates.Render(TemplateVerification, tt.locale, LinkData{Name: "Ada", URL: "https://myfolio.dev/verify"})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestTemplatesOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "en/verification.html",
+		`{{define "subject"}}Confirm {{.Name}}'s address & start{{end}}{{define "content"}}<p>Rebranded {{.Name}}</p>{{end}}`)
+	writeTemplate(t, dir, "en/verification.txt", "Hand-written for {{.Name}} <{{.URL}}>\n")
+	writeTemplate(t, dir, "de/password_reset.html",
+		`{{define "subject"}}Passwort zurücksetzen{{end}}{{define "content"}}<p>Hallo {{.Name}}</p>{{end}}`)
+
+	templates, err := NewTemplates(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := LinkData{Name: "O'Brien", URL: "https://myfolio.dev/x?a=1&b=2"}
+
+	tests := []struct {
+		name        string
+		template    string
+		locale      string
+		wantSubject string
+		wantHTML    string
+		wantText    string
+	}{
+		// subjects are headers, so & and ' are not HTML escaped
+		{"overridden email", TemplateVerification, "en", "Confirm O'Brien's address & start", "Rebranded O&#39;Brien", "Hand-written for O'Brien <https://myfolio.dev/x?a=1&b=2>\n"},
+		{"embedded email next to overrides", TemplatePasswordReset, "en", "Reset your password", "Hi O&#39;Brien,", ""},
+		{"added locale", TemplatePasswordReset, "de", "Passwort zurücksetzen", `lang="de"`, ""},
+		{"overridden email in another locale", TemplateVerification, "bn", "আপনার ইমেইল যাচাই করুন", "স্বাগতম", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := templates.Render(tt.template, tt.locale, data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(msg.HTML, tt.wantHTML) {
+				t.Errorf("HTML does not contain %q", tt.wantHTML)
+			}
+			if tt.wantText != "" && msg.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", msg.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestTemplatesPlaintextPart(t *testing.T) {
+	templates, err := NewTemplates("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := templates.Render(TemplatePasswordReset, "en", LinkData{Name: "Ada", URL: "https://myfolio.dev/reset?token=abc"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	for _, want := range []string{"Hi Ada,", "https://myfolio.dev/reset?token=abc", "This link will expire in 1 hour."} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Text does not contain %q:\n%s", want, msg.Text)
+		}
+	}
+	for _, unwanted := range []string{"<", "&amp;", "Reset your password"} {
+		if strings.Contains(msg.Text, unwanted) {
+			t.Errorf("Text contains %q:\n%s", unwanted, msg.Text)
+		}
+	}
+}
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
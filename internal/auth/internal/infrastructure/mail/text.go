@@ -0,0 +1,156 @@
+package mail
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements start on a new paragraph in the plaintext rendering
+var blockElements = map[string]bool{
+	"p": true, "div": true, "table": true, "tr": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "hr": true,
+}
+
+// hiddenElements have no visible text
+var hiddenElements = map[string]bool{
+	"head": true, "title": true, "style": true, "script": true,
+}
+
+// HTMLToText renders an HTML email as readable plaintext. Links keep their
+// target after the link text, list items become dashes and paragraphs are
+// separated by blank lines.
+func HTMLToText(document string) string {
+	var (
+		out    strings.Builder
+		hidden int
+		links  []linkState
+	)
+
+	z := html.NewTokenizer(strings.NewReader(document))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return tidyText(out.String())
+
+		case html.TextToken:
+			if hidden > 0 {
+				continue
+			}
+			// Text unescapes in place, so look at the surrounding whitespace first
+			raw := z.Raw()
+			leading, trailing := len(raw) > 0 && isSpace(raw[0]), len(raw) > 0 && isSpace(raw[len(raw)-1])
+			text := strings.Join(strings.Fields(string(z.Text())), " ")
+			if text == "" {
+				continue
+			}
+			if leading {
+				text = " " + text
+			}
+			if trailing {
+				text += " "
+			}
+			out.WriteString(text)
+			if len(links) > 0 {
+				links[len(links)-1].text += text
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			switch {
+			case hiddenElements[tag]:
+				hidden++
+			case tag == "br":
+				out.WriteString("\n")
+			case tag == "li":
+				out.WriteString("\n- ")
+			case tag == "a":
+				link := linkState{}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					if string(key) == "href" {
+						link.href = string(val)
+					}
+				}
+				links = append(links, link)
+			case blockElements[tag]:
+				out.WriteString("\n\n")
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case hiddenElements[tag]:
+				if hidden > 0 {
+					hidden--
+				}
+			case tag == "a" && len(links) > 0:
+				link := links[len(links)-1]
+				links = links[:len(links)-1]
+				text := strings.TrimSpace(link.text)
+				if link.href != "" && link.href != text && !strings.HasPrefix(link.href, "#") {
+					out.WriteString(" (" + link.href + ")")
+				}
+			case blockElements[tag]:
+				out.WriteString("\n\n")
+			}
+		}
+	}
+}
+
+type linkState struct {
+	href string
+	text string
+}
+
+// tidyText trims every line and collapses runs of blank lines into one
+func tidyText(text string) string {
+	lines := strings.Split(text, "\n")
+	tidy := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(tidy) > 0 {
+				tidy = append(tidy, "")
+			}
+			blank = true
+			continue
+		}
+		tidy = append(tidy, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(tidy, "\n")) + "\n"
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
+
+// extractLinks returns the targets of every link in an HTML document, in order
+func extractLinks(document string) []string {
+	links := make([]string, 0)
+	z := html.NewTokenizer(strings.NewReader(document))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" && len(val) > 0 {
+					links = append(links, string(val))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+// DeviceRepository stores known devices in memory
+type DeviceRepository struct {
+	mu      sync.Mutex
+	devices map[string]*models.Device // by ID
+}
+
+func NewDeviceRepository() *DeviceRepository {
+	return &DeviceRepository{devices: make(map[string]*models.Device)}
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, device *models.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.devices[device.ID]; ok {
+		return models.ErrConflict
+	}
+	stored := *device
+	r.devices[device.ID] = &stored
+	return nil
+}
+
+func (r *DeviceRepository) ListByUser(ctx context.Context, userID string) ([]*models.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	devices := make([]*models.Device, 0)
+	for _, device := range r.devices {
+		if device.UserID == userID {
+			copied := *device
+			devices = append(devices, &copied)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].LastSeenAt.After(devices[j].LastSeenAt) })
+	return devices, nil
+}
+
+// Touch updates the last sighting. An existing cookie hash is never replaced.
+func (r *DeviceRepository) Touch(ctx context.Context, id, cookieHash string, seenAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	device, ok := r.devices[id]
+	if !ok {
+		return nil
+	}
+	device.LastSeenAt = seenAt
+	if device.CookieHash == "" {
+		device.CookieHash = cookieHash
+	}
+	return nil
+}
+
+func (r *DeviceRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, device := range r.devices {
+		if device.UserID == userID {
+			delete(r.devices, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+)
+
+// refreshToken is a stored session; the token itself is only kept hashed
+type refreshToken struct {
+	userID    string
+	tenantID  string
+	expiresAt time.Time
+}
+
+// TokenRepository stores refresh tokens in memory
+type TokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]refreshToken // by token hash
+}
+
+func NewTokenRepository() *TokenRepository {
+	return &TokenRepository{tokens: make(map[string]refreshToken)}
+}
+
+// GenerateRefreshToken issues an additional token for the owner of a valid token
+func (r *TokenRepository) GenerateRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.valid(token)
+	if err != nil {
+		return "", err
+	}
+	issued := valueobjects.NewToken()
+	r.tokens[hashToken(issued)] = current
+	return issued.String(), nil
+}
+
+func (r *TokenRepository) StoreRefreshToken(ctx context.Context, userID, tenantID string, token valueobjects.Token, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[hashToken(token)] = refreshToken{userID: userID, tenantID: tenantID, expiresAt: expiresAt}
+	return nil
+}
+
+// VerifyRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) VerifyRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.valid(token)
+	if err != nil {
+		return "", err
+	}
+	return current.userID, nil
+}
+
+// GetRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) GetRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	return r.VerifyRefreshToken(ctx, token)
+}
+
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, token valueobjects.Token) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, hashToken(token))
+	return nil
+}
+
+// RotateRefreshToken replaces a valid token with a new one. The new token
+// keeps the old expiry, so rotation never extends a session, and the
+// organization it is scoped to.
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, oldToken valueobjects.Token) (valueobjects.Token, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.valid(oldToken)
+	if err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	delete(r.tokens, hashToken(oldToken))
+	rotated := valueobjects.NewToken()
+	r.tokens[hashToken(rotated)] = current
+	return rotated, current.tenantID, nil
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, stored := range r.tokens {
+		if stored.userID == userID {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// ListSessions returns the user's unexpired refresh tokens, identified by a
+// fingerprint of the token hash
+func (r *TokenRepository) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*models.Session, 0)
+	for hash, stored := range r.tokens {
+		if stored.userID == userID && stored.expiresAt.After(now) {
+			sessions = append(sessions, &models.Session{ID: hash[:16], ExpiresAt: stored.expiresAt})
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ExpiresAt.Before(sessions[j].ExpiresAt) })
+	return sessions, nil
+}
+
+// valid looks up an unexpired token, dropping it when it has expired
+func (r *TokenRepository) valid(token valueobjects.Token) (refreshToken, error) {
+	hash := hashToken(token)
+	stored, ok := r.tokens[hash]
+	if !ok {
+		return refreshToken{}, models.ErrNotFound
+	}
+	if !stored.expiresAt.After(time.Now()) {
+		delete(r.tokens, hash)
+		return refreshToken{}, models.ErrNotFound
+	}
+	return stored, nil
+}
+
+func hashToken(token valueobjects.Token) string {
+	sum := sha256.Sum256([]byte(token.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,196 @@
+// Package memory keeps auth state in process memory. It backs the standalone
+// mode used for local demos and end-to-end tests, where nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+// UserRepository stores users in memory. Users are copied in and out, so
+// callers never share state with the store.
+type UserRepository struct {
+	mu         sync.RWMutex
+	users      map[string]*models.User // by ID
+	identities map[string][]*models.OauthProviders
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:      make(map[string]*models.User),
+		identities: make(map[string][]*models.OauthProviders),
+	}
+}
+
+// Create stores a new user. A taken email returns models.ErrConflict.
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findByEmail(user.Email) != nil {
+		return models.ErrConflict
+	}
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, models.ErrNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return nil, models.ErrNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (r *UserRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok && user.DeletedAt == nil {
+			users = append(users, copyUser(user))
+		}
+	}
+	return users, nil
+}
+
+func (r *UserRepository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool { return googleID != "" && u.GoogleID == googleID })
+}
+
+func (r *UserRepository) FindByGitHubID(ctx context.Context, githubID string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool { return githubID != "" && u.GitHubID == githubID })
+}
+
+// Update replaces a stored user, keeping its creation and deletion times
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok {
+		return models.ErrNotFound
+	}
+	if other := r.findByEmail(user.Email); other != nil && other.ID != user.ID {
+		return models.ErrConflict
+	}
+	user.UpdatedAt = time.Now()
+	stored := *user
+	stored.CreatedAt = existing.CreatedAt
+	stored.DeletedAt = existing.DeletedAt
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *UserRepository) ListIdentities(ctx context.Context, userID string) ([]*models.OauthProviders, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identities := make([]*models.OauthProviders, 0, len(r.identities[userID]))
+	for _, identity := range r.identities[userID] {
+		copied := *identity
+		identities = append(identities, &copied)
+	}
+	return identities, nil
+}
+
+// SoftDelete marks a user as deleted and deactivates the account
+func (r *UserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.DeletedAt != nil {
+		return models.ErrNotFound
+	}
+	user.DeletedAt = &deletedAt
+	user.IsActive = false
+	user.UpdatedAt = deletedAt
+	return nil
+}
+
+// ListDeletedBefore returns soft-deleted users whose deletion happened before the given time
+func (r *UserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0)
+	for _, user := range r.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(before) {
+			users = append(users, copyUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].DeletedAt.Before(*users[j].DeletedAt) })
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// HardDelete permanently removes a user and their linked identities
+func (r *UserRepository) HardDelete(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, userID)
+	delete(r.identities, userID)
+	return nil
+}
+
+func (r *UserRepository) findBy(match func(*models.User) bool) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.DeletedAt == nil && match(user) {
+			return copyUser(user), nil
+		}
+	}
+	return nil, models.ErrNotFound
+}
+
+func (r *UserRepository) findByEmail(email string) *models.User {
+	for _, user := range r.users {
+		if user.DeletedAt == nil && user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func copyUser(user *models.User) *models.User {
+	copied := *user
+	if user.DeletedAt != nil {
+		deletedAt := *user.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	return &copied
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+// VerificationRepository stores verification tokens in memory. Expired tokens
+// are kept until redeemed; callers check the expiry, as with Postgres.
+type VerificationRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.VerificationToken
+}
+
+func NewVerificationRepository() *VerificationRepository {
+	return &VerificationRepository{tokens: make(map[string]models.VerificationToken)}
+}
+
+func (r *VerificationRepository) Create(ctx context.Context, token *models.VerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.Token]; ok {
+		return models.ErrConflict
+	}
+	r.tokens[token.Token] = *token
+	return nil
+}
+
+func (r *VerificationRepository) Get(ctx context.Context, token string) (*models.VerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[token]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *VerificationRepository) Delete(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, token)
+	return nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *database.Client) *AuditRepository {
+	return &AuditRepository{db: db.GetDB()}
+}
+
+// Record appends an event to the audit trail
+func (r *AuditRepository) Record(ctx context.Context, event *models.AuditEvent) error {
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO audit_events (id, user_id, actor_id, action, metadata, ip_address, user_agent, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8)`,
+		event.ID,
+		event.UserID,
+		event.ActorID,
+		event.Action,
+		metadata,
+		event.IPAddress,
+		event.UserAgent,
+		event.CreatedAt,
+	)
+	return err
+}
+
+// ListByUser returns the audit trail of a user, newest first
+func (r *AuditRepository) ListByUser(ctx context.Context, userID string) ([]*models.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, actor_id, action, metadata, ip_address, user_agent, created_at
+		FROM audit_events WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*models.AuditEvent, 0)
+	for rows.Next() {
+		var (
+			event    models.AuditEvent
+			actorID  sql.NullString
+			metadata []byte
+		)
+		if err := rows.Scan(
+			&event.ID,
+			&event.UserID,
+			&actorID,
+			&event.Action,
+			&metadata,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		event.ActorID = actorID.String
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type DeviceRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeviceRepository(db *database.Client) *DeviceRepository {
+	return &DeviceRepository{db: db.GetDB()}
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, device *models.Device) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO devices (id, user_id, cookie_hash, name, user_agent, ip_prefix, location, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		device.ID, device.UserID, nullString(device.CookieHash), device.Name, device.UserAgent,
+		device.IPPrefix, device.Location, device.FirstSeenAt, device.LastSeenAt,
+	)
+	return err
+}
+
+func (r *DeviceRepository) ListByUser(ctx context.Context, userID string) ([]*models.Device, error) {
+	devices := make([]*models.Device, 0)
+	err := r.db.SelectContext(ctx, &devices, `
+		SELECT id, user_id, COALESCE(cookie_hash, '') AS cookie_hash, name, user_agent, ip_prefix, location, first_seen_at, last_seen_at
+		FROM devices WHERE user_id = $1
+		ORDER BY last_seen_at DESC`, userID)
+	return devices, err
+}
+
+// Touch updates the last sighting. An existing cookie hash is never replaced.
+func (r *DeviceRepository) Touch(ctx context.Context, id, cookieHash string, seenAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE devices SET last_seen_at = $1, cookie_hash = COALESCE(cookie_hash, $2)
+		WHERE id = $3`,
+		seenAt, nullString(cookieHash), id)
+	return err
+}
+
+func (r *DeviceRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM devices WHERE user_id = $1`, userID)
+	return err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const mailQueueColumns = `id, kind, recipient, payload, status, attempts, last_error, next_attempt_at, created_at, sent_at`
+
+type MailQueueRepository struct {
+	db *sqlx.DB
+}
+
+func NewMailQueueRepository(db *database.Client) *MailQueueRepository {
+	return &MailQueueRepository{db: db.GetDB()}
+}
+
+// Enqueue stores an email, inside the context's transaction when there is one
+func (r *MailQueueRepository) Enqueue(ctx context.Context, mail *models.QueuedMail) error {
+	recipient, err := json.Marshal(mail.Recipient)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO mail_queue (id, kind, recipient, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		mail.ID, mail.Kind, recipient, []byte(mail.Payload), mail.Status, mail.Attempts, mail.NextAttemptAt, mail.CreatedAt,
+	)
+	return err
+}
+
+// ClaimDue locks due emails so concurrent workers skip them rather than send them twice
+func (r *MailQueueRepository) ClaimDue(ctx context.Context, limit int) ([]*models.QueuedMail, error) {
+	return r.query(ctx, `
+		SELECT `+mailQueueColumns+` FROM mail_queue
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+}
+
+func (r *MailQueueRepository) Update(ctx context.Context, mail *models.QueuedMail) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE mail_queue
+		SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, sent_at = $5
+		WHERE id = $6`,
+		mail.Status, mail.Attempts, mail.LastError, mail.NextAttemptAt, mail.SentAt, mail.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+func (r *MailQueueRepository) FindByID(ctx context.Context, id string) (*models.QueuedMail, error) {
+	mails, err := r.query(ctx, `SELECT `+mailQueueColumns+` FROM mail_queue WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, models.ErrNotFound
+	}
+	return mails[0], nil
+}
+
+// ListByStatus returns the most recently created emails with the given status
+func (r *MailQueueRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*models.QueuedMail, error) {
+	return r.query(ctx, `
+		SELECT `+mailQueueColumns+` FROM mail_queue
+		WHERE status = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, status, limit)
+}
+
+func (r *MailQueueRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM mail_queue WHERE status IN ('sent', 'dead') AND created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *MailQueueRepository) query(ctx context.Context, query string, args ...any) ([]*models.QueuedMail, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mails := make([]*models.QueuedMail, 0)
+	for rows.Next() {
+		var (
+			mail      models.QueuedMail
+			recipient []byte
+			payload   []byte
+			sentAt    sql.NullTime
+		)
+		if err := rows.Scan(
+			&mail.ID,
+			&mail.Kind,
+			&recipient,
+			&payload,
+			&mail.Status,
+			&mail.Attempts,
+			&mail.LastError,
+			&mail.NextAttemptAt,
+			&mail.CreatedAt,
+			&sentAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(recipient, &mail.Recipient); err != nil {
+			return nil, fmt.Errorf("invalid mail recipient: %w", err)
+		}
+		mail.Payload = json.RawMessage(payload)
+		if sentAt.Valid {
+			mail.SentAt = &sentAt.Time
+		}
+		mails = append(mails, &mail)
+	}
+	return mails, rows.Err()
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS password_history (
+    id            UUID PRIMARY KEY,
+    user_id       UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_created ON password_history (user_id, created_at DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS avatar_url   VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locale       VARCHAR(35)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS timezone     VARCHAR(64)  NOT NULL DEFAULT '';
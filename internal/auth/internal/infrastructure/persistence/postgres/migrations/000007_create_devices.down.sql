@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS devices;
+
+ALTER TABLE verification_tokens DROP COLUMN IF EXISTS purpose;
@@ -0,0 +1,16 @@
+ALTER TABLE verification_tokens
+    ADD COLUMN IF NOT EXISTS purpose VARCHAR(32) NOT NULL DEFAULT 'email_verification';
+
+CREATE TABLE IF NOT EXISTS devices (
+    id            UUID PRIMARY KEY,
+    user_id       UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    cookie_hash   CHAR(64),
+    name          VARCHAR(100) NOT NULL,
+    user_agent    TEXT         NOT NULL DEFAULT '',
+    ip_prefix     VARCHAR(50)  NOT NULL DEFAULT '',
+    location      VARCHAR(200) NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_seen_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user ON devices (user_id);
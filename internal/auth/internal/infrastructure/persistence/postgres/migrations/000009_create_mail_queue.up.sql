@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS mail_queue (
+    id              UUID PRIMARY KEY,
+    kind            VARCHAR(50)  NOT NULL,
+    recipient       JSONB        NOT NULL,
+    payload         JSONB        NOT NULL DEFAULT '{}',
+    status          VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    attempts        INT          NOT NULL DEFAULT 0,
+    last_error      TEXT         NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_queue_due
+    ON mail_queue (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_mail_queue_status ON mail_queue (status, created_at);
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+type OrganizationRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrganizationRepository(db *database.Client) *OrganizationRepository {
+	return &OrganizationRepository{db: db.GetDB()}
+}
+
+// Create inserts the organization together with its first owner
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization, owner *models.Membership) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organizations (id, name, slug, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		org.ID, org.Name, org.Slug, org.CreatedAt, org.UpdatedAt,
+	); err != nil {
+		return mapPQError(err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_memberships (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		owner.OrganizationID, owner.UserID, owner.Role, owner.CreatedAt,
+	); err != nil {
+		return mapPQError(err)
+	}
+	return tx.Commit()
+}
+
+// FindByID retrieves an organization. It returns nil when none exists.
+func (r *OrganizationRepository) FindByID(ctx context.Context, id string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.GetContext(ctx, &org, `
+		SELECT id, name, slug, created_at, updated_at FROM organizations WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListForUser returns every organization the user belongs to with their role in it
+func (r *OrganizationRepository) ListForUser(ctx context.Context, userID string) ([]*models.OrganizationMembership, error) {
+	orgs := make([]*models.OrganizationMembership, 0)
+	err := r.db.SelectContext(ctx, &orgs, `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_memberships m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name`, userID)
+	return orgs, err
+}
+
+// FindMembership returns a user's membership in an organization, or nil when they are not a member
+func (r *OrganizationRepository) FindMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	var member models.Membership
+	err := r.db.GetContext(ctx, &member, `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_memberships WHERE organization_id = $1 AND user_id = $2`,
+		organizationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddMember adds a member to the organization in the context
+func (r *OrganizationRepository) AddMember(ctx context.Context, member *models.Membership) error {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok || tenantID != member.OrganizationID {
+		return models.ErrNoTenant
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO organization_memberships (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		tenantID, member.UserID, member.Role, member.CreatedAt,
+	)
+	return mapPQError(err)
+}
+
+// ListMembers lists the members of the organization in the context
+func (r *OrganizationRepository) ListMembers(ctx context.Context) ([]*models.MemberDetails, error) {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok {
+		return nil, models.ErrNoTenant
+	}
+	members := make([]*models.MemberDetails, 0)
+	err := r.db.SelectContext(ctx, &members, `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, u.email, u.first_name, u.last_name
+		FROM organization_memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.created_at`, tenantID)
+	return members, err
+}
+
+// RemoveMember removes a member from the organization in the context
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, userID string) error {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok {
+		return models.ErrNoTenant
+	}
+	return inTransaction(ctx, r.db, func(q querier) error {
+		// locking the owners serializes concurrent removals, so that two owners
+		// cannot remove each other and leave the organization without one
+		var owners []string
+		if err := q.SelectContext(ctx, &owners, `
+			SELECT user_id FROM organization_memberships
+			WHERE organization_id = $1 AND role = 'owner'
+			FOR UPDATE`, tenantID); err != nil {
+			return err
+		}
+		if len(owners) == 1 && owners[0] == userID {
+			return models.ErrLastOwner
+		}
+		res, err := q.ExecContext(ctx, `
+			DELETE FROM organization_memberships WHERE organization_id = $1 AND user_id = $2`,
+			tenantID, userID)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return models.ErrNotFound
+		}
+		return err
+	})
+}
+
+// mapPQError translates Postgres constraint errors into domain errors
+func mapPQError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return models.ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *database.Client) *OutboxRepository {
+	return &OutboxRepository{db: db.GetDB()}
+}
+
+// Add writes an event to the outbox, inside the context's transaction when there is one
+func (r *OutboxRepository) Add(ctx context.Context, event models.DomainEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, occurred_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`,
+		event.ID, event.Type, event.AggregateID, payload, event.OccurredAt,
+	)
+	return err
+}
+
+// ListPending locks due events so concurrent relays skip them rather than
+// deliver them twice
+func (r *OutboxRepository) ListPending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, event_type, aggregate_id, payload, occurred_at, attempts, last_error, next_attempt_at
+		FROM outbox_events
+		WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY occurred_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]*models.OutboxMessage, 0, limit)
+	for rows.Next() {
+		var message models.OutboxMessage
+		var payload []byte
+		if err := rows.Scan(
+			&message.Event.ID,
+			&message.Event.Type,
+			&message.Event.AggregateID,
+			&payload,
+			&message.Event.OccurredAt,
+			&message.Attempts,
+			&message.LastError,
+			&message.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &message.Event.Payload); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+func (r *OutboxRepository) Lease(ctx context.Context, eventID string, until time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET next_attempt_at = $1 WHERE id = $2`, until, eventID)
+	return err
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, eventID string, publishedAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET published_at = $1, attempts = attempts + 1, last_error = ''
+		WHERE id = $2`, publishedAt, eventID)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID, reason string, nextAttemptAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3`, reason, nextAttemptAt, eventID)
+	return err
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PersonalAccessTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewPersonalAccessTokenRepository(db *database.Client) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db.GetDB()}
+}
+
+// Create stores a new personal access token. Only the token hash is persisted.
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO personal_access_tokens
+			(id, user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.TokenPrefix,
+		pq.Array(token.Scopes),
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	return err
+}
+
+// FindByHash retrieves a token by its hash. It returns nil when no token matches.
+func (r *PersonalAccessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens WHERE token_hash = $1`, tokenHash)
+
+	token, err := scanPersonalAccessToken(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListByUser returns all tokens owned by a user, newest first
+func (r *PersonalAccessTokenRepository) ListByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.PersonalAccessToken, 0)
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke marks a token owned by the user as revoked
+func (r *PersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE personal_access_tokens SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`,
+		time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every active token of a user
+func (r *PersonalAccessTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE personal_access_tokens SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL`,
+		time.Now(), userID)
+	return err
+}
+
+// TouchLastUsed records when a token was last used to authenticate
+func (r *PersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPersonalAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	var (
+		token      models.PersonalAccessToken
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.TokenPrefix,
+		pq.Array(&token.Scopes),
+		&token.ExpiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// querier is the subset of *sqlx.DB and *sqlx.Tx the repositories use
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// conn returns the transaction carried by the context, or db when there is none
+func conn(ctx context.Context, db *sqlx.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// inTransaction runs fn on the transaction carried by ctx, or on a new one
+// that is committed when fn succeeds
+func inTransaction(ctx context.Context, db *sqlx.DB, fn func(q querier) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Transactor runs units of work in a Postgres transaction shared by every
+// repository that is called with the transaction's context
+type Transactor struct {
+	base *database.BaseRepository
+}
+
+func NewTransactor(db *database.Client, logger *logger.Logger) *Transactor {
+	return &Transactor{base: database.NewBaseRepository(db.GetDB(), logger)}
+}
+
+// WithinTransaction commits when fn succeeds and rolls back otherwise. Nested
+// calls join the outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+	return t.base.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +11,8 @@ import (
 	"github.com/istiak-004/myFolio-microservices/pkg/database"
 	"github.com/istiak-004/myFolio-microservices/pkg/logger"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 type UserRepository struct {
@@ -27,7 +28,7 @@ func NewUserRepository(db *database.Client, logger *logger.Logger) *UserReposito
 	}
 }
 
-// Create creates a new user in the database.
+// Create creates a new user in the database. A taken email returns models.ErrConflict.
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 	user.ID = uuid.New().String()
@@ -39,10 +40,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 	query := `INSERT INTO users 
 		(id, first_name,last_name,role,is_admin,is_super_admin,email, password_hash, is_verified, is_active, created_at, updated_at) 
-		ON CONFLICT (email) DO NOTHING
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		user.ID,
 		user.FirstName,
 		user.LastName,
@@ -57,21 +57,25 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.UpdatedAt,
 	)
 
-	return err
+	return r.logFailure(ctx, "Create", mapPQError(err))
 }
 
 // GetByEmail retrieves a user by email from the database.
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `SELECT id, first_name, last_name, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at 
-		FROM users WHERE email = $1`
+	query := `SELECT id, first_name, last_name, display_name, avatar_url, locale, timezone, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at 
+		FROM users WHERE email = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRowContext(ctx, query, email)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, email)
 
 	var user models.User
 	err := row.Scan(
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
+		&user.DisplayName,
+		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
@@ -84,10 +88,10 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no rows found for email %w", sql.ErrNoRows)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotFound
 		}
-		return nil, err
+		return nil, r.logFailure(ctx, "FindByEmail", err)
 	}
 
 	return &user, nil
@@ -95,16 +99,20 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 
 // GetByID retrieves a user by ID from the database.
 func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
-	query := `SELECT id, first_name, last_name, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at 
-		FROM users WHERE id = $1`
+	query := `SELECT id, first_name, last_name, display_name, avatar_url, locale, timezone, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at 
+		FROM users WHERE id = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
 
 	var user models.User
 	err := row.Scan(
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
+		&user.DisplayName,
+		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Role,
@@ -117,39 +125,251 @@ func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User,
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotFound
 		}
-		return nil, err
+		return nil, r.logFailure(ctx, "FindByID", err)
 	}
 
 	return &user, nil
 }
 
-// UpdateUser updates an existing user in the database.
-func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+// Update persists every mutable field of an existing user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `UPDATE users 
-		SET first_name = $1, last_name = $2, email = $3, password_hash = $4, is_verified = $5, is_active = $6, updated_at = $7 
-		WHERE id = $8`
+		SET first_name = $1, last_name = $2, display_name = $3, avatar_url = $4, locale = $5, timezone = $6,
+			email = $7, password_hash = $8, role = $9, is_verified = $10, is_active = $11, updated_at = $12 
+		WHERE id = $13`
 
-	_, err := r.db.ExecContext(ctx, query,
+	user.UpdatedAt = time.Now()
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
 		user.FirstName,
 		user.LastName,
+		user.DisplayName,
+		user.AvatarURL,
+		user.Locale,
+		user.Timezone,
 		user.Email,
 		user.PasswordHash,
+		user.Role,
 		user.IsVerified,
 		user.IsActive,
-		time.Now(),
+		user.UpdatedAt,
 		user.ID,
 	)
+	if err != nil {
+		return r.logFailure(ctx, "Update", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+// FindByIDs retrieves every existing user among the given IDs
+func (r *UserRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.User, error) {
+	query := `SELECT id, first_name, last_name, display_name, avatar_url, locale, timezone, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at 
+		FROM users WHERE id = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, r.logFailure(ctx, "FindByIDs", err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, len(ids))
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.FirstName,
+			&user.LastName,
+			&user.DisplayName,
+			&user.AvatarURL,
+			&user.Locale,
+			&user.Timezone,
+			&user.Email,
+			&user.PasswordHash,
+			&user.Role,
+			&user.IsAdmin,
+			&user.IsSuperAdmin,
+			&user.IsVerified,
+			&user.IsActive,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return nil, r.logFailure(ctx, "FindByIDs", err)
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
 
+// FindByGoogleID retrieves the user linked to a Google account
+func (r *UserRepository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+	return r.findByProvider(ctx, "google", googleID)
+}
+
+// FindByGitHubID retrieves the user linked to a GitHub account
+func (r *UserRepository) FindByGitHubID(ctx context.Context, githubID string) (*models.User, error) {
+	return r.findByProvider(ctx, "github", githubID)
+}
+
+// findByProvider looks a user up through oauth_providers. It returns
+// models.ErrNotFound when no live user is linked to the provider account.
+func (r *UserRepository) findByProvider(ctx context.Context, provider, providerID string) (*models.User, error) {
+	query := `SELECT u.id, u.first_name, u.last_name, u.display_name, u.avatar_url, u.locale, u.timezone, u.email, u.password_hash, u.role, u.is_admin, u.is_super_admin, u.is_verified, u.is_active, u.created_at, u.updated_at 
+		FROM users u
+		JOIN oauth_providers op ON op.user_id = u.id
+		WHERE op.provider = $1 AND op.provider_id = $2 AND u.deleted_at IS NULL`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, provider, providerID)
+
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.DisplayName,
+		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.IsAdmin,
+		&user.IsSuperAdmin,
+		&user.IsVerified,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, r.logFailure(ctx, "findByProvider", err)
+	}
+	return &user, nil
+}
+
+// ListIdentities returns the OAuth providers linked to a user
+func (r *UserRepository) ListIdentities(ctx context.Context, userID string) ([]*models.OauthProviders, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT user_id, provider, provider_id, created_at
+		FROM oauth_providers WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, r.logFailure(ctx, "ListIdentities", err)
+	}
+	defer rows.Close()
+
+	identities := make([]*models.OauthProviders, 0)
+	for rows.Next() {
+		var identity models.OauthProviders
+		if err := rows.Scan(&identity.UserID, &identity.Provider, &identity.ProviderID, &identity.CreatedAt); err != nil {
+			return nil, r.logFailure(ctx, "ListIdentities", err)
+		}
+		identities = append(identities, &identity)
+	}
+	return identities, rows.Err()
+}
+
+// SoftDelete marks a user as deleted and deactivates the account
+func (r *UserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users SET deleted_at = $1, is_active = FALSE, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NULL`,
+		deletedAt, userID)
+	if err != nil {
+		return r.logFailure(ctx, "SoftDelete", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
 	return err
 }
 
+// ListDeletedBefore returns soft-deleted users whose deletion happened before the given time
+func (r *UserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, email, deleted_at FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, r.logFailure(ctx, "ListDeletedBefore", err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.DeletedAt); err != nil {
+			return nil, r.logFailure(ctx, "ListDeletedBefore", err)
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// hardDeleteStatements erase a user, in order, all taking the user ID as $1.
+// Queued mail is matched by address, so it goes before the user row.
+// Memberships, devices, personal access tokens and password history follow
+// the user row through ON DELETE CASCADE.
+var hardDeleteStatements = []string{
+	// hand organizations the user solely owns to the longest-standing admin,
+	// or member, so that they keep an owner
+	`UPDATE organization_memberships SET role = 'owner'
+		WHERE organization_id IN (
+			SELECT organization_id FROM organization_memberships WHERE user_id = $1 AND role = 'owner')
+		AND NOT EXISTS (
+			SELECT 1 FROM organization_memberships o
+			WHERE o.organization_id = organization_memberships.organization_id AND o.user_id <> $1 AND o.role = 'owner')
+		AND user_id = (
+			SELECT c.user_id FROM organization_memberships c
+			WHERE c.organization_id = organization_memberships.organization_id AND c.user_id <> $1
+			ORDER BY c.role = 'admin' DESC, c.created_at, c.user_id
+			LIMIT 1)`,
+	`DELETE FROM organizations
+		WHERE id IN (SELECT organization_id FROM organization_memberships WHERE user_id = $1)
+		AND NOT EXISTS (
+			SELECT 1 FROM organization_memberships m
+			WHERE m.organization_id = organizations.id AND m.user_id <> $1)`,
+	`DELETE FROM mail_queue WHERE recipient->>'email' = (SELECT email FROM users WHERE id = $1)`,
+	`DELETE FROM outbox_events WHERE aggregate_id = $1`,
+	`DELETE FROM verification_tokens WHERE user_id = $1`,
+	`DELETE FROM oauth_providers WHERE user_id = $1`,
+	`DELETE FROM audit_events WHERE user_id = $1`,
+	`DELETE FROM users WHERE id = $1`,
+}
+
+// HardDelete erases a user and every row about them, in the transaction
+// carried by ctx or in one of its own. Refresh tokens live in Redis and are
+// revoked by the caller.
+func (r *UserRepository) HardDelete(ctx context.Context, userID string) error {
+	err := inTransaction(ctx, r.db, func(q querier) error {
+		for _, stmt := range hardDeleteStatements {
+			if _, err := q.ExecContext(ctx, stmt, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return r.logFailure(ctx, "HardDelete", err)
+}
+
+// UpdateUser updates an existing user in the database.
+//
+// Deprecated: use Update, which also persists the profile fields and role.
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	return r.Update(ctx, user)
+}
+
 func (r *UserRepository) FindOrCreateOAuthUser(ctx context.Context, provider, providerID, email string) (*models.User, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return nil, r.logFailure(ctx, "FindOrCreateOAuthUser", err)
 	}
 	defer tx.Rollback()
 
@@ -169,7 +389,7 @@ func (r *UserRepository) FindOrCreateOAuthUser(ctx context.Context, provider, pr
 	}
 
 	if !errors.Is(err, sql.ErrNoRows) {
-		return nil, err
+		return nil, r.logFailure(ctx, "FindOrCreateOAuthUser", err)
 	}
 
 	// If the user doesn't exist, create a new user
@@ -214,7 +434,7 @@ func (r *UserRepository) FindOrCreateOAuthUser(ctx context.Context, provider, pr
 		user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, r.logFailure(ctx, "FindOrCreateOAuthUser", err)
 	}
 
 	// Link OAuth provider
@@ -227,7 +447,17 @@ func (r *UserRepository) FindOrCreateOAuthUser(ctx context.Context, provider, pr
 		time.Now(),
 	)
 	if err != nil {
-		return nil, err
+		return nil, r.logFailure(ctx, "FindOrCreateOAuthUser", err)
 	}
 	return &user, tx.Commit()
 }
+
+// logFailure logs a failed query with the request-scoped fields of ctx and
+// returns err. A missing row or a taken email is an answer, not a failure.
+func (r *UserRepository) logFailure(ctx context.Context, operation string, err error) error {
+	if err == nil || errors.Is(err, sql.ErrNoRows) || errors.Is(err, models.ErrConflict) {
+		return err
+	}
+	r.logger.Ctx(ctx).Error("User query failed", zap.String("operation", operation), zap.Error(err))
+	return err
+}
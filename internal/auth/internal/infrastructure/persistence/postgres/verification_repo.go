@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
 	"github.com/istiak-004/myFolio-microservices/pkg/database"
@@ -17,22 +19,26 @@ func NewVerificationRepository(db *database.Client) *VerificationRepository {
 }
 
 func (r *VerificationRepository) Create(ctx context.Context, token *models.VerificationToken) error {
-	_, err := r.db.ExecContext(ctx, `
-        INSERT INTO verification_tokens (token, user_id, expires_at)
-        VALUES ($1, $2, $3)`,
-		token.Token, token.UserID, token.ExpiresAt,
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+        INSERT INTO verification_tokens (token, purpose, user_id, expires_at)
+        VALUES ($1, $2, $3, $4)`,
+		token.Token, token.Purpose, token.UserID, token.ExpiresAt,
 	)
 	return err
 }
 
+// Get returns models.ErrNotFound for unknown tokens
 func (r *VerificationRepository) Get(ctx context.Context, token string) (*models.VerificationToken, error) {
 	t := &models.VerificationToken{}
-	err := r.db.QueryRowContext(ctx, `SELECT token, user_id, expires_at FROM verification_tokens WHERE token = $1`, token).
-		Scan(&t.Token, &t.UserID, &t.ExpiresAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT token, purpose, user_id, expires_at FROM verification_tokens WHERE token = $1`, token).
+		Scan(&t.Token, &t.Purpose, &t.UserID, &t.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
 	return t, err
 }
 
 func (r *VerificationRepository) Delete(ctx context.Context, token string) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM verification_tokens WHERE token = $1`, token)
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM verification_tokens WHERE token = $1`, token)
 	return err
 }
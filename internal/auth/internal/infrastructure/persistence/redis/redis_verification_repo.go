@@ -6,19 +6,29 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
 	"github.com/istiak-004/myFolio-microservices/pkg/database"
 	"github.com/redis/go-redis/v9"
 )
 
 const (
 	refreshTokenPrefix = "auth:refresh_token:"
+	userTokensPrefix   = "auth:user_refresh_tokens:"
 	usedJtiPrefix      = "auth:used_jti:"
-	tokenTTL           = 30 * 24 * time.Hour // 30 days
-	usedJTITTL         = 60 * time.Minute    // prevent reuse for 1 hour
+	usedJTITTL         = 60 * time.Minute // prevent reuse for 1 hour
+
+	// tenantSeparator splits the owner from the organization in a token's value
+	tenantSeparator = "|"
 )
 
+// TokenRepository stores refresh tokens in Redis. Each token hash maps to its
+// owner, and the organization it is scoped to, and expires with the token; a sorted set per user, scored by expiry,
+// tracks the user's sessions.
 type TokenRepository struct {
 	rdb *redis.Client
 }
@@ -31,37 +41,125 @@ func NewTokenRepository(rdb *database.RedisClient) *TokenRepository {
 // hashToken hashes the token using SHA-256 and returns the hex string.
 // This is used to create a unique identifier for the token in Redis.
 // It is important to use a secure hashing algorithm to prevent token collisions.
-func hashToken(token string) string {
-	h := sha256.Sum256([]byte(token))
+func hashToken(token valueobjects.Token) string {
+	h := sha256.Sum256([]byte(token.String()))
 	return hex.EncodeToString(h[:])
 }
 
+// GenerateRefreshToken issues an additional token, with the same expiry, for
+// the owner of a valid token
+func (r *TokenRepository) GenerateRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	userID, tenantID, ttl, err := r.lookup(ctx, hashToken(token))
+	if err != nil {
+		return "", err
+	}
+	issued := valueobjects.NewToken()
+	if err := r.store(ctx, userID, tenantID, hashToken(issued), ttl); err != nil {
+		return "", err
+	}
+	return issued.String(), nil
+}
+
 // StoreRefreshToken stores the refresh token in Redis with an expiration time.
-func (r *TokenRepository) StoreRefreshToken(ctx context.Context, userID, token string, expiresAt time.Time) error {
-	hashed := hashToken(token)
-	jti := fmt.Sprintf("%s%s", refreshTokenPrefix, hashed)
-	return r.rdb.Set(ctx, jti, userID, time.Until(expiresAt)).Err()
+func (r *TokenRepository) StoreRefreshToken(ctx context.Context, userID, tenantID string, token valueobjects.Token, expiresAt time.Time) error {
+	return r.store(ctx, userID, tenantID, hashToken(token), time.Until(expiresAt))
 }
 
-// VerifyRefreshToken checks if the refresh token is valid and returns the associated user ID.
-// It uses the hashed token to look up the user ID in Redis.
-func (r *TokenRepository) VerifyRefreshToken(ctx context.Context, token string) (string, error) {
-	hashed := hashToken(token)
-	jti := fmt.Sprintf("%s%s", refreshTokenPrefix, hashed)
-	userID, err := r.rdb.Get(ctx, jti).Result()
-	if err == redis.Nil {
-		return "", errors.New("token not found or expired")
-	} else if err != nil {
-		return "", err
+// VerifyRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) VerifyRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	value, err := r.rdb.Get(ctx, refreshTokenPrefix+hashToken(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", models.ErrNotFound
 	}
-	return userID, nil
+	userID, _ := parseSessionValue(value)
+	return userID, err
+}
+
+// GetRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) GetRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	return r.VerifyRefreshToken(ctx, token)
 }
 
 // RevokeRefreshToken removes the refresh token from Redis, effectively invalidating it.
-func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, token string) error {
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, token valueobjects.Token) error {
 	hashed := hashToken(token)
-	jti := fmt.Sprintf("%s%s", refreshTokenPrefix, hashed)
-	return r.rdb.Del(ctx, jti).Err()
+	value, err := r.rdb.GetDel(ctx, refreshTokenPrefix+hashed).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil // already revoked or expired
+	}
+	if err != nil {
+		return err
+	}
+	userID, _ := parseSessionValue(value)
+	return r.rdb.ZRem(ctx, userTokensPrefix+userID, hashed).Err()
+}
+
+// RotateRefreshToken replaces a valid token with a new one. The old token is
+// taken with GETDEL, so when the same token is presented twice concurrently
+// only one caller gets a new token. The new token keeps the old expiry, so
+// rotation never extends a session, and the organization it is scoped to.
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, oldToken valueobjects.Token) (valueobjects.Token, string, error) {
+	hashed := hashToken(oldToken)
+	key := refreshTokenPrefix + hashed
+
+	ttl, err := r.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	value, err := r.rdb.GetDel(ctx, key).Result()
+	if errors.Is(err, redis.Nil) || ttl <= 0 {
+		return valueobjects.Token{}, "", models.ErrNotFound
+	}
+	if err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	userID, tenantID := parseSessionValue(value)
+	r.rdb.ZRem(ctx, userTokensPrefix+userID, hashed)
+
+	rotated := valueobjects.NewToken()
+	if err := r.store(ctx, userID, tenantID, hashToken(rotated), ttl); err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	return rotated, tenantID, nil
+}
+
+// RevokeAllForUser deletes every refresh token of the user
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	userKey := userTokensPrefix + userID
+	hashes, err := r.rdb.ZRange(ctx, userKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get user tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(hashes)+1)
+	for _, hashed := range hashes {
+		keys = append(keys, refreshTokenPrefix+hashed)
+	}
+	keys = append(keys, userKey)
+	return r.rdb.Del(ctx, keys...).Err()
+}
+
+// ListSessions returns the user's unexpired refresh tokens, identified by a
+// fingerprint of the token hash
+func (r *TokenRepository) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	entries, err := r.rdb.ZRangeByScoreWithScores(ctx, userTokensPrefix+userID, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user tokens: %w", err)
+	}
+
+	sessions := make([]*models.Session, 0, len(entries))
+	for _, entry := range entries {
+		hashed, _ := entry.Member.(string)
+		if len(hashed) < 16 {
+			continue
+		}
+		sessions = append(sessions, &models.Session{
+			ID:        hashed[:16],
+			ExpiresAt: time.Unix(int64(entry.Score), 0),
+		})
+	}
+	return sessions, nil
 }
 
 // MarkJTIAssigned marks a JTI (JWT ID) as used in Redis to prevent replay attacks.
@@ -81,26 +179,55 @@ func (r *TokenRepository) IsJTIUsed(ctx context.Context, jti string) (bool, erro
 	return exists > 0, err
 }
 
-// RotateRefreshToken revokes the old refresh token and stores a new one.
-// It returns the new token and any error encountered during the process.
-// This is useful for implementing refresh token rotation, where a new token is issued
-// each time the old one is used. This helps to prevent replay attacks and ensures that
-// the refresh token is always fresh.
-func (r *TokenRepository) RotateRefreshToken(ctx context.Context, oldToken string, newToken string, userID string) (string, error) {
-	// Revoke old
-	_ = r.RevokeRefreshToken(ctx, oldToken)
+// store saves a token hash for ttl and records it in the user's session set,
+// pruning entries of tokens that have expired since
+func (r *TokenRepository) store(ctx context.Context, userID, tenantID, hashed string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return models.ErrNotFound
+	}
+	expiresAt := time.Now().Add(ttl)
+	userKey := userTokensPrefix + userID
 
-	// Store new
-	expiresAt := time.Now().Add(tokenTTL)
-	err := r.StoreRefreshToken(ctx, userID, newToken, expiresAt)
-	if err != nil {
-		return "", err
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, refreshTokenPrefix+hashed, sessionValue(userID, tenantID), ttl)
+		pipe.ZAdd(ctx, userKey, redis.Z{Score: float64(expiresAt.Unix()), Member: hashed})
+		pipe.ZRemRangeByScore(ctx, userKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+		return nil
+	})
+	return err
+}
+
+// lookup returns the owner and organization of an unexpired token hash and
+// its remaining lifetime
+func (r *TokenRepository) lookup(ctx context.Context, hashed string) (string, string, time.Duration, error) {
+	key := refreshTokenPrefix + hashed
+	pipe := r.rdb.Pipeline()
+	valueCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", "", 0, models.ErrNotFound
+		}
+		return "", "", 0, err
+	}
+	ttl := ttlCmd.Val()
+	if ttl <= 0 {
+		return "", "", 0, models.ErrNotFound
 	}
-	return newToken, nil
+	userID, tenantID := parseSessionValue(valueCmd.Val())
+	return userID, tenantID, ttl, nil
 }
 
-func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
-	// Optional: Use SCAN to find all keys by userID if you structure keys differently
-	// For now, this is a no-op (requires more tracking)
-	return nil // OR: implement tracking jtis per user
+// sessionValue is what a token hash maps to: the owner, followed by the
+// organization the token is scoped to, if any
+func sessionValue(userID, tenantID string) string {
+	if tenantID == "" {
+		return userID
+	}
+	return userID + tenantSeparator + tenantID
+}
+
+func parseSessionValue(value string) (userID, tenantID string) {
+	userID, tenantID, _ = strings.Cut(value, tenantSeparator)
+	return userID, tenantID
 }
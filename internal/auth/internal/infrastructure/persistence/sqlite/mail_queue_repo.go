@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const mailQueueColumns = `id, kind, recipient, payload, status, attempts, last_error, next_attempt_at, created_at, sent_at`
+
+type MailQueueRepository struct {
+	db *sqlx.DB
+}
+
+func NewMailQueueRepository(db *database.Client) *MailQueueRepository {
+	return &MailQueueRepository{db: db.GetDB()}
+}
+
+// Enqueue stores an email, inside the context's transaction when there is one
+func (r *MailQueueRepository) Enqueue(ctx context.Context, mail *models.QueuedMail) error {
+	recipient, err := json.Marshal(mail.Recipient)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO mail_queue (id, kind, recipient, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		mail.ID, mail.Kind, string(recipient), string(mail.Payload), mail.Status, mail.Attempts,
+		utc(mail.NextAttemptAt), utc(mail.CreatedAt),
+	)
+	return err
+}
+
+// ClaimDue returns due emails. The transaction it runs in holds the database
+// write lock, so concurrent workers wait rather than send an email twice.
+func (r *MailQueueRepository) ClaimDue(ctx context.Context, limit int) ([]*models.QueuedMail, error) {
+	return r.query(ctx, `
+		SELECT `+mailQueueColumns+` FROM mail_queue
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?`, utc(time.Now()), limit)
+}
+
+func (r *MailQueueRepository) Update(ctx context.Context, mail *models.QueuedMail) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE mail_queue
+		SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, sent_at = ?
+		WHERE id = ?`,
+		mail.Status, mail.Attempts, mail.LastError, utc(mail.NextAttemptAt), nullableTime(mail.SentAt), mail.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+func (r *MailQueueRepository) FindByID(ctx context.Context, id string) (*models.QueuedMail, error) {
+	mails, err := r.query(ctx, `SELECT `+mailQueueColumns+` FROM mail_queue WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, models.ErrNotFound
+	}
+	return mails[0], nil
+}
+
+// ListByStatus returns the most recently created emails with the given status
+func (r *MailQueueRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*models.QueuedMail, error) {
+	return r.query(ctx, `
+		SELECT `+mailQueueColumns+` FROM mail_queue
+		WHERE status = ?
+		ORDER BY created_at DESC
+		LIMIT ?`, status, limit)
+}
+
+func (r *MailQueueRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM mail_queue WHERE status IN ('sent', 'dead') AND created_at < ?`, utc(before))
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+func (r *MailQueueRepository) query(ctx context.Context, query string, args ...any) ([]*models.QueuedMail, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mails := make([]*models.QueuedMail, 0)
+	for rows.Next() {
+		var (
+			mail      models.QueuedMail
+			recipient string
+			payload   string
+			sentAt    sql.NullTime
+		)
+		if err := rows.Scan(
+			&mail.ID,
+			&mail.Kind,
+			&recipient,
+			&payload,
+			&mail.Status,
+			&mail.Attempts,
+			&mail.LastError,
+			&mail.NextAttemptAt,
+			&mail.CreatedAt,
+			&sentAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(recipient), &mail.Recipient); err != nil {
+			return nil, fmt.Errorf("invalid mail recipient: %w", err)
+		}
+		mail.Payload = json.RawMessage(payload)
+		if sentAt.Valid {
+			mail.SentAt = &sentAt.Time
+		}
+		mails = append(mails, &mail)
+	}
+	return mails, rows.Err()
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS mail_queue;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS devices;
+DROP TABLE IF EXISTS organization_memberships;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS password_history;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS verification_tokens;
+DROP TABLE IF EXISTS oauth_providers;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,153 @@
+-- SQLite keeps UUIDs and JSON as TEXT. Timestamps are stored as UTC text
+-- declared TIMESTAMP, so they compare correctly and scan into time.Time.
+
+CREATE TABLE IF NOT EXISTS users (
+    id             TEXT PRIMARY KEY,
+    email          TEXT      NOT NULL UNIQUE,
+    password_hash  TEXT      NOT NULL DEFAULT '',
+    google_id      TEXT UNIQUE,
+    github_id      TEXT UNIQUE,
+    role           TEXT      NOT NULL DEFAULT 'visitor',
+    first_name     TEXT      NOT NULL DEFAULT '',
+    last_name      TEXT      NOT NULL DEFAULT '',
+    display_name   TEXT      NOT NULL DEFAULT '',
+    avatar_url     TEXT      NOT NULL DEFAULT '',
+    locale         TEXT      NOT NULL DEFAULT '',
+    timezone       TEXT      NOT NULL DEFAULT '',
+    is_active      BOOLEAN   NOT NULL DEFAULT 1,
+    is_verified    BOOLEAN   NOT NULL DEFAULT 0,
+    is_admin       BOOLEAN   NOT NULL DEFAULT 0,
+    is_super_admin BOOLEAN   NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL,
+    deleted_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS oauth_providers (
+    user_id     TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider    TEXT      NOT NULL,
+    provider_id TEXT      NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (provider, provider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_providers_user ON oauth_providers (user_id);
+
+CREATE TABLE IF NOT EXISTS verification_tokens (
+    token      TEXT PRIMARY KEY,
+    purpose    TEXT      NOT NULL DEFAULT 'email_verification',
+    user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens (user_id, expires_at);
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT      NOT NULL,
+    token_hash   TEXT      NOT NULL UNIQUE,
+    token_prefix TEXT      NOT NULL,
+    scopes       TEXT      NOT NULL DEFAULT '[]',
+    expires_at   TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id            TEXT PRIMARY KEY,
+    user_id       TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_created ON password_history (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    actor_id   TEXT,
+    action     TEXT      NOT NULL,
+    metadata   TEXT      NOT NULL DEFAULT '{}',
+    ip_address TEXT      NOT NULL DEFAULT '',
+    user_agent TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_created ON audit_events (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         TEXT PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    slug       TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_memberships (
+    organization_id TEXT      NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            TEXT      NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at      TIMESTAMP NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_memberships_user ON organization_memberships (user_id);
+
+CREATE TABLE IF NOT EXISTS devices (
+    id            TEXT PRIMARY KEY,
+    user_id       TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    cookie_hash   TEXT,
+    name          TEXT      NOT NULL,
+    user_agent    TEXT      NOT NULL DEFAULT '',
+    ip_prefix     TEXT      NOT NULL DEFAULT '',
+    location      TEXT      NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at  TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user ON devices (user_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              TEXT PRIMARY KEY,
+    event_type      TEXT      NOT NULL,
+    aggregate_id    TEXT      NOT NULL,
+    payload         TEXT      NOT NULL DEFAULT '{}',
+    occurred_at     TIMESTAMP NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    last_error      TEXT      NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL,
+    published_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+    ON outbox_events (next_attempt_at, occurred_at) WHERE published_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS mail_queue (
+    id              TEXT PRIMARY KEY,
+    kind            TEXT      NOT NULL,
+    recipient       TEXT      NOT NULL,
+    payload         TEXT      NOT NULL DEFAULT '{}',
+    status          TEXT      NOT NULL DEFAULT 'pending',
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    last_error      TEXT      NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL,
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_queue_due
+    ON mail_queue (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_mail_queue_status ON mail_queue (status, created_at);
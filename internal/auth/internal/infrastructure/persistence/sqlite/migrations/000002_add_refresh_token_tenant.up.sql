@@ -0,0 +1 @@
+ALTER TABLE refresh_tokens ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
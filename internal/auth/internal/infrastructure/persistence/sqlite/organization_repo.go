@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type OrganizationRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrganizationRepository(db *database.Client) *OrganizationRepository {
+	return &OrganizationRepository{db: db.GetDB()}
+}
+
+// Create inserts the organization together with its first owner
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization, owner *models.Membership) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organizations (id, name, slug, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		org.ID, org.Name, org.Slug, utc(org.CreatedAt), utc(org.UpdatedAt),
+	); err != nil {
+		return mapSQLiteError(err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_memberships (organization_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)`,
+		owner.OrganizationID, owner.UserID, owner.Role, utc(owner.CreatedAt),
+	); err != nil {
+		return mapSQLiteError(err)
+	}
+	return tx.Commit()
+}
+
+// FindByID retrieves an organization. It returns nil when none exists.
+func (r *OrganizationRepository) FindByID(ctx context.Context, id string) (*models.Organization, error) {
+	var org models.Organization
+	err := conn(ctx, r.db).GetContext(ctx, &org, `
+		SELECT id, name, slug, created_at, updated_at FROM organizations WHERE id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListForUser returns every organization the user belongs to with their role in it
+func (r *OrganizationRepository) ListForUser(ctx context.Context, userID string) ([]*models.OrganizationMembership, error) {
+	orgs := make([]*models.OrganizationMembership, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &orgs, `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		JOIN organization_memberships m ON m.organization_id = o.id
+		WHERE m.user_id = ?
+		ORDER BY o.name`, userID)
+	return orgs, err
+}
+
+// FindMembership returns a user's membership in an organization, or nil when they are not a member
+func (r *OrganizationRepository) FindMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	var member models.Membership
+	err := conn(ctx, r.db).GetContext(ctx, &member, `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_memberships WHERE organization_id = ? AND user_id = ?`,
+		organizationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddMember adds a member to the organization in the context
+func (r *OrganizationRepository) AddMember(ctx context.Context, member *models.Membership) error {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok || tenantID != member.OrganizationID {
+		return models.ErrNoTenant
+	}
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO organization_memberships (organization_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)`,
+		tenantID, member.UserID, member.Role, utc(member.CreatedAt),
+	)
+	return mapSQLiteError(err)
+}
+
+// ListMembers lists the members of the organization in the context
+func (r *OrganizationRepository) ListMembers(ctx context.Context) ([]*models.MemberDetails, error) {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok {
+		return nil, models.ErrNoTenant
+	}
+	members := make([]*models.MemberDetails, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &members, `
+		SELECT m.organization_id, m.user_id, m.role, m.created_at, u.email, u.first_name, u.last_name
+		FROM organization_memberships m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = ?
+		ORDER BY m.created_at`, tenantID)
+	return members, err
+}
+
+// RemoveMember removes a member from the organization in the context
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, userID string) error {
+	tenantID, ok := models.TenantFromContext(ctx)
+	if !ok {
+		return models.ErrNoTenant
+	}
+	return inTransaction(ctx, r.db, func(q querier) error {
+		// a single statement, so the owner check cannot race a concurrent removal
+		res, err := q.ExecContext(ctx, `
+			DELETE FROM organization_memberships
+			WHERE organization_id = ?1 AND user_id = ?2
+			AND (role <> 'owner' OR EXISTS (
+				SELECT 1 FROM organization_memberships o
+				WHERE o.organization_id = ?1 AND o.user_id <> ?2 AND o.role = 'owner'))`,
+			tenantID, userID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected > 0 {
+			return err
+		}
+		var role string
+		if err := q.GetContext(ctx, &role, `
+			SELECT role FROM organization_memberships WHERE organization_id = ? AND user_id = ?`,
+			tenantID, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrNotFound
+			}
+			return err
+		}
+		return models.ErrLastOwner
+	})
+}
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+)
+
+func TestRemoveMemberKeepsAnOwner(t *testing.T) {
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+	orgs := NewOrganizationRepository(db)
+	ctx := models.ContextWithTenant(context.Background(), "acme")
+
+	now := time.Now().UTC()
+	if _, err := users.db.ExecContext(ctx, `INSERT INTO organizations (id, name, slug, created_at, updated_at) VALUES ('acme', 'Acme', 'acme', ?, ?)`, now, now); err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]string{}
+	for _, name := range []string{"ada", "grace", "linus"} {
+		user := &models.User{Email: name + "@example.com", PasswordHash: "hash", IsActive: true}
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = user.ID
+	}
+	for name, role := range map[string]string{"ada": "owner", "grace": "owner", "linus": "viewer"} {
+		if _, err := users.db.ExecContext(ctx, `INSERT INTO organization_memberships (organization_id, user_id, role, created_at) VALUES ('acme', ?, ?, ?)`, ids[name], role, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := orgs.RemoveMember(ctx, ids["linus"]); err != nil {
+		t.Fatalf("removing a viewer: %v", err)
+	}
+	if err := orgs.RemoveMember(ctx, ids["linus"]); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("removing a former member: got %v, want models.ErrNotFound", err)
+	}
+
+	// both owners remove each other at once; one of them must stay
+	var wg sync.WaitGroup
+	results := make([]error, 2)
+	for i, name := range []string{"ada", "grace"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = orgs.RemoveMember(ctx, ids[name])
+		}()
+	}
+	wg.Wait()
+
+	removed, refused := 0, 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			removed++
+		case errors.Is(err, models.ErrLastOwner):
+			refused++
+		default:
+			t.Fatalf("RemoveMember: %v", err)
+		}
+	}
+	if removed != 1 || refused != 1 {
+		t.Errorf("got %d removed and %d refused, want one of each", removed, refused)
+	}
+	var owners int
+	if err := users.db.GetContext(ctx, &owners, `SELECT COUNT(*) FROM organization_memberships WHERE organization_id = 'acme' AND role = 'owner'`); err != nil {
+		t.Fatal(err)
+	}
+	if owners != 1 {
+		t.Errorf("%d owners left, want 1", owners)
+	}
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *database.Client) *OutboxRepository {
+	return &OutboxRepository{db: db.GetDB()}
+}
+
+// Add writes an event to the outbox, inside the context's transaction when there is one
+func (r *OutboxRepository) Add(ctx context.Context, event models.DomainEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, occurred_at, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		event.ID, event.Type, event.AggregateID, string(payload), utc(event.OccurredAt), utc(event.OccurredAt),
+	)
+	return err
+}
+
+// ListPending returns due events. SQLite has no row locks; the transaction
+// the relay runs in holds the database write lock, so concurrent relays
+// wait for each other rather than deliver an event twice.
+func (r *OutboxRepository) ListPending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, event_type, aggregate_id, payload, occurred_at, attempts, last_error, next_attempt_at
+		FROM outbox_events
+		WHERE published_at IS NULL AND next_attempt_at <= ?
+		ORDER BY occurred_at
+		LIMIT ?`, utc(time.Now()), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]*models.OutboxMessage, 0, limit)
+	for rows.Next() {
+		var message models.OutboxMessage
+		var payload string
+		if err := rows.Scan(
+			&message.Event.ID,
+			&message.Event.Type,
+			&message.Event.AggregateID,
+			&payload,
+			&message.Event.OccurredAt,
+			&message.Attempts,
+			&message.LastError,
+			&message.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payload), &message.Event.Payload); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+func (r *OutboxRepository) Lease(ctx context.Context, eventID string, until time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET next_attempt_at = ? WHERE id = ?`, utc(until), eventID)
+	return err
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, eventID string, publishedAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET published_at = ?, attempts = attempts + 1, last_error = ''
+		WHERE id = ?`, utc(publishedAt), eventID)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID, reason string, nextAttemptAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?`, reason, utc(nextAttemptAt), eventID)
+	return err
+}
@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordHistoryRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordHistoryRepository(db *database.Client) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{db: db.GetDB()}
+}
+
+// Add records a password hash in the user's history
+func (r *PasswordHistoryRepository) Add(ctx context.Context, entry *models.PasswordHistoryEntry) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO password_history (id, user_id, password_hash, created_at)
+		VALUES (?, ?, ?, ?)`,
+		entry.ID, entry.UserID, entry.PasswordHash, utc(entry.CreatedAt),
+	)
+	return err
+}
+
+// ListRecent returns the user's most recent password hashes, newest first
+func (r *PasswordHistoryRepository) ListRecent(ctx context.Context, userID string, limit int) ([]*models.PasswordHistoryEntry, error) {
+	entries := make([]*models.PasswordHistoryEntry, 0, limit)
+	err := conn(ctx, r.db).SelectContext(ctx, &entries, `
+		SELECT id, user_id, password_hash, created_at
+		FROM password_history WHERE user_id = ?
+		ORDER BY created_at DESC LIMIT ?`, userID, limit)
+	return entries, err
+}
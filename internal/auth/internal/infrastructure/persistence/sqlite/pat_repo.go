@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const personalAccessTokenColumns = `id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+type PersonalAccessTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewPersonalAccessTokenRepository(db *database.Client) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db.GetDB()}
+}
+
+// Create stores a new personal access token. Only the token hash is
+// persisted; scopes are kept as a JSON array.
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	scopes, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO personal_access_tokens
+			(id, user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.TokenPrefix,
+		string(scopes),
+		utc(token.ExpiresAt),
+		utc(token.CreatedAt),
+	)
+	return mapSQLiteError(err)
+}
+
+// FindByHash retrieves a token by its hash. It returns nil when no token matches.
+func (r *PersonalAccessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+personalAccessTokenColumns+`
+		FROM personal_access_tokens WHERE token_hash = ?`, tokenHash)
+
+	token, err := scanPersonalAccessToken(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListByUser returns all tokens owned by a user, newest first
+func (r *PersonalAccessTokenRepository) ListByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+personalAccessTokenColumns+`
+		FROM personal_access_tokens WHERE user_id = ?
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.PersonalAccessToken, 0)
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke marks a token owned by the user as revoked
+func (r *PersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE personal_access_tokens SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		utc(time.Now()), id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every active token of a user
+func (r *PersonalAccessTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE personal_access_tokens SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL`,
+		utc(time.Now()), userID)
+	return err
+}
+
+// TouchLastUsed records when a token was last used to authenticate
+func (r *PersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?`, utc(usedAt), id)
+	return err
+}
+
+func scanPersonalAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	var (
+		token      models.PersonalAccessToken
+		scopes     string
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.TokenPrefix,
+		&scopes,
+		&token.ExpiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/valueobjects"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// TokenRepository stores refresh tokens in the refresh_tokens table. Only a
+// SHA-256 hash of each token is kept, so a copy of the database cannot be
+// used to resume sessions.
+type TokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepository(db *database.Client) *TokenRepository {
+	return &TokenRepository{db: db.GetDB()}
+}
+
+// GenerateRefreshToken issues an additional token for the owner of a valid token
+func (r *TokenRepository) GenerateRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	userID, tenantID, expiresAt, err := r.lookup(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	issued := valueobjects.NewToken()
+	if err := r.StoreRefreshToken(ctx, userID, tenantID, issued, expiresAt); err != nil {
+		return "", err
+	}
+	return issued.String(), nil
+}
+
+func (r *TokenRepository) StoreRefreshToken(ctx context.Context, userID, tenantID string, token valueobjects.Token, expiresAt time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, user_id, tenant_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		hashToken(token), userID, tenantID, utc(expiresAt), utc(time.Now()),
+	)
+	return mapSQLiteError(err)
+}
+
+// VerifyRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) VerifyRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	userID, _, _, err := r.lookup(ctx, token)
+	return userID, err
+}
+
+// GetRefreshToken returns the owner of an unexpired token, or models.ErrNotFound
+func (r *TokenRepository) GetRefreshToken(ctx context.Context, token valueobjects.Token) (string, error) {
+	return r.VerifyRefreshToken(ctx, token)
+}
+
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, token valueobjects.Token) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM refresh_tokens WHERE token_hash = ?`, hashToken(token))
+	return err
+}
+
+// RotateRefreshToken replaces a valid token with a new one. The new token
+// keeps the old expiry, so rotation never extends a session. The old token
+// is deleted conditionally, so of two concurrent rotations only one succeeds.
+// The new token stays scoped to the organization of the old one.
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, oldToken valueobjects.Token) (valueobjects.Token, string, error) {
+	userID, tenantID, expiresAt, err := r.lookup(ctx, oldToken)
+	if err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM refresh_tokens WHERE token_hash = ?`, hashToken(oldToken))
+	if err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return valueobjects.Token{}, "", models.ErrNotFound
+	}
+
+	rotated := valueobjects.NewToken()
+	if err := r.StoreRefreshToken(ctx, userID, tenantID, rotated, expiresAt); err != nil {
+		return valueobjects.Token{}, "", err
+	}
+	return rotated, tenantID, nil
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM refresh_tokens WHERE user_id = ?`, userID)
+	return err
+}
+
+// ListSessions returns the user's unexpired refresh tokens, identified by a
+// fingerprint of the token hash
+func (r *TokenRepository) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT token_hash, expires_at FROM refresh_tokens
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY expires_at`, userID, utc(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*models.Session, 0)
+	for rows.Next() {
+		var hash string
+		var session models.Session
+		if err := rows.Scan(&hash, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+		session.ID = hash[:16]
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+// lookup returns the owner, organization and expiry of an unexpired token
+func (r *TokenRepository) lookup(ctx context.Context, token valueobjects.Token) (string, string, time.Time, error) {
+	var (
+		userID, tenantID string
+		expiresAt        time.Time
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT user_id, tenant_id, expires_at FROM refresh_tokens
+		WHERE token_hash = ? AND expires_at > ?`,
+		hashToken(token), utc(time.Now())).Scan(&userID, &tenantID, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", time.Time{}, models.ErrNotFound
+	}
+	return userID, tenantID, expiresAt, err
+}
+
+func hashToken(token valueobjects.Token) string {
+	sum := sha256.Sum256([]byte(token.String()))
+	return hex.EncodeToString(sum[:])
+}
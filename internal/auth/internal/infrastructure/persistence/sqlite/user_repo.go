@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const userColumns = `id, first_name, last_name, display_name, avatar_url, locale, timezone, email, password_hash, role, is_admin, is_super_admin, is_verified, is_active, created_at, updated_at`
+
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserRepository(db *database.Client) *UserRepository {
+	return &UserRepository{db: db.GetDB()}
+}
+
+// Create inserts a new user. A taken email returns models.ErrConflict.
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	if user.Role == "" {
+		user.Role = "visitor"
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO users
+			(id, first_name, last_name, role, is_admin, is_super_admin, email, password_hash, is_verified, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID,
+		user.FirstName,
+		user.LastName,
+		user.Role,
+		user.IsAdmin,
+		user.IsSuperAdmin,
+		user.Email,
+		user.PasswordHash,
+		user.IsVerified,
+		user.IsActive,
+		utc(user.CreatedAt),
+		utc(user.UpdatedAt),
+	)
+	return mapSQLiteError(err)
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE email = ? AND deleted_at IS NULL`, email)
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+// FindByIDs retrieves every existing user among the given IDs
+func (r *UserRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return []*models.User{}, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	return r.findMany(ctx, `SELECT `+userColumns+` FROM users WHERE id IN (`+placeholders+`) AND deleted_at IS NULL`, args...)
+}
+
+func (r *UserRepository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE google_id = ? AND deleted_at IS NULL`, googleID)
+}
+
+func (r *UserRepository) FindByGitHubID(ctx context.Context, githubID string) (*models.User, error) {
+	return r.findOne(ctx, `SELECT `+userColumns+` FROM users WHERE github_id = ? AND deleted_at IS NULL`, githubID)
+}
+
+// Update persists every mutable field of an existing user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now()
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET first_name = ?, last_name = ?, display_name = ?, avatar_url = ?, locale = ?, timezone = ?,
+			email = ?, password_hash = ?, role = ?, is_verified = ?, is_active = ?, updated_at = ?
+		WHERE id = ?`,
+		user.FirstName,
+		user.LastName,
+		user.DisplayName,
+		user.AvatarURL,
+		user.Locale,
+		user.Timezone,
+		user.Email,
+		user.PasswordHash,
+		user.Role,
+		user.IsVerified,
+		user.IsActive,
+		utc(user.UpdatedAt),
+		user.ID,
+	)
+	if err != nil {
+		return mapSQLiteError(err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+// ListIdentities returns the OAuth providers linked to a user
+func (r *UserRepository) ListIdentities(ctx context.Context, userID string) ([]*models.OauthProviders, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT user_id, provider, provider_id, created_at
+		FROM oauth_providers WHERE user_id = ?
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := make([]*models.OauthProviders, 0)
+	for rows.Next() {
+		var identity models.OauthProviders
+		if err := rows.Scan(&identity.UserID, &identity.Provider, &identity.ProviderID, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, &identity)
+	}
+	return identities, rows.Err()
+}
+
+// SoftDelete marks a user as deleted and deactivates the account
+func (r *UserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users SET deleted_at = ?, is_active = 0, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		utc(deletedAt), utc(deletedAt), userID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return models.ErrNotFound
+	}
+	return err
+}
+
+// ListDeletedBefore returns soft-deleted users whose deletion happened before the given time
+func (r *UserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, email, deleted_at FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		ORDER BY deleted_at
+		LIMIT ?`, utc(before), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.DeletedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// hardDeleteStatements erase a user, in order, all taking the user ID as ?1.
+// Queued mail is matched by address, so it goes before the user row.
+// Identities, tokens, memberships, devices and password history follow the
+// user row through ON DELETE CASCADE.
+var hardDeleteStatements = []string{
+	// hand organizations the user solely owns to the longest-standing admin,
+	// or member, so that they keep an owner
+	`UPDATE organization_memberships SET role = 'owner'
+		WHERE organization_id IN (
+			SELECT organization_id FROM organization_memberships WHERE user_id = ?1 AND role = 'owner')
+		AND NOT EXISTS (
+			SELECT 1 FROM organization_memberships o
+			WHERE o.organization_id = organization_memberships.organization_id AND o.user_id <> ?1 AND o.role = 'owner')
+		AND user_id = (
+			SELECT c.user_id FROM organization_memberships c
+			WHERE c.organization_id = organization_memberships.organization_id AND c.user_id <> ?1
+			ORDER BY c.role = 'admin' DESC, c.created_at, c.user_id
+			LIMIT 1)`,
+	`DELETE FROM organizations
+		WHERE id IN (SELECT organization_id FROM organization_memberships WHERE user_id = ?1)
+		AND NOT EXISTS (
+			SELECT 1 FROM organization_memberships m
+			WHERE m.organization_id = organizations.id AND m.user_id <> ?1)`,
+	`DELETE FROM mail_queue WHERE json_extract(recipient, '$.email') = (SELECT email FROM users WHERE id = ?1)`,
+	`DELETE FROM outbox_events WHERE aggregate_id = ?1`,
+	`DELETE FROM audit_events WHERE user_id = ?1`,
+	`DELETE FROM users WHERE id = ?1`,
+}
+
+// HardDelete erases a user and every row about them, in the transaction
+// carried by ctx or in one of its own
+func (r *UserRepository) HardDelete(ctx context.Context, userID string) error {
+	return inTransaction(ctx, r.db, func(q querier) error {
+		for _, stmt := range hardDeleteStatements {
+			if _, err := q.ExecContext(ctx, stmt, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findOne returns models.ErrNotFound when no row matches
+func (r *UserRepository) findOne(ctx context.Context, query string, args ...any) (*models.User, error) {
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	return user, err
+}
+
+func (r *UserRepository) findMany(ctx context.Context, query string, args ...any) ([]*models.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.DisplayName,
+		&user.AvatarURL,
+		&user.Locale,
+		&user.Timezone,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.IsAdmin,
+		&user.IsSuperAdmin,
+		&user.IsVerified,
+		&user.IsActive,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+func newTestDB(t *testing.T) *database.Client {
+	t.Helper()
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestHardDelete(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+
+	create := func(email string) *models.User {
+		user := &models.User{Email: email, PasswordHash: "hash", IsActive: true}
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	gone, kept, admin, editor := create("gone@example.com"), create("kept@example.com"), create("admin@example.com"), create("editor@example.com")
+
+	exec := func(query string, args ...any) {
+		t.Helper()
+		if _, err := users.db.ExecContext(ctx, query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	now := time.Now().UTC()
+	for _, user := range []*models.User{gone, kept} {
+		exec(`INSERT INTO oauth_providers (user_id, provider, provider_id, created_at) VALUES (?, 'github', ?, ?)`, user.ID, user.ID, now)
+		exec(`INSERT INTO verification_tokens (token, user_id, expires_at) VALUES (?, ?, ?)`, "v-"+user.ID, user.ID, now)
+		exec(`INSERT INTO refresh_tokens (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)`, "r-"+user.ID, user.ID, now, now)
+		exec(`INSERT INTO personal_access_tokens (id, user_id, name, token_hash, token_prefix, expires_at, created_at) VALUES (?, ?, 'ci', ?, 'mfp_', ?, ?)`, "p-"+user.ID, user.ID, "p-"+user.ID, now, now)
+		exec(`INSERT INTO devices (id, user_id, name, first_seen_at, last_seen_at) VALUES (?, ?, 'laptop', ?, ?)`, "d-"+user.ID, user.ID, now, now)
+		exec(`INSERT INTO audit_events (id, user_id, action, created_at) VALUES (?, ?, 'login', ?)`, "a-"+user.ID, user.ID, now)
+		exec(`INSERT INTO outbox_events (id, event_type, aggregate_id, occurred_at, next_attempt_at) VALUES (?, 'user.registered', ?, ?, ?)`, "o-"+user.ID, user.ID, now, now)
+		exec(`INSERT INTO mail_queue (id, kind, recipient, next_attempt_at, created_at) VALUES (?, 'verification', json_object('email', ?), ?, ?)`, "m-"+user.ID, user.Email, now, now)
+	}
+
+	member := func(org, userID, role string, joined time.Time) {
+		exec(`INSERT INTO organization_memberships (organization_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`, org, userID, role, joined)
+	}
+	for _, org := range []string{"solo", "handed-over", "co-owned"} {
+		exec(`INSERT INTO organizations (id, name, slug, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`, org, org, org, now, now)
+		member(org, gone.ID, "owner", now.Add(-time.Hour))
+	}
+	// the editor joined first, but an admin is preferred
+	member("handed-over", editor.ID, "editor", now.Add(-time.Minute))
+	member("handed-over", admin.ID, "admin", now)
+	member("co-owned", kept.ID, "owner", now)
+
+	if err := users.HardDelete(ctx, gone.ID); err != nil {
+		t.Fatalf("HardDelete: %v", err)
+	}
+
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := users.db.GetContext(ctx, &n, query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+	for _, table := range []string{"oauth_providers", "verification_tokens", "refresh_tokens", "personal_access_tokens", "devices", "audit_events", "organization_memberships"} {
+		if n := count(`SELECT COUNT(*) FROM `+table+` WHERE user_id = ?`, gone.ID); n != 0 {
+			t.Errorf("%s: %d rows of the deleted user left", table, n)
+		}
+		if n := count(`SELECT COUNT(*) FROM `+table+` WHERE user_id = ?`, kept.ID); n == 0 {
+			t.Errorf("%s: rows of another user were deleted", table)
+		}
+	}
+	if n := count(`SELECT COUNT(*) FROM outbox_events WHERE aggregate_id = ?`, gone.ID); n != 0 {
+		t.Errorf("outbox_events: %d rows of the deleted user left", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM mail_queue WHERE json_extract(recipient, '$.email') = ?`, gone.Email); n != 0 {
+		t.Errorf("mail_queue: %d rows of the deleted user left", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM mail_queue`); n != 1 {
+		t.Errorf("mail_queue: got %d rows, want the other user's 1", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM users WHERE id = ?`, gone.ID); n != 0 {
+		t.Error("user row left")
+	}
+
+	if n := count(`SELECT COUNT(*) FROM organizations WHERE id = 'solo'`); n != 0 {
+		t.Error("organization without members left")
+	}
+	roles := map[string]string{}
+	rows, err := users.db.QueryContext(ctx, `SELECT organization_id || '/' || user_id, role FROM organization_memberships`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, role string
+		if err := rows.Scan(&key, &role); err != nil {
+			t.Fatal(err)
+		}
+		roles[key] = role
+	}
+	for key, want := range map[string]string{
+		"handed-over/" + admin.ID:  "owner",
+		"handed-over/" + editor.ID: "editor",
+		"co-owned/" + kept.ID:      "owner",
+	} {
+		if roles[key] != want {
+			t.Errorf("%s: role %q, want %q", key, roles[key], want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/jmoiron/sqlx"
+)
+
+type VerificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewVerificationRepository(db *database.Client) *VerificationRepository {
+	return &VerificationRepository{db: db.GetDB()}
+}
+
+func (r *VerificationRepository) Create(ctx context.Context, token *models.VerificationToken) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO verification_tokens (token, purpose, user_id, expires_at)
+		VALUES (?, ?, ?, ?)`,
+		token.Token, token.Purpose, token.UserID, utc(token.ExpiresAt),
+	)
+	return mapSQLiteError(err)
+}
+
+// Get returns models.ErrNotFound for unknown tokens
+func (r *VerificationRepository) Get(ctx context.Context, token string) (*models.VerificationToken, error) {
+	t := &models.VerificationToken{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT token, purpose, user_id, expires_at FROM verification_tokens WHERE token = ?`, token).
+		Scan(&t.Token, &t.Purpose, &t.UserID, &t.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound
+	}
+	return t, err
+}
+
+func (r *VerificationRepository) Delete(ctx context.Context, token string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM verification_tokens WHERE token = ?`, token)
+	return err
+}
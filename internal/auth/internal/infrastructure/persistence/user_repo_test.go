@@ -0,0 +1,164 @@
+// Package persistence_test checks that every UserRepository adapter keeps the
+// contract documented on ports.UserRepository.
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/istiak-004/myFolio-microservices/auth/config"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/memory"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/postgres"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+// userRepositories builds one repository per adapter. Postgres needs a
+// migrated database named by AUTH_TEST_POSTGRES_HOST, _PORT, _USER,
+// _PASSWORD and _NAME and is skipped without one.
+var userRepositories = map[string]func(t *testing.T) ports.UserRepository{
+	"memory": func(t *testing.T) ports.UserRepository {
+		return memory.NewUserRepository()
+	},
+	"sqlite": func(t *testing.T) ports.UserRepository {
+		db, err := database.NewSQLite(database.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db")}, testLogger(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := sqlite.Migrate(db); err != nil {
+			t.Fatal(err)
+		}
+		return sqlite.NewUserRepository(db)
+	},
+	"postgres": func(t *testing.T) ports.UserRepository {
+		host := os.Getenv("AUTH_TEST_POSTGRES_HOST")
+		if host == "" {
+			t.Skip("AUTH_TEST_POSTGRES_HOST is not set")
+		}
+		port, _ := strconv.Atoi(os.Getenv("AUTH_TEST_POSTGRES_PORT"))
+		if port == 0 {
+			port = 5432
+		}
+		db, err := database.NewDB(&config.DatabaseConfig{
+			Host:         host,
+			Port:         port,
+			User:         os.Getenv("AUTH_TEST_POSTGRES_USER"),
+			Password:     os.Getenv("AUTH_TEST_POSTGRES_PASSWORD"),
+			Name:         os.Getenv("AUTH_TEST_POSTGRES_NAME"),
+			SSLMode:      "disable",
+			MaxOpenConns: 4,
+		}, testLogger(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return postgres.NewUserRepository(db, testLogger(t))
+	},
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	for name, newRepository := range userRepositories {
+		t.Run(name, func(t *testing.T) {
+			testUserRepositoryContract(t, newRepository(t))
+		})
+	}
+}
+
+func testUserRepositoryContract(t *testing.T, users ports.UserRepository) {
+	ctx := context.Background()
+	// unique per run, so that a shared postgres database can be reused
+	suffix := uuid.New().String()[:8]
+	create := func(t *testing.T, email string) *models.User {
+		t.Helper()
+		now := time.Now()
+		user := &models.User{
+			ID:           uuid.New().String(),
+			Email:        email + "-" + suffix + "@example.com",
+			PasswordHash: "hash",
+			Role:         "visitor",
+			IsActive:     true,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		return user
+	}
+
+	t.Run("finds a live user", func(t *testing.T) {
+		user := create(t, "live")
+		byEmail, err := users.FindByEmail(ctx, user.Email)
+		if err != nil || byEmail == nil || byEmail.ID != user.ID {
+			t.Errorf("FindByEmail = %v, %v; want user %s", byEmail, err, user.ID)
+		}
+		byID, err := users.FindByID(ctx, user.ID)
+		if err != nil || byID == nil || byID.Email != user.Email {
+			t.Errorf("FindByID = %v, %v; want user %s", byID, err, user.Email)
+		}
+	})
+
+	t.Run("reports unknown users as not found", func(t *testing.T) {
+		lookups := map[string]func() (*models.User, error){
+			"FindByEmail":    func() (*models.User, error) { return users.FindByEmail(ctx, "nobody-"+suffix+"@example.com") },
+			"FindByID":       func() (*models.User, error) { return users.FindByID(ctx, uuid.New().String()) },
+			"FindByGoogleID": func() (*models.User, error) { return users.FindByGoogleID(ctx, "google-"+suffix) },
+			"FindByGitHubID": func() (*models.User, error) { return users.FindByGitHubID(ctx, "github-"+suffix) },
+		}
+		for name, lookup := range lookups {
+			if user, err := lookup(); user != nil || !errors.Is(err, models.ErrNotFound) {
+				t.Errorf("%s = %v, %v; want nil, models.ErrNotFound", name, user, err)
+			}
+		}
+	})
+
+	t.Run("hides soft-deleted users", func(t *testing.T) {
+		user := create(t, "deleted")
+		if err := users.SoftDelete(ctx, user.ID, time.Now()); err != nil {
+			t.Fatalf("SoftDelete: %v", err)
+		}
+		if found, err := users.FindByEmail(ctx, user.Email); found != nil || !errors.Is(err, models.ErrNotFound) {
+			t.Errorf("FindByEmail = %v, %v; want nil, models.ErrNotFound", found, err)
+		}
+		if found, err := users.FindByID(ctx, user.ID); found != nil || !errors.Is(err, models.ErrNotFound) {
+			t.Errorf("FindByID = %v, %v; want nil, models.ErrNotFound", found, err)
+		}
+		if err := users.SoftDelete(ctx, user.ID, time.Now()); !errors.Is(err, models.ErrNotFound) {
+			t.Errorf("second SoftDelete = %v, want models.ErrNotFound", err)
+		}
+	})
+
+	t.Run("rejects a taken email", func(t *testing.T) {
+		user := create(t, "taken")
+		again := *user
+		again.ID = uuid.New().String()
+		if err := users.Create(ctx, &again); !errors.Is(err, models.ErrConflict) {
+			t.Errorf("Create = %v, want models.ErrConflict", err)
+		}
+	})
+
+	t.Run("does not update unknown users", func(t *testing.T) {
+		ghost := &models.User{ID: uuid.New().String(), Email: "ghost-" + suffix + "@example.com"}
+		if err := users.Update(ctx, ghost); !errors.Is(err, models.ErrNotFound) {
+			t.Errorf("Update = %v, want models.ErrNotFound", err)
+		}
+	})
+}
@@ -2,9 +2,12 @@ package token
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -137,3 +140,26 @@ func (r *RefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID string)
 
 	return nil
 }
+
+// ListSessions returns the user's unexpired refresh tokens. Sessions are
+// identified by a fingerprint so the tokens themselves are never exposed.
+func (r *RefreshTokenRepo) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	userKeyString := r.prefix + "user_tokens:" + userID
+
+	now := fmt.Sprintf("%d", time.Now().Unix())
+	entries, err := r.redisClient.ZRangeByScoreWithScores(ctx, userKeyString, &redis.ZRangeBy{Min: now, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user tokens: %w", err)
+	}
+
+	sessions := make([]*models.Session, 0, len(entries))
+	for _, entry := range entries {
+		token, _ := entry.Member.(string)
+		sum := sha256.Sum256([]byte(token))
+		sessions = append(sessions, &models.Session{
+			ID:        hex.EncodeToString(sum[:8]),
+			ExpiresAt: time.Unix(int64(entry.Score), 0),
+		})
+	}
+	return sessions, nil
+}
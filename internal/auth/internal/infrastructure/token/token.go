@@ -2,6 +2,7 @@ package token
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -28,9 +29,22 @@ type JWTTokenManager struct {
 }
 
 type CustomClaims struct {
-	UserID               string `json:"user_id"`
-	Role                 string `json:"role"`
-	jwt.RegisteredClaims        // embedded standard claims
+	UserID               string      `json:"user_id"`
+	Role                 string      `json:"role"`
+	TenantID             string      `json:"tid,omitempty"` // organization the token is scoped to
+	TenantRole           string      `json:"trl,omitempty"` // role within that organization
+	Actor                *ActorClaim `json:"act,omitempty"` // set while an admin impersonates the subject
+	jwt.RegisteredClaims             // embedded standard claims
+}
+
+// ActorClaim is the RFC 8693 "act" claim identifying the party acting on behalf of the subject
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// IsImpersonated reports whether the token was issued for an impersonation session
+func (c *CustomClaims) IsImpersonated() bool {
+	return c.Actor != nil && c.Actor.Subject != ""
 }
 
 // NewTokenManager creates a new TokenManager with the given parameters
@@ -52,6 +66,23 @@ func NewTokenManager(privateKeyPath, publicKeyPath, issuer string, accessTTL, re
 	}, nil
 }
 
+// NewEphemeralTokenManager creates a TokenManager with an RSA key pair
+// generated at startup. Tokens it signs stop verifying when the process
+// exits, so it is only meant for local development and tests.
+func NewEphemeralTokenManager(issuer string, accessTTL, refreshTTL time.Duration) (*JWTTokenManager, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return &JWTTokenManager{
+		privateKey: privateKey,
+		publicKey:  &privateKey.PublicKey,
+		issuer:     issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}, nil
+}
+
 // GenerateAccessToken generates a new access token for the given user ID and role
 func (tm *JWTTokenManager) GenerateAccessToken(userID, role string) (string, string, error) {
 	jti := uuid.New().String()
@@ -74,6 +105,54 @@ func (tm *JWTTokenManager) GenerateAccessToken(userID, role string) (string, str
 	return signed, jti, nil
 }
 
+// GenerateTenantAccessToken generates an access token scoped to an organization
+func (tm *JWTTokenManager) GenerateTenantAccessToken(userID, role, tenantID, tenantRole string) (string, string, error) {
+	jti := uuid.New().String()
+	claims := CustomClaims{
+		UserID:     userID,
+		Role:       role,
+		TenantID:   tenantID,
+		TenantRole: tenantRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userID,
+			Issuer:    tm.issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tm.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	signed, err := token.SignedString(tm.privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, jti, nil
+}
+
+// GenerateImpersonationToken generates a short-lived access token for userID
+// that records actorID as the acting party in the "act" claim
+func (tm *JWTTokenManager) GenerateImpersonationToken(userID, role, actorID string, ttl time.Duration) (string, string, error) {
+	jti := uuid.New().String()
+	claims := CustomClaims{
+		UserID: userID,
+		Role:   role,
+		Actor:  &ActorClaim{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userID,
+			Issuer:    tm.issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	signed, err := token.SignedString(tm.privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+	return signed, jti, nil
+}
+
 // GenerateRefreshToken generates a new refresh token for the given user ID
 func (tm *JWTTokenManager) GenerateRefreshToken(userID string) (string, string, error) {
 	jti := uuid.New().String()
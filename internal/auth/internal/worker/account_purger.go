@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultPurgeInterval is how often deleted accounts are checked for purging
+const DefaultPurgeInterval = time.Hour
+
+// AccountPurger periodically hard-deletes accounts whose deletion grace period has ended
+type AccountPurger struct {
+	accounts ports.AccountService
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func NewAccountPurger(accounts ports.AccountService, interval time.Duration, logger *logger.Logger) *AccountPurger {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	return &AccountPurger{
+		accounts: accounts,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run purges on every tick until the context is cancelled
+func (p *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *AccountPurger) purge(ctx context.Context) {
+	purged, err := p.accounts.PurgeDeleted(ctx)
+	if err != nil {
+		p.logger.Error("Failed to purge deleted accounts", zap.Error(err), zap.Int("purged", purged))
+		return
+	}
+	if purged > 0 {
+		p.logger.Info("Purged deleted accounts", zap.Int("purged", purged))
+	}
+}
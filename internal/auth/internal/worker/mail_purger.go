@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultMailPurgeInterval is how often sent and dead emails are checked for deletion
+const DefaultMailPurgeInterval = time.Hour
+
+// MailPurger periodically deletes sent and dead emails past their retention,
+// so that the links they carry do not linger in the database
+type MailPurger struct {
+	queue    ports.MailQueue
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func NewMailPurger(queue ports.MailQueue, interval time.Duration, logger *logger.Logger) *MailPurger {
+	if interval <= 0 {
+		interval = DefaultMailPurgeInterval
+	}
+	return &MailPurger{
+		queue:    queue,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run purges on every tick until the context is cancelled
+func (p *MailPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *MailPurger) purge(ctx context.Context) {
+	purged, err := p.queue.PurgeFinished(ctx)
+	if err != nil {
+		p.logger.Error("Failed to purge finished mail", zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		p.logger.Info("Purged finished mail", zap.Int("purged", purged))
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultMailPollInterval is how often the mail queue is checked for due emails
+const DefaultMailPollInterval = 5 * time.Second
+
+// MailSender delivers queued emails in the background
+type MailSender struct {
+	queue    ports.MailQueue
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func NewMailSender(queue ports.MailQueue, interval time.Duration, logger *logger.Logger) *MailSender {
+	if interval <= 0 {
+		interval = DefaultMailPollInterval
+	}
+	return &MailSender{
+		queue:    queue,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run delivers on every tick until the context is cancelled. Batches are
+// drained back to back while emails keep coming.
+func (s *MailSender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			attempted, err := s.queue.DeliverDue(ctx)
+			if err != nil {
+				s.logger.Error("Failed to deliver queued mail", zap.Error(err))
+			}
+			if err != nil || attempted == 0 {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRelayInterval is how often the outbox is polled for new events
+	DefaultRelayInterval = time.Second
+	// DefaultRelayBatchSize is how many events are claimed at once
+	DefaultRelayBatchSize = 100
+
+	maxRelayBackoff = 10 * time.Minute
+	// relayLease is how long claimed events are hidden from other relays
+	// while they are published
+	relayLease = 5 * time.Minute
+)
+
+// OutboxRelay publishes events from the outbox to the broker. An event is only
+// marked published after the broker accepted it, so delivery is at least once:
+// a crash between the two steps publishes the event again once its lease runs out.
+type OutboxRelay struct {
+	transactor ports.Transactor
+	outbox     ports.OutboxRepository
+	publisher  ports.EventPublisher
+	interval   time.Duration
+	batchSize  int
+	logger     *logger.Logger
+}
+
+func NewOutboxRelay(
+	transactor ports.Transactor,
+	outbox ports.OutboxRepository,
+	publisher ports.EventPublisher,
+	interval time.Duration,
+	batchSize int,
+	logger *logger.Logger,
+) *OutboxRelay {
+	if interval <= 0 {
+		interval = DefaultRelayInterval
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultRelayBatchSize
+	}
+	return &OutboxRelay{
+		transactor: transactor,
+		outbox:     outbox,
+		publisher:  publisher,
+		interval:   interval,
+		batchSize:  batchSize,
+		logger:     logger,
+	}
+}
+
+// Run relays on every tick until the context is cancelled. A full batch is
+// followed immediately by the next one so a backlog drains quickly.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			relayed, err := r.relay(ctx)
+			if err != nil {
+				r.logger.Error("Failed to relay outbox events", zap.Error(err))
+			}
+			if err != nil || relayed < r.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes one batch and reports how many events it handled. No
+// transaction is held open while publishing: the batch is claimed in one short
+// transaction and the outcome of each event is recorded on its own.
+func (r *OutboxRelay) relay(ctx context.Context) (int, error) {
+	messages, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, message := range messages {
+		if err := r.publish(ctx, message); err != nil {
+			// the lease runs out and the event is published again
+			errs = append(errs, fmt.Errorf("failed to record delivery of event %s: %w", message.Event.ID, err))
+		}
+	}
+	return len(messages), errors.Join(errs...)
+}
+
+// claim leases a batch of due events to this relay
+func (r *OutboxRelay) claim(ctx context.Context) ([]*models.OutboxMessage, error) {
+	var messages []*models.OutboxMessage
+	err := r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := r.outbox.ListPending(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+		leasedUntil := time.Now().Add(relayLease)
+		for _, message := range due {
+			if err := r.outbox.Lease(ctx, message.Event.ID, leasedUntil); err != nil {
+				return err
+			}
+		}
+		messages = due
+		return nil
+	})
+	return messages, err
+}
+
+// publish hands a claimed event to the broker and records the outcome
+func (r *OutboxRelay) publish(ctx context.Context, message *models.OutboxMessage) error {
+	event := message.Event
+	err := r.publisher.Publish(ctx, event)
+	if err == nil {
+		return r.outbox.MarkPublished(ctx, event.ID, time.Now())
+	}
+	next := time.Now().Add(relayBackoff(message.Attempts + 1))
+	r.logger.Warn("Failed to publish outbox event",
+		zap.String("event_id", event.ID),
+		zap.String("event_type", event.Type),
+		zap.Int("attempts", message.Attempts+1),
+		zap.Time("next_attempt_at", next),
+		zap.Error(err),
+	)
+	return r.outbox.MarkFailed(ctx, event.ID, err.Error(), next)
+}
+
+// relayBackoff doubles the delay after every failed attempt, starting at one second
+func relayBackoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return maxRelayBackoff
+	}
+	return min(time.Second<<(attempts-1), maxRelayBackoff)
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/models"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/domain/ports"
+	"github.com/istiak-004/myFolio-microservices/auth/internal/infrastructure/persistence/sqlite"
+	"github.com/istiak-004/myFolio-microservices/pkg/database"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+)
+
+// peekingPublisher looks at the outbox from a transaction of its own while it
+// publishes, which waits for, and times out on, a transaction held by the relay
+type peekingPublisher struct {
+	transactor ports.Transactor
+	outbox     ports.OutboxRepository
+	fail       map[string]bool
+	published  []string
+	peekErrs   []error
+	visible    int
+}
+
+func (p *peekingPublisher) Publish(_ context.Context, event models.DomainEvent) error {
+	err := p.transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		pending, err := p.outbox.ListPending(ctx, 100)
+		p.visible += len(pending)
+		return err
+	})
+	p.peekErrs = append(p.peekErrs, err)
+	if p.fail[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func TestOutboxRelayPublishesLeasedEventsOutsideTheTransaction(t *testing.T) {
+	log, err := logger.New("test", logger.Config{Level: "error", Format: "json", Outputs: []string{"stderr"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.NewSQLite(database.SQLiteConfig{
+		Path:         filepath.Join(t.TempDir(), "auth.db"),
+		MaxOpenConns: 4,
+		BusyTimeout:  200 * time.Millisecond,
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	transactor := sqlite.NewTransactor(db, log)
+	outbox := sqlite.NewOutboxRepository(db)
+
+	ctx := context.Background()
+	for _, id := range []string{"registered", "deleted"} {
+		if err := outbox.Add(ctx, models.DomainEvent{ID: id, Type: "user." + id, AggregateID: "ada", OccurredAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publisher := &peekingPublisher{transactor: transactor, outbox: outbox, fail: map[string]bool{"deleted": true}}
+	relay := NewOutboxRelay(transactor, outbox, publisher, time.Second, 10, log)
+	relayed, err := relay.relay(ctx)
+	if err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if relayed != 2 {
+		t.Errorf("relayed %d events, want 2", relayed)
+	}
+	for _, err := range publisher.peekErrs {
+		if err != nil {
+			t.Errorf("outbox locked while publishing: %v", err)
+		}
+	}
+	if publisher.visible != 0 {
+		t.Errorf("%d events were visible to other relays while leased", publisher.visible)
+	}
+	if len(publisher.published) != 1 || publisher.published[0] != "registered" {
+		t.Errorf("published %v, want [registered]", publisher.published)
+	}
+
+	// neither the published event nor the failed one, which backs off, is due again
+	var pending []*models.OutboxMessage
+	if err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		pending, err = outbox.ListPending(ctx, 10)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("%d events due right after relaying, want 0", len(pending))
+	}
+}
+
+func TestRelayBackoffDoublesUpToTheCap(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{10, 512 * time.Second},
+		{11, maxRelayBackoff},
+		{64, maxRelayBackoff},
+	}
+	for _, tt := range tests {
+		if got := relayBackoff(tt.attempts); got != tt.want {
+			t.Errorf("relayBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
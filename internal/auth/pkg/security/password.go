@@ -3,7 +3,7 @@ package security
 import (
 	"errors"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
 )
 
 var (
@@ -15,10 +15,16 @@ func HashPassword(password string) (string, error) {
 	if len(password) < 8 {
 		return "", ErrPasswordTooShort
 	}
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return hashing.Default().Hash(password)
 }
 
 func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	ok, err := hashing.Default().Verify(password, hash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPasswordMismatch
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testConfig is a service config exercising nested sections, secrets and validation
+type testConfig struct {
+	Database struct {
+		Host     string `mapstructure:"host"`
+		Password string `mapstructure:"password" secret:"true"`
+	} `mapstructure:"database"`
+	Log struct {
+		Level string `mapstructure:"level"`
+	} `mapstructure:"log"`
+	Origins []string          `mapstructure:"origins"`
+	Tokens  map[string]string `mapstructure:"tokens" secret:"true"`
+}
+
+func (c *testConfig) Validate() error {
+	if c.Database.Host == "" {
+		return errors.New("database.host is required")
+	}
+	return nil
+}
+
+// writeFile writes a file into dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// configDir returns a directory holding config.yml with the given content
+func configDir(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "config.yml", content)
+	return dir
+}
+
+const baseConfig = `
+database:
+  host: localhost
+  password: postgres
+log:
+  level: info
+origins:
+  - http://localhost:3000
+`
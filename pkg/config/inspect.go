@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Source is where the effective value of a config key came from
+type Source string
+
+const (
+	SourceDefault Source = "default" // built-in default
+	SourceFile    Source = "file"    // config.yml
+	SourceOverlay Source = "overlay" // config.<env>.yml
+	SourceLocal   Source = "local"   // config.local.yml
+	SourceEnv     Source = "env"     // environment variable or its _FILE form
+)
+
+// Report is the effective config of a service with the origin of each value,
+// as printed by a service's config command
+type Report struct {
+	Files []string // config files merged, in order
+	Keys  []ReportKey
+	Err   error // why the config is invalid, nil if it is valid
+}
+
+// ReportKey is one effective config value. Values of secret fields are
+// replaced with Redacted.
+type ReportKey struct {
+	Key    string
+	Value  any
+	Source Source
+}
+
+// Inspect loads the config like LoadConfig but, rather than failing on an
+// invalid config, reports why it is invalid next to the merged values. Only
+// errors reading the files or secrets are returned.
+func Inspect[T Config](prefix, configPath string, target T, opts ...Option) (*Report, error) {
+	l, err := readLayers(prefix, configPath, reflect.TypeOf(target), newLoadOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for _, file := range l.files {
+		report.Files = append(report.Files, file.path)
+	}
+
+	secrets := make(map[string]bool)
+	for _, leaf := range leafFields(reflect.TypeOf(target), "") {
+		if leaf.field.Tag.Get("secret") == "true" {
+			secrets[strings.ToLower(leaf.key)] = true
+		}
+	}
+
+	for _, key := range l.v.AllKeys() {
+		if !l.v.IsSet(key) {
+			continue
+		}
+		value := l.v.Get(key)
+		if isSecretKey(secrets, key) {
+			value = redactReportValue(value)
+		}
+		report.Keys = append(report.Keys, ReportKey{Key: key, Value: value, Source: l.source(prefix, key)})
+	}
+	sort.Slice(report.Keys, func(i, j int) bool { return report.Keys[i].Key < report.Keys[j].Key })
+
+	if _, target, err = decode(l.v, target); err != nil {
+		report.Err = err
+	} else if err := target.Validate(); err != nil {
+		report.Err = err
+	}
+	return report, nil
+}
+
+// source is the layer that set key: the environment wins over the files,
+// later files over earlier ones
+func (l *layers) source(prefix, key string) Source {
+	// a variable can also set a whole section or map, e.g. AUTH_GRPC_SERVICE_TOKENS
+	for k := key; k != ""; {
+		envName := strings.ToUpper(prefix + "_" + strings.ReplaceAll(k, ".", "_"))
+		if value, ok := os.LookupEnv(envName); ok && value != "" {
+			return SourceEnv
+		}
+		if _, ok := os.LookupEnv(envName + fileEnvSuffix); ok {
+			return SourceEnv
+		}
+		dot := strings.LastIndex(k, ".")
+		if dot < 0 {
+			break
+		}
+		k = k[:dot]
+	}
+	// maps are reported as one key but files set their entries
+	for i := len(l.files) - 1; i >= 0; i-- {
+		for fileKey := range l.files[i].keys {
+			if fileKey == key || strings.HasPrefix(fileKey, key+".") {
+				return l.files[i].source
+			}
+		}
+	}
+	return SourceDefault
+}
+
+// redactReportValue hides a secret value, or each value of a map of secrets
+// so that its keys stay visible
+func redactReportValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, entry := range v {
+			redacted[k] = redactReportValue(entry)
+		}
+		return redacted
+	case string:
+		if v == "" {
+			return v
+		}
+	}
+	return Redacted
+}
+
+// isSecretKey reports whether key is a secret field or lies within one, as
+// the entries of a map of tokens do
+func isSecretKey(secrets map[string]bool, key string) bool {
+	for k := key; ; {
+		if secrets[k] {
+			return true
+		}
+		dot := strings.LastIndex(k, ".")
+		if dot < 0 {
+			return false
+		}
+		k = k[:dot]
+	}
+}
+
+// Print writes the report as a table of keys, values and sources followed by
+// the outcome of validation
+func (r *Report) Print(w io.Writer) error {
+	fmt.Fprintf(w, "Files: %s\n\n", strings.Join(r.Files, ", "))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, key := range r.Keys {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key.Key, formatValue(key.Value), key.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if r.Err != nil {
+		_, err := fmt.Fprintf(w, "\nInvalid: %v\n", r.Err)
+		return err
+	}
+	_, err := fmt.Fprintln(w, "\nValid")
+	return err
+}
+
+// formatValue renders a value as JSON so strings, lists and maps are told apart
+func formatValue(value any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectReportsTheSourceOfEachValue(t *testing.T) {
+	dir := configDir(t, baseConfig+"tokens:\n  gateway: s3cret\n  blog: \"\"\n")
+	writeFile(t, dir, "config.production.yml", "log:\n  level: warn\n")
+	writeFile(t, dir, "config.local.yml", "origins:\n  - http://localhost:5173\n")
+	t.Setenv("SVC_ENV", "production")
+	t.Setenv("SVC_DATABASE_HOST", "db.internal")
+
+	report, err := Inspect("svc", dir, &testConfig{})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	wantFiles := []string{
+		filepath.Join(dir, "config.yml"),
+		filepath.Join(dir, "config.production.yml"),
+		filepath.Join(dir, "config.local.yml"),
+	}
+	if strings.Join(report.Files, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("files = %v, want %v", report.Files, wantFiles)
+	}
+	if report.Err != nil {
+		t.Errorf("Err = %v, want a valid config", report.Err)
+	}
+
+	keys := make(map[string]ReportKey)
+	for _, key := range report.Keys {
+		keys[key.Key] = key
+	}
+	tests := []struct {
+		key    string
+		source Source
+		value  any
+	}{
+		{"database.host", SourceEnv, "db.internal"},
+		{"database.password", SourceFile, Redacted},
+		{"log.level", SourceOverlay, "warn"},
+		{"app_name", SourceDefault, "svc"},
+	}
+	for _, tt := range tests {
+		got, ok := keys[tt.key]
+		if !ok {
+			t.Errorf("%s missing from the report", tt.key)
+			continue
+		}
+		if got.Source != tt.source || got.Value != tt.value {
+			t.Errorf("%s = %v from %s, want %v from %s", tt.key, got.Value, got.Source, tt.value, tt.source)
+		}
+	}
+	if got := keys["origins"]; got.Source != SourceLocal {
+		t.Errorf("origins from %s, want %s", got.Source, SourceLocal)
+	}
+	tokens, _ := keys["tokens"].Value.(map[string]any)
+	if tokens["gateway"] != Redacted || tokens["blog"] != "" {
+		t.Errorf("tokens = %v, want set tokens redacted and their names kept", keys["tokens"].Value)
+	}
+}
+
+func TestInspectReportsInvalidConfigs(t *testing.T) {
+	dir := configDir(t, strings.Replace(baseConfig, "host: localhost", "host: \"\"", 1))
+
+	report, err := Inspect("svc", dir, &testConfig{})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if report.Err == nil {
+		t.Fatal("Err = nil, want why the config is invalid")
+	}
+
+	var out bytes.Buffer
+	if err := report.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"KEY", "log.level", `"info"`, "Invalid: database.host is required"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "postgres") {
+		t.Errorf("output leaks the database password:\n%s", out.String())
+	}
+}
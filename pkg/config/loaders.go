@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -9,26 +13,143 @@ import (
 
 // Load loads the base and service-specific config into the target object.
 // Usage: Load("auth", &AuthConfig{})
-// LoadConfig loads config from a specific directory path
-func LoadConfig[T Config](prefix, configPath string, target T) (Base, T, error) {
+// LoadConfig loads config from a specific directory path.
+//
+// config.yml is the base. The overlay for the environment named by ENV (set
+// in config.yml or by <PREFIX>_ENV), config.<env>.yml, and then the untracked
+// config.local.yml are merged over it when they exist; nested sections merge
+// key by key, lists are replaced. Environment variables override all files.
+//
+// Any key can be set from a file by pointing <ENV>_FILE at it, and any value
+// can be a secret://<provider>/<name> reference; file and env providers are
+// registered by default, others can be added with WithSecretProvider.
+func LoadConfig[T Config](prefix, configPath string, target T, opts ...Option) (Base, T, error) {
+	return load(prefix, configPath, target, newLoadOptions(opts))
+}
+
+// Config file names within the config directory
+const (
+	baseConfigName  = "config"
+	localConfigFile = "config.local.yml"
+)
+
+// overlayConfigFile is the file name of the overlay of an environment
+func overlayConfigFile(env string) string {
+	return "config." + env + ".yml"
+}
+
+// newViper returns a viper instance reading config.yml from configPath
+func newViper(prefix, configPath string) *viper.Viper {
 	v := viper.New()
-	v.SetConfigName("config")
+	v.SetConfigName(baseConfigName)
 	v.SetConfigType("yaml")
 	v.AddConfigPath(configPath)
 	v.AutomaticEnv()
 	v.SetEnvPrefix(strings.ToUpper(prefix))
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	return v
+}
+
+// configFile is a config file that was merged and the keys it set
+type configFile struct {
+	path   string
+	source Source
+	keys   map[string]bool
+}
+
+// layers is viper with every config file merged, the environment bound and
+// secrets resolved
+type layers struct {
+	v     *viper.Viper
+	files []configFile
+}
+
+func readLayers(prefix, configPath string, t reflect.Type, options *loadOptions) (*layers, error) {
+	v := newViper(prefix, configPath)
+
+	// AutomaticEnv only sees keys viper already knows, so register every
+	// key of the target, nested ones included, e.g. AUTH_DATABASE_HOST
+	keys := bindEnvs(v, t, "")
 
 	// Safe defaults
 	v.SetDefault("LOG_LEVEL", "info")
 	v.SetDefault("ENV", "development")
 	v.SetDefault("APP_NAME", prefix)
 
-	// Optional config file
 	if err := v.ReadInConfig(); err != nil {
-		return Base{}, target, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	base := configFile{path: v.ConfigFileUsed(), source: SourceFile, keys: map[string]bool{}}
+	for _, key := range v.AllKeys() {
+		if v.InConfig(key) {
+			base.keys[key] = true
+		}
+	}
+	l := &layers{v: v, files: []configFile{base}}
+
+	overlays := []configFile{{path: filepath.Join(configPath, localConfigFile), source: SourceLocal}}
+	if env := v.GetString("ENV"); env != "" {
+		overlay := configFile{path: filepath.Join(configPath, overlayConfigFile(env)), source: SourceOverlay}
+		overlays = append([]configFile{overlay}, overlays...)
+	}
+	for _, file := range overlays {
+		settings, keys, err := readConfigFile(file.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", file.path, err)
+		}
+		if err := v.MergeConfigMap(settings); err != nil {
+			return nil, fmt.Errorf("failed to merge config file %s: %w", file.path, err)
+		}
+		file.keys = keys
+		l.files = append(l.files, file)
+	}
+
+	if err := applySecretFiles(v, prefix, keys); err != nil {
+		return nil, fmt.Errorf("failed to read secret file: %w", err)
+	}
+	if err := resolveSecretRefs(v, options.providers); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// readConfigFile reads a YAML file into its settings and the keys it sets
+func readConfigFile(path string) (map[string]any, map[string]bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, err
+	}
+	keys := make(map[string]bool)
+	for _, key := range v.AllKeys() {
+		keys[key] = true
+	}
+	return v.AllSettings(), keys, nil
+}
+
+func load[T Config](prefix, configPath string, target T, options *loadOptions) (Base, T, error) {
+	l, err := readLayers(prefix, configPath, reflect.TypeOf(target), options)
+	if err != nil {
+		return Base{}, target, err
+	}
+	base, target, err := decode(l.v, target)
+	if err != nil {
+		return Base{}, target, err
 	}
+	if err := target.Validate(); err != nil {
+		return Base{}, target, fmt.Errorf("service config invalid: %w", err)
+	}
+	return base, target, nil
+}
 
+func decode[T Config](v *viper.Viper, target T) (Base, T, error) {
 	var base Base
 	if err := v.Unmarshal(&base); err != nil {
 		return Base{}, target, fmt.Errorf("base config load failed: %w", err)
@@ -37,10 +158,69 @@ func LoadConfig[T Config](prefix, configPath string, target T) (Base, T, error)
 	if err := v.Unmarshal(&target); err != nil {
 		return Base{}, target, fmt.Errorf("service config load failed: %w", err)
 	}
+	return base, target, nil
+}
 
-	// if err := target.Validate(); err != nil {
-	// 	return Base{}, target, fmt.Errorf("service config invalid: %w", err)
-	// }
+// bindEnvs registers the key of every leaf field of t with viper and returns
+// the keys
+func bindEnvs(v *viper.Viper, t reflect.Type, prefix string) []string {
+	var keys []string
+	for _, leaf := range leafFields(t, prefix) {
+		_ = v.BindEnv(leaf.key)
+		keys = append(keys, leaf.key)
+	}
+	return keys
+}
 
-	return base, target, nil
+// leafField is a field of a config struct that holds a value rather than a
+// nested section
+type leafField struct {
+	key   string
+	field reflect.StructField
+}
+
+// leafFields returns every leaf field of t with its key, following
+// mapstructure tags. Squashed structs share their parent's prefix.
+func leafFields(t reflect.Type, prefix string) []leafField {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var leaves []leafField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := name
+		if strings.Contains(opts, "squash") || field.Anonymous {
+			key = strings.TrimSuffix(prefix, ".")
+		} else if prefix != "" {
+			key = prefix + name
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType.PkgPath() != "time" {
+			next := key + "."
+			if key == "" {
+				next = ""
+			}
+			leaves = append(leaves, leafFields(fieldType, next)...)
+			continue
+		}
+		leaves = append(leaves, leafField{key: key, field: field})
+	}
+	return leaves
 }
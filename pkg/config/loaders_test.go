@@ -0,0 +1,125 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOverlaysMergeOverTheBase(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         string // SVC_ENV
+		overlay     string // config.production.yml
+		local       string // config.local.yml
+		wantHost    string
+		wantPass    string
+		wantLevel   string
+		wantOrigins []string
+	}{
+		{
+			name:        "base only",
+			wantHost:    "localhost",
+			wantPass:    "postgres",
+			wantLevel:   "info",
+			wantOrigins: []string{"http://localhost:3000"},
+		},
+		{
+			name:        "overlay of another environment is ignored",
+			overlay:     "log:\n  level: warn\n",
+			wantHost:    "localhost",
+			wantPass:    "postgres",
+			wantLevel:   "info",
+			wantOrigins: []string{"http://localhost:3000"},
+		},
+		{
+			name:        "overlay merges key by key and replaces lists",
+			env:         "production",
+			overlay:     "database:\n  host: db.internal\norigins:\n  - https://myfolio.dev\n",
+			wantHost:    "db.internal",
+			wantPass:    "postgres",
+			wantLevel:   "info",
+			wantOrigins: []string{"https://myfolio.dev"},
+		},
+		{
+			name:        "local wins over the overlay",
+			env:         "production",
+			overlay:     "database:\n  host: db.internal\nlog:\n  level: warn\n",
+			local:       "database:\n  host: 127.0.0.1\n",
+			wantHost:    "127.0.0.1",
+			wantPass:    "postgres",
+			wantLevel:   "warn",
+			wantOrigins: []string{"http://localhost:3000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := configDir(t, baseConfig)
+			if tt.env != "" {
+				t.Setenv("SVC_ENV", tt.env)
+			}
+			if tt.overlay != "" {
+				writeFile(t, dir, "config.production.yml", tt.overlay)
+			}
+			if tt.local != "" {
+				writeFile(t, dir, "config.local.yml", tt.local)
+			}
+
+			base, cfg, err := LoadConfig("svc", dir, &testConfig{})
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.Database.Host != tt.wantHost || cfg.Database.Password != tt.wantPass {
+				t.Errorf("database = %+v, want host %q and password %q", cfg.Database, tt.wantHost, tt.wantPass)
+			}
+			if cfg.Log.Level != tt.wantLevel {
+				t.Errorf("log.level = %q, want %q", cfg.Log.Level, tt.wantLevel)
+			}
+			if !slices.Equal(cfg.Origins, tt.wantOrigins) {
+				t.Errorf("origins = %v, want %v", cfg.Origins, tt.wantOrigins)
+			}
+			if want := tt.env; want != "" && base.Env != want {
+				t.Errorf("ENV = %q, want %q", base.Env, want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentWinsOverEveryFile(t *testing.T) {
+	dir := configDir(t, baseConfig+"ENV: staging\n")
+	writeFile(t, dir, "config.staging.yml", "database:\n  host: staging.internal\n")
+	writeFile(t, dir, "config.local.yml", "database:\n  host: 127.0.0.1\n")
+	t.Setenv("SVC_DATABASE_HOST", "from-env")
+
+	base, cfg, err := LoadConfig("svc", dir, &testConfig{})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if base.Env != "staging" {
+		t.Errorf("ENV = %q, want staging from config.yml", base.Env)
+	}
+	if cfg.Database.Host != "from-env" {
+		t.Errorf("host = %q, want the environment variable", cfg.Database.Host)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	t.Run("no config.yml", func(t *testing.T) {
+		if _, _, err := LoadConfig("svc", t.TempDir(), &testConfig{}); err == nil {
+			t.Error("LoadConfig succeeded without config.yml")
+		}
+	})
+	t.Run("malformed overlay", func(t *testing.T) {
+		dir := configDir(t, baseConfig)
+		writeFile(t, dir, "config.local.yml", "database: [")
+		if _, _, err := LoadConfig("svc", dir, &testConfig{}); err == nil {
+			t.Error("LoadConfig succeeded with a malformed config.local.yml")
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		dir := configDir(t, baseConfig)
+		writeFile(t, dir, "config.local.yml", "database:\n  host: \"\"\n")
+		if _, _, err := LoadConfig("svc", dir, &testConfig{}); err == nil {
+			t.Error("LoadConfig succeeded with an invalid config")
+		}
+	})
+}
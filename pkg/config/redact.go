@@ -0,0 +1,68 @@
+package config
+
+import "reflect"
+
+// Redacted replaces secret values in the output of Redact
+const Redacted = "[REDACTED]"
+
+// Redact returns a copy of cfg that is safe to log. Fields tagged
+// `secret:"true"` are replaced with Redacted: strings directly, and the
+// values of maps of strings, such as caller name to token. Unset secrets are
+// left empty so it stays visible that they are missing. cfg is not modified.
+func Redact[T any](cfg T) T {
+	redacted, _ := redactValue(reflect.ValueOf(&cfg).Elem(), false).Interface().(T)
+	return redacted
+}
+
+func redactValue(v reflect.Value, secret bool) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(redactValue(v.Elem(), secret))
+		return out
+
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			out.Field(i).Set(redactValue(v.Field(i), field.Tag.Get("secret") == "true"))
+		}
+		return out
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), redactValue(iter.Value(), secret))
+		}
+		return out
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactValue(v.Index(i), secret))
+		}
+		return out
+
+	case reflect.String:
+		if secret && v.Len() > 0 {
+			out := reflect.New(v.Type()).Elem()
+			out.SetString(Redacted)
+			return out
+		}
+	}
+	return v
+}
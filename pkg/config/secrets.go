@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SecretScheme prefixes config values that are references to a secret rather
+// than the secret itself: secret://<provider>/<name>, e.g.
+// secret://file/db_password or secret://env/SMTP_PASSWORD.
+const SecretScheme = "secret://"
+
+// fileEnvSuffix marks environment variables holding the path of a file with
+// the value, as Docker and Kubernetes secrets are mounted
+const fileEnvSuffix = "_FILE"
+
+// SecretProvider resolves the name part of a secret:// reference to its value
+type SecretProvider interface {
+	Resolve(name string) (string, error)
+}
+
+// FileSecretProvider reads secrets from files. Relative names are resolved
+// against Dir; trailing newlines are stripped.
+type FileSecretProvider struct {
+	Dir string
+}
+
+// DefaultSecretDir is where Docker and Kubernetes mount secrets by convention
+const DefaultSecretDir = "/run/secrets"
+
+func (p FileSecretProvider) Resolve(name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Dir, name)
+	}
+	return readSecretFile(path)
+}
+
+// EnvSecretProvider reads secrets from environment variables
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) Resolve(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// Option customizes LoadConfig
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	providers map[string]SecretProvider
+}
+
+func defaultLoadOptions() *loadOptions {
+	return &loadOptions{providers: map[string]SecretProvider{
+		"file": FileSecretProvider{Dir: DefaultSecretDir},
+		"env":  EnvSecretProvider{},
+	}}
+}
+
+func newLoadOptions(opts []Option) *loadOptions {
+	options := defaultLoadOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithSecretProvider registers a provider for secret://<name>/... references,
+// replacing any provider already registered under that name
+func WithSecretProvider(name string, provider SecretProvider) Option {
+	return func(o *loadOptions) {
+		o.providers[name] = provider
+	}
+}
+
+// applySecretFiles sets every key whose <ENV>_FILE variable is present to the
+// content of that file. Setting both the variable and its _FILE form is an error.
+func applySecretFiles(v *viper.Viper, prefix string, keys []string) error {
+	for _, key := range keys {
+		envName := strings.ToUpper(prefix + "_" + strings.ReplaceAll(key, ".", "_"))
+		path, ok := os.LookupEnv(envName + fileEnvSuffix)
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(envName); set {
+			return fmt.Errorf("both %s and %s%s are set", envName, envName, fileEnvSuffix)
+		}
+		value, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", envName, fileEnvSuffix, err)
+		}
+		v.Set(key, value)
+	}
+	return nil
+}
+
+// resolveSecretRefs replaces every secret:// value, whether it came from the
+// file or the environment, with the secret it references
+func resolveSecretRefs(v *viper.Viper, providers map[string]SecretProvider) error {
+	for _, key := range v.AllKeys() {
+		ref, ok := v.Get(key).(string)
+		if !ok || !strings.HasPrefix(ref, SecretScheme) {
+			continue
+		}
+		providerName, name, _ := strings.Cut(strings.TrimPrefix(ref, SecretScheme), "/")
+		provider, ok := providers[providerName]
+		if !ok {
+			return fmt.Errorf("%s: unknown secret provider %q", key, providerName)
+		}
+		if name == "" {
+			return fmt.Errorf("%s: secret reference %q has no name", key, ref)
+		}
+		value, err := provider.Resolve(name)
+		if err != nil {
+			return fmt.Errorf("%s: failed to resolve secret: %w", key, err)
+		}
+		v.Set(key, value)
+	}
+	return nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("secret file %s does not exist", path)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
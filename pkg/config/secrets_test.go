@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// staticProvider resolves names from a map
+type staticProvider map[string]string
+
+func (p staticProvider) Resolve(name string) (string, error) {
+	value, ok := p[name]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func TestSecretsFromFiles(t *testing.T) {
+	dir := configDir(t, baseConfig)
+	secrets := t.TempDir()
+	path := writeFile(t, secrets, "db_password", "s3cret\n")
+
+	t.Setenv("SVC_DATABASE_PASSWORD_FILE", path)
+	_, cfg, err := LoadConfig("svc", dir, &testConfig{})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Errorf("password = %q, want the file content without the newline", cfg.Database.Password)
+	}
+
+	t.Setenv("SVC_DATABASE_PASSWORD", "other")
+	if _, _, err := LoadConfig("svc", dir, &testConfig{}); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("variable and _FILE both set: got %v, want an error", err)
+	}
+}
+
+func TestSecretFileMustExist(t *testing.T) {
+	t.Setenv("SVC_DATABASE_PASSWORD_FILE", "/nonexistent/db_password")
+	if _, _, err := LoadConfig("svc", configDir(t, baseConfig), &testConfig{}); err == nil {
+		t.Error("LoadConfig succeeded with a missing secret file")
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	secrets := t.TempDir()
+	writeFile(t, secrets, "db_password", "from-file\n")
+	t.Setenv("SMTP_PASSWORD", "from-env")
+
+	tests := []struct {
+		name    string
+		ref     string
+		env     string // set as SVC_DATABASE_PASSWORD instead of the file value
+		want    string
+		wantErr bool
+	}{
+		{"file", "secret://file/db_password", "", "from-file", false},
+		{"env", "secret://env/SMTP_PASSWORD", "", "from-env", false},
+		{"custom provider", "secret://vault/db", "", "from-vault", false},
+		{"reference in an environment variable", "postgres", "secret://vault/db", "from-vault", false},
+		{"plain value", "postgres", "", "postgres", false},
+		{"unknown provider", "secret://kms/db", "", "", true},
+		{"no name", "secret://file/", "", "", true},
+		{"unresolvable", "secret://env/UNSET_SECRET", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := configDir(t, "database:\n  host: localhost\n  password: \""+tt.ref+"\"\n")
+			if tt.env != "" {
+				t.Setenv("SVC_DATABASE_PASSWORD", tt.env)
+			}
+			_, cfg, err := LoadConfig("svc", dir, &testConfig{},
+				WithSecretProvider("file", FileSecretProvider{Dir: secrets}),
+				WithSecretProvider("vault", staticProvider{"db": "from-vault"}),
+			)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got password %q, want an error", cfg.Database.Password)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.Database.Password != tt.want {
+				t.Errorf("password = %q, want %q", cfg.Database.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &testConfig{Tokens: map[string]string{"gateway": "token", "blog": ""}}
+	cfg.Database.Host = "localhost"
+	cfg.Database.Password = "s3cret"
+
+	redacted := Redact(cfg)
+	if redacted.Database.Password != Redacted || redacted.Database.Host != "localhost" {
+		t.Errorf("database = %+v, want only the password redacted", redacted.Database)
+	}
+	if redacted.Tokens["gateway"] != Redacted || redacted.Tokens["blog"] != "" {
+		t.Errorf("tokens = %v, want set tokens redacted and unset ones empty", redacted.Tokens)
+	}
+	if cfg.Database.Password != "s3cret" || cfg.Tokens["gateway"] != "token" {
+		t.Error("Redact modified its argument")
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watcher holds the current config of a service and reloads it whenever one
+// of its config files changes. A reload goes through the same steps as
+// LoadConfig, environment overrides and secrets included, and only a config
+// that passes validation replaces the current one.
+type Watcher[T Config] struct {
+	prefix     string
+	configPath string
+	newTarget  func() T
+	options    *loadOptions
+
+	current atomic.Pointer[snapshot[T]]
+
+	mu          sync.Mutex // serializes reloads and guards the callbacks
+	subscribers []func(old, new T)
+	onError     []func(error)
+}
+
+type snapshot[T Config] struct {
+	base Base
+	cfg  T
+}
+
+// NewWatcher loads the config like LoadConfig does. newTarget returns the
+// empty value each load, the initial one included, is decoded into. Changes
+// are not picked up until Watch is called.
+func NewWatcher[T Config](prefix, configPath string, newTarget func() T, opts ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		prefix:     prefix,
+		configPath: configPath,
+		newTarget:  newTarget,
+		options:    newLoadOptions(opts),
+	}
+	base, cfg, err := load(prefix, configPath, newTarget(), w.options)
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(&snapshot[T]{base: base, cfg: cfg})
+	return w, nil
+}
+
+// Current returns the last valid config. Callers must treat it as read-only.
+func (w *Watcher[T]) Current() T {
+	return w.current.Load().cfg
+}
+
+// Base returns the base config loaded with Current
+func (w *Watcher[T]) Base() Base {
+	return w.current.Load().base
+}
+
+// Subscribe registers fn to be called with the previous and the new config
+// after each reload that changed something. Subscribers run one at a time in
+// the order they subscribed.
+func (w *Watcher[T]) Subscribe(fn func(old, new T)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// OnError registers fn to be told about reloads that were rejected
+func (w *Watcher[T]) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// OnChange subscribes fn to the part of the config picked by selector. It is
+// only called when a reload changed that part.
+func OnChange[T Config, V any](w *Watcher[T], selector func(T) V, fn func(old, new V)) {
+	w.Subscribe(func(old, new T) {
+		oldValue, newValue := selector(old), selector(new)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			fn(oldValue, newValue)
+		}
+	})
+}
+
+// Watch starts watching config.yml and the overlay and local files that
+// exist through viper. The watch lasts for the life of the process; an overlay
+// created later is picked up with the next change to a watched file.
+func (w *Watcher[T]) Watch() error {
+	l, err := readLayers(w.prefix, w.configPath, reflect.TypeOf(w.newTarget()), w.options)
+	if err != nil {
+		return err
+	}
+	for _, file := range l.files {
+		v := viper.New()
+		v.SetConfigFile(file.path)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file %s: %w", file.path, err)
+		}
+		// viper only reports the change; the reload itself starts from a
+		// fresh instance so values set from secrets cannot mask edits
+		v.OnConfigChange(func(fsnotify.Event) {
+			_ = w.Reload()
+		})
+		v.WatchConfig()
+	}
+	return nil
+}
+
+// Reload loads and validates the config and, if it is valid and differs from
+// the current one, swaps it in and notifies subscribers. On error the
+// current config is kept and the OnError callbacks are called.
+func (w *Watcher[T]) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	base, cfg, err := load(w.prefix, w.configPath, w.newTarget(), w.options)
+	if err != nil {
+		err = fmt.Errorf("config reload rejected: %w", err)
+		for _, fn := range w.onError {
+			fn(err)
+		}
+		return err
+	}
+
+	old := w.current.Load()
+	// editors often write a file more than once per save
+	if reflect.DeepEqual(old.base, base) && reflect.DeepEqual(old.cfg, cfg) {
+		return nil
+	}
+	w.current.Store(&snapshot[T]{base: base, cfg: cfg})
+	for _, fn := range w.subscribers {
+		fn(old.cfg, cfg)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWatcher(t *testing.T, dir string) *Watcher[*testConfig] {
+	t.Helper()
+	w, err := NewWatcher("svc", dir, func() *testConfig { return &testConfig{} })
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	return w
+}
+
+func TestReloadNotifiesSubscribersOfChanges(t *testing.T) {
+	dir := configDir(t, baseConfig)
+	w := newTestWatcher(t, dir)
+
+	var levels, origins [][2]string
+	OnChange(w, func(c *testConfig) string { return c.Log.Level }, func(old, new string) {
+		levels = append(levels, [2]string{old, new})
+	})
+	OnChange(w, func(c *testConfig) []string { return c.Origins }, func(old, new []string) {
+		origins = append(origins, [2]string{strings.Join(old, ","), strings.Join(new, ",")})
+	})
+	reloads := 0
+	w.Subscribe(func(old, new *testConfig) { reloads++ })
+
+	// unchanged files do not notify anyone
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if reloads != 0 {
+		t.Errorf("unchanged reload notified %d times", reloads)
+	}
+
+	writeFile(t, dir, "config.yml", strings.Replace(baseConfig, "level: info", "level: debug", 1))
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if w.Current().Log.Level != "debug" {
+		t.Errorf("current level = %q, want debug", w.Current().Log.Level)
+	}
+	if len(levels) != 1 || levels[0] != [2]string{"info", "debug"} {
+		t.Errorf("level changes = %v, want [info debug]", levels)
+	}
+	if len(origins) != 0 {
+		t.Errorf("origins subscriber called for an unchanged value: %v", origins)
+	}
+	if reloads != 1 {
+		t.Errorf("reloads = %d, want 1", reloads)
+	}
+}
+
+func TestReloadKeepsTheConfigWhenInvalid(t *testing.T) {
+	dir := configDir(t, baseConfig)
+	w := newTestWatcher(t, dir)
+
+	var rejected []error
+	w.OnError(func(err error) { rejected = append(rejected, err) })
+	w.Subscribe(func(old, new *testConfig) { t.Error("subscriber called for a rejected config") })
+
+	for name, content := range map[string]string{
+		"invalid":   strings.Replace(baseConfig, "host: localhost", "host: \"\"", 1),
+		"malformed": "database: [",
+	} {
+		writeFile(t, dir, "config.yml", content)
+		if err := w.Reload(); err == nil {
+			t.Errorf("%s config: Reload succeeded", name)
+		}
+	}
+	if len(rejected) != 2 {
+		t.Errorf("OnError called %d times, want 2", len(rejected))
+	}
+	if w.Current().Database.Host != "localhost" {
+		t.Errorf("host = %q, want the previous config kept", w.Current().Database.Host)
+	}
+}
+
+func TestWatchReloadsOnSave(t *testing.T) {
+	dir := configDir(t, baseConfig)
+	w := newTestWatcher(t, dir)
+
+	changed := make(chan string, 10)
+	OnChange(w, func(c *testConfig) string { return c.Log.Level }, func(_, new string) { changed <- new })
+	if err := w.Watch(); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	writeFile(t, dir, "config.yml", strings.Replace(baseConfig, "level: info", "level: warn", 1))
+	select {
+	case level := <-changed:
+		if level != "warn" {
+			t.Errorf("level = %q, want warn", level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the config file was saved")
+	}
+}
@@ -2,18 +2,23 @@ package database
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
 )
 
 // Migrator handles database migrations
 type Migrator struct {
 	migrationsPath string
+	migrations     fs.FS // embedded migrations, used instead of migrationsPath when set
 	db             *sqlx.DB
 }
 
@@ -30,18 +35,20 @@ func NewMigrator(db *sqlx.DB, migrationsPath string) (*Migrator, error) {
 	}, nil
 }
 
-// Up applies all available migrations
-func (m *Migrator) Up() error {
-	driver, err := postgres.WithInstance(m.db.DB, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+// NewEmbeddedMigrator creates a migrator for migrations compiled into the
+// binary. The migration files must be at the root of migrations.
+func NewEmbeddedMigrator(db *sqlx.DB, migrations fs.FS) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
 	}
+}
 
-	migrator, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", m.migrationsPath),
-		"postgres", driver)
+// Up applies all available migrations
+func (m *Migrator) Up() error {
+	migrator, err := m.migrate()
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 
 	if err := migrator.Up(); err != nil && err != migrate.ErrNoChange {
@@ -53,16 +60,9 @@ func (m *Migrator) Up() error {
 
 // Down rolls back all migrations
 func (m *Migrator) Down() error {
-	driver, err := postgres.WithInstance(m.db.DB, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
-	}
-
-	migrator, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", m.migrationsPath),
-		"postgres", driver)
+	migrator, err := m.migrate()
 	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 
 	if err := migrator.Down(); err != nil && err != migrate.ErrNoChange {
@@ -72,6 +72,42 @@ func (m *Migrator) Down() error {
 	return nil
 }
 
+// migrate prepares golang-migrate for the database's dialect
+func (m *Migrator) migrate() (*migrate.Migrate, error) {
+	var (
+		driver migratedb.Driver
+		err    error
+	)
+	switch m.db.DriverName() {
+	case DriverSQLite:
+		driver, err = sqlite3.WithInstance(m.db.DB, &sqlite3.Config{})
+	case DriverPostgres:
+		driver, err = postgres.WithInstance(m.db.DB, &postgres.Config{})
+	default:
+		return nil, fmt.Errorf("migrations are not supported for driver %q", m.db.DriverName())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	var migrator *migrate.Migrate
+	if m.migrations != nil {
+		source, err := iofs.New(m.migrations, ".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		migrator, err = migrate.NewWithInstance("iofs", source, m.db.DriverName(), driver)
+	} else {
+		migrator, err = migrate.NewWithDatabaseInstance(
+			fmt.Sprintf("file://%s", m.migrationsPath),
+			m.db.DriverName(), driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator, nil
+}
+
 // CreateMigration creates new migration files
 func (m *Migrator) CreateMigration(name string) error {
 	upFile := filepath.Join(m.migrationsPath, fmt.Sprintf("%s_up.sql", name))
@@ -33,6 +33,9 @@ type RedisClient struct {
 	logger *logger.Logger
 }
 
+// defaultRedisPingTimeout bounds the startup ping when no dial timeout is configured
+const defaultRedisPingTimeout = 5 * time.Second
+
 var (
 	redisInstance *RedisClient
 	redisOnce     sync.Once
@@ -68,13 +71,15 @@ func NewRedisClient[T HasRedisCionfig](config T, logger *logger.Logger) (*RedisC
 			return
 		}
 
-		ctx, err := context.WithTimeout(context.Background(), redisConfig.DialTimeout)
-		if err != nil {
-			initErr = fmt.Errorf("failed to create context: %w", err)
-			return
+		pingTimeout := redisConfig.DialTimeout
+		if pingTimeout <= 0 {
+			pingTimeout = defaultRedisPingTimeout
 		}
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		defer cancel()
 
 		if err := rdb.Ping(ctx).Err(); err != nil {
+			rdb.Close()
 			initErr = fmt.Errorf("redis ping failed: %w", err)
 			return
 		}
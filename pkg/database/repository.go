@@ -7,8 +7,11 @@ import (
 
 	"github.com/istiak-004/myFolio-microservices/pkg/logger"
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
+// BaseRepository runs queries and transactions, logging failures with the
+// request-scoped fields of the context they were run with
 type BaseRepository struct {
 	// DB is the database connection
 	db     *sqlx.DB
@@ -28,16 +31,16 @@ func NewBaseRepository(db *sqlx.DB, logger *logger.Logger) *BaseRepository {
 func (r *BaseRepository) WithTransaction(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
 	tx, err := r.db.BeginTxx(ctx, nil) // Begin a new transaction
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to begin transaction!")
+		r.logger.Ctx(ctx).Error("Failed to begin transaction!", zap.Error(err))
 		return err
 	}
 
 	// Ensure that the transaction is rolled back in case of panic
 	defer func() {
 		if p := recover(); p != nil {
-			r.logger.WithError(err).Error("Panic occurred in transaction!")
+			r.logger.Ctx(ctx).Error("Panic occurred in transaction!", zap.Any("panic", p))
 			if err := tx.Rollback(); err != nil {
-				r.logger.WithError(err).Error("Failed to rollback transaction!")
+				r.logger.Ctx(ctx).Error("Failed to rollback transaction!", zap.Error(err))
 			}
 			panic(p)
 		}
@@ -46,9 +49,9 @@ func (r *BaseRepository) WithTransaction(ctx context.Context, fn func(tx *sqlx.T
 	// Execute the function with the transaction
 	// If the function returns an error, rollback the transaction
 	if err := fn(tx); err != nil {
-		r.logger.WithError(err).Error("Transaction failed!")
+		r.logger.Ctx(ctx).Error("Transaction failed!", zap.Error(err))
 		if rbErr := tx.Rollback(); rbErr != nil {
-			r.logger.WithError(err).Error("Failed to rollback transaction!")
+			r.logger.Ctx(ctx).Error("Failed to rollback transaction!", zap.Error(rbErr))
 			return fmt.Errorf("transaction failed: %w, rollback error: %v", err, rbErr)
 		}
 		return fmt.Errorf("transaction failed: %w", err)
@@ -56,7 +59,7 @@ func (r *BaseRepository) WithTransaction(ctx context.Context, fn func(tx *sqlx.T
 
 	// If the function succeeds, commit the transaction
 	if err := tx.Commit(); err != nil {
-		r.logger.WithError(err).Error("Failed to commit transaction!")
+		r.logger.Ctx(ctx).Error("Failed to commit transaction!", zap.Error(err))
 		return err
 	}
 	return nil
@@ -67,7 +70,7 @@ func (r *BaseRepository) WithTransaction(ctx context.Context, fn func(tx *sqlx.T
 func (r *BaseRepository) Get(ctx context.Context, dest any, query string, args ...any) error {
 	err := r.db.GetContext(ctx, dest, query, args...)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to execute query!")
+		r.logger.Ctx(ctx).Error("Failed to execute query!", zap.Error(err))
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 	return nil
@@ -78,7 +81,7 @@ func (r *BaseRepository) Get(ctx context.Context, dest any, query string, args .
 func (r *BaseRepository) Select(ctx context.Context, dest any, query string, args ...any) error {
 	err := r.db.SelectContext(ctx, dest, query, args...)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to execute query!")
+		r.logger.Ctx(ctx).Error("Failed to execute query!", zap.Error(err))
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 	return nil
@@ -89,7 +92,7 @@ func (r *BaseRepository) Select(ctx context.Context, dest any, query string, arg
 func (r *BaseRepository) Exec(ctx context.Context, query string, args ...any) error {
 	_, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to execute query!")
+		r.logger.Ctx(ctx).Error("Failed to execute query!", zap.Error(err))
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 	return nil
@@ -100,7 +103,7 @@ func (r *BaseRepository) Exec(ctx context.Context, query string, args ...any) er
 func (r *BaseRepository) QuerySingleRow(ctx context.Context, dest any, query string, args ...any) error {
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(dest)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to execute query!")
+		r.logger.Ctx(ctx).Error("Failed to execute query!", zap.Error(err))
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 	return nil
@@ -112,7 +115,7 @@ func (r *BaseRepository) QuerySingleRow(ctx context.Context, dest any, query str
 func (r *BaseRepository) QueryAllRows(ctx context.Context, dest any, query string, args ...any) (*sql.Rows, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to execute query!")
+		r.logger.Ctx(ctx).Error("Failed to execute query!", zap.Error(err))
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
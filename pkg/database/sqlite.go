@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// SQL driver names, as reported by sqlx.DB.DriverName
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
+
+const defaultSQLiteBusyTimeout = 5 * time.Second
+
+// SQLiteConfig configures a SQLite database file
+type SQLiteConfig struct {
+	Path         string        `mapstructure:"path" validate:"required"`
+	BusyTimeout  time.Duration `mapstructure:"busy_timeout"`   // how long a write waits for another writer
+	MaxOpenConns int           `mapstructure:"max_open_conns"` // 0 leaves the pool unbounded
+}
+
+// NewSQLite opens a SQLite database file, creating it when it does not exist.
+// Unlike NewDB it is not a singleton. The database runs in WAL mode with
+// foreign keys enforced, and transactions take the write lock when they
+// begin, so concurrent writers wait for each other instead of deadlocking.
+func NewSQLite(config SQLiteConfig, logger *logger.Logger) (*Client, error) {
+	if dir := filepath.Dir(config.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	busyTimeout := config.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = defaultSQLiteBusyTimeout
+	}
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_journal_mode", "WAL")
+	params.Set("_txlock", "immediate")
+	params.Set("_busy_timeout", fmt.Sprint(busyTimeout.Milliseconds()))
+	dsn := "file:" + config.Path + "?" + params.Encode()
+
+	db, err := sqlx.Open(DriverSQLite, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+
+	ctx, cancel := context.WithTimeout(context.Background(), busyTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	logger.WithComponent("database").Info("SQLite database opened", logger.String("path", config.Path))
+	return &Client{db: db, logger: logger}, nil
+}
+
+// Driver returns the name of the SQL driver, DriverPostgres or DriverSQLite
+func (c *Client) Driver() string {
+	return c.db.DriverName()
+}
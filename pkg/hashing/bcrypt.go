@@ -0,0 +1,58 @@
+package hashing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptParams holds the tunable bcrypt cost
+type BcryptParams struct {
+	Cost int `mapstructure:"cost"`
+}
+
+// DefaultBcryptParams returns bcrypt's default cost
+func DefaultBcryptParams() BcryptParams {
+	return BcryptParams{Cost: bcrypt.DefaultCost}
+}
+
+// BcryptHasher hashes passwords with bcrypt using the modular crypt format ($2a$...)
+type BcryptHasher struct {
+	params BcryptParams
+}
+
+func NewBcryptHasher(params BcryptParams) (*BcryptHasher, error) {
+	if params.Cost < bcrypt.MinCost || params.Cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{params: params}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.params.Cost)
+	return string(bytes), err
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.params.Cost
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,167 @@
+package hashing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Supported password hashing algorithms
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+// Hasher hashes passwords into a self-describing encoded string.
+// The encoding records the algorithm and its parameters so that hashes
+// produced under an older policy can still be verified and upgraded.
+type Hasher interface {
+	// Hash returns the encoded hash of the password
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether the encoded hash was produced under a different policy
+	NeedsRehash(encoded string) bool
+}
+
+// Policy describes how new password hashes are produced
+type Policy struct {
+	Algorithm string       `mapstructure:"algorithm"`
+	Bcrypt    BcryptParams `mapstructure:"bcrypt"`
+	Argon2    Argon2Params `mapstructure:"argon2"`
+}
+
+// DefaultPolicy returns the policy used when nothing is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		Algorithm: AlgorithmArgon2id,
+		Bcrypt:    DefaultBcryptParams(),
+		Argon2:    DefaultArgon2Params(),
+	}
+}
+
+// PolicyHasher hashes with the algorithm selected by its policy and verifies
+// hashes produced by any supported algorithm.
+type PolicyHasher struct {
+	policy  Policy
+	current Hasher
+	bcrypt  *BcryptHasher
+	argon2  *Argon2Hasher
+}
+
+// New creates a hasher for the given policy. Zero values fall back to defaults.
+func New(policy Policy) (*PolicyHasher, error) {
+	defaults := DefaultPolicy()
+	if policy.Algorithm == "" {
+		policy.Algorithm = defaults.Algorithm
+	}
+	if policy.Bcrypt.Cost == 0 {
+		policy.Bcrypt = defaults.Bcrypt
+	}
+	policy.Argon2 = policy.Argon2.withDefaults()
+
+	bcryptHasher, err := NewBcryptHasher(policy.Bcrypt)
+	if err != nil {
+		return nil, err
+	}
+	argon2Hasher, err := NewArgon2Hasher(policy.Argon2)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &PolicyHasher{
+		policy: policy,
+		bcrypt: bcryptHasher,
+		argon2: argon2Hasher,
+	}
+	switch strings.ToLower(policy.Algorithm) {
+	case AlgorithmArgon2id:
+		h.current = argon2Hasher
+	case AlgorithmBcrypt:
+		h.current = bcryptHasher
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, policy.Algorithm)
+	}
+	return h, nil
+}
+
+// Policy returns the effective policy
+func (h *PolicyHasher) Policy() Policy {
+	return h.policy
+}
+
+// Hash hashes the password with the policy's current algorithm
+func (h *PolicyHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks the password against a hash of any supported algorithm
+func (h *PolicyHasher) Verify(password, encoded string) (bool, error) {
+	hasher, err := h.hasherFor(encoded)
+	if err != nil {
+		return false, err
+	}
+	return hasher.Verify(password, encoded)
+}
+
+// NeedsRehash reports whether the hash uses another algorithm or other parameters
+func (h *PolicyHasher) NeedsRehash(encoded string) bool {
+	hasher, err := h.hasherFor(encoded)
+	if err != nil {
+		return true
+	}
+	if hasher != h.current {
+		return true
+	}
+	return hasher.NeedsRehash(encoded)
+}
+
+func (h *PolicyHasher) hasherFor(encoded string) (Hasher, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		return h.argon2, nil
+	case isBcryptHash(encoded):
+		return h.bcrypt, nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
+
+var (
+	defaultHasher Hasher
+	defaultMu     sync.RWMutex
+)
+
+// Default returns the process-wide hasher, built from DefaultPolicy unless SetDefault was called
+func Default() Hasher {
+	defaultMu.RLock()
+	h := defaultHasher
+	defaultMu.RUnlock()
+	if h != nil {
+		return h
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultHasher == nil {
+		h, err := New(DefaultPolicy())
+		if err != nil {
+			panic("failed to initialize default password hasher: " + err.Error())
+		}
+		defaultHasher = h
+	}
+	return defaultHasher
+}
+
+// SetDefault replaces the process-wide hasher. It is meant to be called once at startup.
+func SetDefault(h Hasher) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultHasher = h
+}
@@ -0,0 +1,120 @@
+package hashing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheap parameters keep the tests fast; production costs are exercised by DefaultPolicy
+var (
+	testArgon2 = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	testBcrypt = BcryptParams{Cost: 4}
+)
+
+func newTestHasher(t *testing.T, algorithm string, argon2 Argon2Params) *PolicyHasher {
+	t.Helper()
+	h, err := New(Policy{Algorithm: algorithm, Bcrypt: testBcrypt, Argon2: argon2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestPolicyHasherRoundTrip(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+	}{
+		{AlgorithmArgon2id, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{AlgorithmBcrypt, "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h := newTestHasher(t, tt.algorithm, testArgon2)
+			encoded, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if !strings.HasPrefix(encoded, tt.prefix) {
+				t.Errorf("hash %q does not start with %q", encoded, tt.prefix)
+			}
+			if ok, err := h.Verify("correct horse", encoded); !ok || err != nil {
+				t.Errorf("Verify(right password) = %v, %v", ok, err)
+			}
+			if ok, err := h.Verify("wrong horse", encoded); ok || err != nil {
+				t.Errorf("Verify(wrong password) = %v, %v", ok, err)
+			}
+			if h.NeedsRehash(encoded) {
+				t.Error("a fresh hash needs rehashing")
+			}
+			again, _ := h.Hash("correct horse")
+			if again == encoded {
+				t.Error("two hashes of the same password are equal; the salt is not random")
+			}
+		})
+	}
+}
+
+func TestPolicyHasherVerifiesAndUpgradesOlderHashes(t *testing.T) {
+	legacy, err := newTestHasher(t, AlgorithmBcrypt, testArgon2).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weaker, err := newTestHasher(t, AlgorithmArgon2id, testArgon2).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testArgon2
+	stronger.Iterations = 2
+	current := newTestHasher(t, AlgorithmArgon2id, stronger)
+	for name, encoded := range map[string]string{"bcrypt": legacy, "older argon2 costs": weaker} {
+		if ok, err := current.Verify("correct horse", encoded); !ok || err != nil {
+			t.Errorf("%s: Verify = %v, %v", name, ok, err)
+		}
+		if !current.NeedsRehash(encoded) {
+			t.Errorf("%s: NeedsRehash = false", name)
+		}
+	}
+}
+
+func TestPolicyHasherRejectsUnknownHashes(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, testArgon2)
+	for _, encoded := range []string{"", "plaintext", "$argon2id$v=19$m=x$salt$key", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5"} {
+		if ok, err := h.Verify("correct horse", encoded); ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v; want an error", encoded, ok, err)
+		}
+		if !h.NeedsRehash(encoded) {
+			t.Errorf("NeedsRehash(%q) = false", encoded)
+		}
+	}
+}
+
+func TestNewRejectsBadPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr error
+	}{
+		{"unknown algorithm", Policy{Algorithm: "md5"}, ErrUnknownAlgorithm},
+		{"bcrypt cost too low", Policy{Bcrypt: BcryptParams{Cost: 3}}, nil},
+		{"argon2 salt too short", Policy{Argon2: Argon2Params{SaltLength: 4}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.policy)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("got %v, want an error", err)
+			}
+		})
+	}
+
+	h, err := New(Policy{})
+	if err != nil {
+		t.Fatalf("zero policy: %v", err)
+	}
+	if h.Policy() != DefaultPolicy() {
+		t.Errorf("zero policy resolved to %+v, want the defaults", h.Policy())
+	}
+}
@@ -1,17 +1,43 @@
 package middleware
 
 import (
+	"sync/atomic"
+
 	"github.com/gin-gonic/gin"
 )
 
+// CORS answers Cross-Origin Resource Sharing checks for a list of allowed
+// origins that can be replaced while it serves requests
+type CORS struct {
+	allowedOrigins atomic.Pointer[[]string]
+}
+
+// NewCORS returns a CORS policy allowing allowedOrigins; "*" allows any origin
+func NewCORS(allowedOrigins []string) *CORS {
+	cors := &CORS{}
+	cors.SetAllowedOrigins(allowedOrigins)
+	return cors
+}
+
+// SetAllowedOrigins replaces the allowed origins
+func (p *CORS) SetAllowedOrigins(allowedOrigins []string) {
+	origins := append([]string(nil), allowedOrigins...)
+	p.allowedOrigins.Store(&origins)
+}
+
 // CORS configures Cross-Origin Resource Sharing
 func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	return NewCORS(allowedOrigins).Middleware()
+}
+
+// Middleware sets the CORS headers for allowed origins and answers preflight requests
+func (p *CORS) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 		allowed := false
 
 		// Check if origin is in allowed list
-		for _, o := range allowedOrigins {
+		for _, o := range *p.allowedOrigins.Load() {
 			if o == "*" || o == origin {
 				allowed = true
 				break
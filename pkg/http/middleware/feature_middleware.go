@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlags switches parts of an API on and off at runtime. A feature
+// without a flag is enabled, so only the features to turn off need listing.
+type FeatureFlags struct {
+	flags atomic.Pointer[map[string]bool]
+}
+
+// NewFeatureFlags returns the flags set to flags
+func NewFeatureFlags(flags map[string]bool) *FeatureFlags {
+	f := &FeatureFlags{}
+	f.Set(flags)
+	return f
+}
+
+// Set replaces all flags
+func (f *FeatureFlags) Set(flags map[string]bool) {
+	copied := make(map[string]bool, len(flags))
+	for name, enabled := range flags {
+		copied[name] = enabled
+	}
+	f.flags.Store(&copied)
+}
+
+// Enabled reports whether the named feature is on
+func (f *FeatureFlags) Enabled(name string) bool {
+	enabled, ok := (*f.flags.Load())[name]
+	return !ok || enabled
+}
+
+// FeatureGate answers 404 for routes of disabled features, as if they did not
+// exist. routes maps route path prefixes, as registered with gin, to the
+// feature serving them.
+func FeatureGate(flags *FeatureFlags, routes map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		for prefix, feature := range routes {
+			if strings.HasPrefix(path, prefix) && !flags.Enabled(feature) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
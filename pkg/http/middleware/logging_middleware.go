@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
 )
 
+// LoggingMiddleware reports how long requests took in X-Response-Time and
+// logs them with the request-scoped logger set by RequestContext: server
+// errors at error level, everything else at debug level.
 func LoggingMiddleware() gin.HandlerFunc {
 	// Log the request details
 	return func(c *gin.Context) {
@@ -15,5 +21,17 @@ func LoggingMiddleware() gin.HandlerFunc {
 
 		// Log the request details
 		c.Writer.Header().Set("X-Response-Time", duration.String())
+
+		// c.Request now carries what later middleware added, e.g. the user ID
+		log := logger.FromContext(c.Request.Context())
+		fields := []zap.Field{zap.Int("status", c.Writer.Status()), zap.Duration("duration", duration)}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			log.Error("Request failed", fields...)
+			return
+		}
+		log.Debug("Request completed", fields...)
 	}
 }
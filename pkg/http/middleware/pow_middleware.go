@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// Headers carrying a solved proof-of-work challenge
+const (
+	ProofOfWorkChallengeHeader = "X-PoW-Challenge"
+	ProofOfWorkSolutionHeader  = "X-PoW-Solution"
+)
+
+const (
+	powVersion      byte = 1
+	powNonceSize         = 16
+	powMaxBodyBytes      = 1 << 20
+)
+
+var (
+	errPoWMalformed = errors.New("malformed proof of work challenge")
+	errPoWSignature = errors.New("invalid proof of work signature")
+	errPoWExpired   = errors.New("proof of work challenge expired")
+	errPoWClient    = errors.New("proof of work challenge was issued to another client")
+	errPoWSolution  = errors.New("proof of work solution is incorrect")
+	errPoWReplayed  = errors.New("proof of work challenge was already used")
+)
+
+// ProofOfWorkConfig configures the hashcash-style anti-bot challenge
+type ProofOfWorkConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Secret is the HMAC key signing challenges. Instances behind one load
+	// balancer must share it; when empty a random per-process key is used.
+	Secret string `mapstructure:"secret" secret:"true"`
+	// Threshold is the pressure, from 0 to 1, above which a challenge is required.
+	// Pressure is the higher of the IP's rate limit pressure and the account's.
+	Threshold float64 `mapstructure:"threshold"`
+	// MinDifficulty and MaxDifficulty are the number of leading zero bits the
+	// solution hash must have at the threshold and at full pressure
+	MinDifficulty int           `mapstructure:"min_difficulty"`
+	MaxDifficulty int           `mapstructure:"max_difficulty"`
+	TTL           time.Duration `mapstructure:"ttl"` // how long a challenge may be solved and redeemed
+	// AccountRequests per AccountInterval is the volume a single email may see
+	// before it adds pressure, whichever IPs the requests come from
+	AccountRequests int           `mapstructure:"account_requests"`
+	AccountInterval time.Duration `mapstructure:"account_interval"`
+}
+
+// DefaultProofOfWorkConfig returns settings that cost a browser well under a
+// second at the threshold and a few seconds at full pressure
+func DefaultProofOfWorkConfig() ProofOfWorkConfig {
+	return ProofOfWorkConfig{
+		Threshold:       0.5,
+		MinDifficulty:   16,
+		MaxDifficulty:   22,
+		TTL:             5 * time.Minute,
+		AccountRequests: 5,
+		AccountInterval: time.Minute,
+	}
+}
+
+// ProofOfWork requires clients showing suspicious volume to solve a puzzle
+// before the request is handled. It must run after RateLimiterMiddleware.
+//
+// When a challenge is required the request is rejected with 428 and a body of
+//
+//	{"error": "...", "challenge": "<token>", "difficulty": 18, "expires_at": "..."}
+//
+// The client finds any string S such that SHA-256(challenge + ":" + S) starts
+// with `difficulty` zero bits, and retries with the X-PoW-Challenge and
+// X-PoW-Solution headers. Every challenge can be redeemed once.
+func ProofOfWork(config ProofOfWorkConfig) gin.HandlerFunc {
+	if !config.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	pow := newProofOfWork(config)
+	go pow.cleanup()
+	return pow.handle
+}
+
+type proofOfWork struct {
+	config ProofOfWorkConfig
+	secret []byte
+
+	mu       sync.Mutex
+	used     map[string]time.Time // redeemed challenges until they expire
+	accounts map[string]*clientLimiter
+}
+
+func newProofOfWork(config ProofOfWorkConfig) *proofOfWork {
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &proofOfWork{
+		config:   config,
+		secret:   secret,
+		used:     make(map[string]time.Time),
+		accounts: make(map[string]*clientLimiter),
+	}
+}
+
+func (p *proofOfWork) handle(c *gin.Context) {
+	client := c.ClientIP()
+	challenge := c.GetHeader(ProofOfWorkChallengeHeader)
+	var challengeErr error
+	if challenge != "" {
+		// a solved challenge is checked against the difficulty it was issued
+		// with, since pressure keeps rising while the client retries, and it
+		// counts toward neither the IP's nor the account's pressure
+		challengeErr = p.verify(challenge, c.GetHeader(ProofOfWorkSolutionHeader), client)
+		if challengeErr == nil {
+			waiveRateLimit(c)
+			c.Next()
+			return
+		}
+	}
+
+	pressure := math.Max(RateLimitPressure(c), p.accountPressure(c))
+	if pressure < p.config.Threshold {
+		c.Next()
+		return
+	}
+	if challengeErr != nil {
+		// hand out a fresh challenge together with the reason
+		c.Header("X-PoW-Error", challengeErr.Error())
+	}
+
+	difficulty := p.difficulty(pressure)
+	token, expiresAt := p.issue(client, difficulty)
+	c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+		"error":      "proof of work required",
+		"challenge":  token,
+		"difficulty": difficulty,
+		"algorithm":  "sha256",
+		"expires_at": expiresAt,
+	})
+}
+
+// difficulty scales linearly from MinDifficulty at the threshold to MaxDifficulty at full pressure
+func (p *proofOfWork) difficulty(pressure float64) int {
+	span := 1 - p.config.Threshold
+	if span <= 0 {
+		return p.config.MaxDifficulty
+	}
+	ratio := (pressure - p.config.Threshold) / span
+	extra := math.Round(ratio * float64(p.config.MaxDifficulty-p.config.MinDifficulty))
+	return min(p.config.MaxDifficulty, p.config.MinDifficulty+int(extra))
+}
+
+// accountPressure tracks request volume per email found in the JSON body,
+// which catches credential stuffing and sign-up floods spread over many IPs
+func (p *proofOfWork) accountPressure(c *gin.Context) float64 {
+	if p.config.AccountRequests <= 0 || c.Request.Body == nil {
+		return 0
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, powMaxBodyBytes))
+	if err != nil {
+		return 0
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.Email == "" {
+		return 0
+	}
+	account := strings.ToLower(strings.TrimSpace(req.Email))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.accounts[account]
+	if !ok {
+		every := p.config.AccountInterval / time.Duration(p.config.AccountRequests)
+		entry = &clientLimiter{limiter: rate.NewLimiter(rate.Every(every), p.config.AccountRequests)}
+		p.accounts[account] = entry
+	}
+	entry.lastSeen = time.Now()
+	if !entry.limiter.Allow() {
+		return 1
+	}
+	return pressure(entry.limiter)
+}
+
+// challenge layout, before signing:
+// version | nonce | expiry (unix seconds) | difficulty | SHA-256 of the client IP
+const powPayloadSize = 1 + powNonceSize + 8 + 1 + sha256.Size
+
+func (p *proofOfWork) issue(client string, difficulty int) (string, time.Time) {
+	expiresAt := time.Now().Add(p.config.TTL).Truncate(time.Second)
+
+	payload := make([]byte, 0, powPayloadSize)
+	payload = append(payload, powVersion)
+	nonce := make([]byte, powNonceSize)
+	_, _ = rand.Read(nonce)
+	payload = append(payload, nonce...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiresAt.Unix()))
+	payload = append(payload, byte(difficulty))
+	clientHash := sha256.Sum256([]byte(client))
+	payload = append(payload, clientHash[:]...)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload)), expiresAt
+}
+
+func (p *proofOfWork) verify(challenge, solution, client string) error {
+	encoded, signature, ok := strings.Cut(challenge, ".")
+	if !ok || solution == "" {
+		return errPoWMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != powPayloadSize || payload[0] != powVersion {
+		return errPoWMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return errPoWSignature
+	}
+
+	offset := 1 + powNonceSize
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[offset:offset+8])), 0)
+	difficulty := int(payload[offset+8])
+	clientHash := sha256.Sum256([]byte(client))
+	switch {
+	case time.Now().After(expiresAt):
+		return errPoWExpired
+	case !bytes.Equal(payload[offset+9:], clientHash[:]):
+		return errPoWClient
+	}
+
+	hash := sha256.Sum256([]byte(challenge + ":" + solution))
+	if leadingZeroBits(hash[:]) < difficulty {
+		return errPoWSolution
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, replayed := p.used[encoded]; replayed {
+		return errPoWReplayed
+	}
+	p.used[encoded] = expiresAt
+	return nil
+}
+
+func (p *proofOfWork) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// cleanup forgets expired challenges and idle accounts
+func (p *proofOfWork) cleanup() {
+	for {
+		time.Sleep(cleanupInterval)
+		now := time.Now()
+		p.mu.Lock()
+		for challenge, expiresAt := range p.used {
+			if now.After(expiresAt) {
+				delete(p.used, challenge)
+			}
+		}
+		for account, entry := range p.accounts {
+			if now.Sub(entry.lastSeen) > cleanupInterval {
+				delete(p.accounts, account)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+func leadingZeroBits(hash []byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
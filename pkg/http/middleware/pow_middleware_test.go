@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProofOfWorkVerify(t *testing.T) {
+	config := ProofOfWorkConfig{Secret: "shared", MinDifficulty: 8, MaxDifficulty: 8, TTL: time.Minute}
+	pow := newProofOfWork(config)
+	challenge, _ := pow.issue("203.0.113.7", 8)
+
+	other := newProofOfWork(ProofOfWorkConfig{Secret: "another", TTL: time.Minute})
+	forged, _ := other.issue("203.0.113.7", 8)
+
+	expiredConfig := config
+	expiredConfig.TTL = -time.Minute
+	expired, _ := newProofOfWork(expiredConfig).issue("203.0.113.7", 8)
+
+	encoded, _, _ := strings.Cut(challenge, ".")
+
+	tests := []struct {
+		name      string
+		challenge string
+		solution  string
+		client    string
+		wantErr   error
+	}{
+		{"no signature", encoded, "1", "203.0.113.7", errPoWMalformed},
+		{"no solution", challenge, "", "203.0.113.7", errPoWMalformed},
+		{"not base64", "!!!." + strings.SplitN(challenge, ".", 2)[1], "1", "203.0.113.7", errPoWMalformed},
+		{"signed with another secret", forged, solve(forged, 8), "203.0.113.7", errPoWSignature},
+		{"expired", expired, solve(expired, 8), "203.0.113.7", errPoWExpired},
+		{"issued to another client", challenge, solve(challenge, 8), "198.51.100.1", errPoWClient},
+		{"wrong solution", challenge, unsolved(challenge, 8), "203.0.113.7", errPoWSolution},
+		{"solved", challenge, solve(challenge, 8), "203.0.113.7", nil},
+		{"replayed", challenge, solve(challenge, 8), "203.0.113.7", errPoWReplayed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := pow.verify(tt.challenge, tt.solution, tt.client); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// instances sharing a secret accept each other's challenges
+	peer := newProofOfWork(config)
+	fresh, _ := pow.issue("203.0.113.7", 8)
+	if err := peer.verify(fresh, solve(fresh, 8), "203.0.113.7"); err != nil {
+		t.Errorf("challenge from a peer instance: %v", err)
+	}
+}
+
+func TestProofOfWorkDifficultyScalesWithPressure(t *testing.T) {
+	pow := newProofOfWork(ProofOfWorkConfig{Threshold: 0.5, MinDifficulty: 16, MaxDifficulty: 22})
+	for pressure, want := range map[float64]int{0.5: 16, 0.75: 19, 1: 22} {
+		if got := pow.difficulty(pressure); got != want {
+			t.Errorf("difficulty(%v) = %d, want %d", pressure, got, want)
+		}
+	}
+
+	full := newProofOfWork(ProofOfWorkConfig{Threshold: 1, MinDifficulty: 16, MaxDifficulty: 22})
+	if got := full.difficulty(1); got != 22 {
+		t.Errorf("difficulty at a threshold of 1 = %d, want 22", got)
+	}
+}
+
+func TestProofOfWorkTracksAccountsAcrossClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var bodies []string
+	r := gin.New()
+	r.POST("/login", ProofOfWork(ProofOfWorkConfig{
+		Enabled:         true,
+		Threshold:       0.4,
+		MinDifficulty:   4,
+		MaxDifficulty:   4,
+		TTL:             time.Minute,
+		AccountRequests: 4,
+		AccountInterval: time.Hour,
+	}), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		bodies = append(bodies, string(body))
+		c.Status(http.StatusOK)
+	})
+
+	login := func(ip, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	body := `{"email":"Ada@Example.com","password":"guess"}`
+	if got := login("203.0.113.1", body); got != http.StatusOK {
+		t.Fatalf("first login: got %d, want 200", got)
+	}
+	if len(bodies) != 1 || bodies[0] != body {
+		t.Errorf("handler read %q, want the original body", bodies)
+	}
+	// the same account from a fresh IP, with different casing
+	if got := login("203.0.113.2", `{"email":" ada@example.com "}`); got != http.StatusPreconditionRequired {
+		t.Errorf("second login from another IP: got %d, want 428", got)
+	}
+	if got := login("203.0.113.3", `{"email":"grace@example.com"}`); got != http.StatusOK {
+		t.Errorf("login for another account: got %d, want 200", got)
+	}
+	if got := login("203.0.113.4", `not json`); got != http.StatusOK {
+		t.Errorf("request without an email: got %d, want 200", got)
+	}
+}
+
+func TestProofOfWorkDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", ProofOfWork(ProofOfWorkConfig{AccountRequests: 1, AccountInterval: time.Hour}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"ada@example.com"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200", i+1, w.Code)
+		}
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		hash []byte
+		want int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x00, 0x00}, 16},
+	}
+	for _, tt := range tests {
+		if got := leadingZeroBits(tt.hash); got != tt.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.hash, got, tt.want)
+		}
+	}
+}
+
+// unsolved returns a solution that misses the difficulty
+func unsolved(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		hash := sha256.Sum256([]byte(challenge + ":" + solution))
+		if leadingZeroBits(hash[:]) < difficulty {
+			return solution
+		}
+	}
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
 
@@ -17,64 +17,138 @@ type clientLimiter struct {
 
 type RateLimitConfig struct {
 	Enabled  bool
-	Requests int           // e.g., 100
-	Interval time.Duration // e.g., 1 * time.Minute
+	Requests int           `validate:"required_if=Enabled true,gte=0"` // e.g., 100
+	Interval time.Duration `validate:"required_if=Enabled true,gte=0"` // e.g., 1 * time.Minute
 }
 
-var (
-	mu              sync.Mutex
-	clientLimiters  = make(map[string]*clientLimiter)
-	cleanupInterval = time.Minute * 5
-)
+// RateLimitPressureKey is the gin context key holding how much of the
+// client's burst allowance is used up, from 0 (idle) to 1 (about to be limited).
+// Later middleware such as ProofOfWork use it as a signal of suspicious volume.
+const RateLimitPressureKey = "rate_limit_pressure"
+
+const cleanupInterval = time.Minute * 5
+
+// RateLimiter limits requests per client IP. Its limits can be changed while
+// it serves requests.
+type RateLimiter struct {
+	mu      sync.Mutex
+	config  RateLimitConfig
+	clients map[string]*clientLimiter
+}
+
+// NewRateLimiter returns a limiter enforcing config
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	l := &RateLimiter{config: config, clients: make(map[string]*clientLimiter)}
+	go l.cleanupStaleClients()
+	return l
+}
+
+// Update replaces the limits. Clients start over with a full allowance under
+// the new limits.
+func (l *RateLimiter) Update(config RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	l.clients = make(map[string]*clientLimiter)
+}
 
 // Middleware
 func RateLimiterMiddleware(config RateLimitConfig) gin.HandlerFunc {
 	if !config.Enabled {
 		return func(c *gin.Context) { c.Next() }
 	}
+	return NewRateLimiter(config).Middleware()
+}
 
-	go cleanupStaleClients()
-
+// Middleware rejects clients over the current limit with 429
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := getClientIP(c)
-
-		limiter := getRateLimiter(ip, config.Requests, config.Interval)
-		if !limiter.Allow() {
+		limiter := l.get(c.ClientIP())
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		now := time.Now()
+		reservation := limiter.ReserveN(now, 1)
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error": "Too many requests",
 			})
 			return
 		}
+		setPressure(c, limiter)
+		// limiters can be stacked, e.g. a stricter one on login routes
+		value, _ := c.Get(rateLimitPendingKey)
+		pendings, _ := value.([]*pendingRequest)
+		c.Set(rateLimitPendingKey, append(pendings, &pendingRequest{reservation: reservation, at: now}))
 		c.Next()
 	}
 }
 
-// Get IP from common headers or remote addr
-func getClientIP(c *gin.Context) string {
-	for _, h := range []string{"X-Forwarded-For", "X-Real-IP", "RemoteAddr"} {
-		if ip := c.GetHeader(h); ip != "" {
-			return strings.Split(ip, ",")[0]
+const rateLimitPendingKey = "rate_limit_pending"
+
+// pendingRequest is the token a request took, which is handed back if
+// ProofOfWork accepts the solution the request carries
+type pendingRequest struct {
+	reservation *rate.Reservation
+	at          time.Time
+	waived      bool
+}
+
+// waiveRateLimit hands the token the current request took back to every
+// limiter it passed, so that solving a challenge does not raise the pressure
+// that asked for it. It must only be called once a solution is verified: a
+// request merely claiming to carry one is counted like any other.
+func waiveRateLimit(c *gin.Context) {
+	value, _ := c.Get(rateLimitPendingKey)
+	pendings, _ := value.([]*pendingRequest)
+	for _, pending := range pendings {
+		if !pending.waived {
+			pending.waived = true
+			// cancelling as of the reservation restores the token
+			pending.reservation.CancelAt(pending.at)
 		}
 	}
-	ip := c.ClientIP()
-	if ip == "" {
-		ip = c.Request.RemoteAddr
+}
+
+// RateLimitPressure returns the pressure recorded by RateLimiterMiddleware, or 0
+// when the request did not pass through it
+func RateLimitPressure(c *gin.Context) float64 {
+	return c.GetFloat64(RateLimitPressureKey)
+}
+
+// setPressure records the pressure of limiter unless a limiter the request
+// passed earlier is under more
+func setPressure(c *gin.Context, limiter *rate.Limiter) {
+	c.Set(RateLimitPressureKey, math.Max(RateLimitPressure(c), pressure(limiter)))
+}
+
+// pressure is the share of the burst already consumed
+func pressure(limiter *rate.Limiter) float64 {
+	burst := float64(limiter.Burst())
+	if burst == 0 {
+		return 1
 	}
-	return ip
+	p := 1 - limiter.Tokens()/burst
+	return math.Min(1, math.Max(0, p))
 }
 
-// Create or retrieve a rate limiter for a given IP
-func getRateLimiter(ip string, rps int, interval time.Duration) *rate.Limiter {
-	mu.Lock()
-	defer mu.Unlock()
+// Create or retrieve the rate limiter of a given IP, nil when limiting is disabled
+func (l *RateLimiter) get(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	limiter, exists := clientLimiters[ip]
+	if !l.config.Enabled {
+		return nil
+	}
+	limiter, exists := l.clients[ip]
 	if !exists {
 		limiter = &clientLimiter{
-			limiter:  rate.NewLimiter(rate.Every(interval/time.Duration(rps)), rps),
+			limiter:  rate.NewLimiter(rate.Every(l.config.Interval/time.Duration(l.config.Requests)), l.config.Requests),
 			lastSeen: time.Now(),
 		}
-		clientLimiters[ip] = limiter
+		l.clients[ip] = limiter
 	} else {
 		limiter.lastSeen = time.Now()
 	}
@@ -83,15 +157,15 @@ func getRateLimiter(ip string, rps int, interval time.Duration) *rate.Limiter {
 }
 
 // Clean up old entries to prevent memory leaks
-func cleanupStaleClients() {
+func (l *RateLimiter) cleanupStaleClients() {
 	for {
 		time.Sleep(cleanupInterval)
-		mu.Lock()
-		for ip, client := range clientLimiters {
+		l.mu.Lock()
+		for ip, client := range l.clients {
 			if time.Since(client.lastSeen) > cleanupInterval {
-				delete(clientLimiters, ip)
+				delete(l.clients, ip)
 			}
 		}
-		mu.Unlock()
+		l.mu.Unlock()
 	}
 }
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newLimitedRouter(limit RateLimitConfig, pow ProofOfWorkConfig, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewRateLimiter(limit).Middleware())
+	r.POST("/login", ProofOfWork(pow), handler)
+	return r
+}
+
+func post(r http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiterCountsBogusChallengesInParallel(t *testing.T) {
+	const burst, requests = 5, 40
+	pow := DefaultProofOfWorkConfig()
+	pow.Enabled = true
+	release := make(chan struct{})
+	r := newLimitedRouter(
+		RateLimitConfig{Enabled: true, Requests: burst, Interval: time.Minute},
+		pow,
+		// hold admitted requests so that all of them are in flight at once
+		func(c *gin.Context) { <-release; c.Status(http.StatusOK) },
+	)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		statuses = map[int]int{}
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := post(r, map[string]string{
+				ProofOfWorkChallengeHeader: "made-up",
+				ProofOfWorkSolutionHeader:  "42",
+			})
+			mu.Lock()
+			statuses[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	// rejected requests return at once; let them finish before releasing the rest
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := statuses[http.StatusTooManyRequests]; got != requests-burst {
+		t.Errorf("got %d responses with 429, want %d: %v", got, requests-burst, statuses)
+	}
+}
+
+func TestRateLimiterRefundsSolvedChallenge(t *testing.T) {
+	pow := ProofOfWorkConfig{Enabled: true, Threshold: 0.4, MinDifficulty: 4, MaxDifficulty: 4, TTL: time.Minute}
+	r := newLimitedRouter(
+		RateLimitConfig{Enabled: true, Requests: 4, Interval: time.Minute},
+		pow,
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	if w := post(r, nil); w.Code != http.StatusOK {
+		t.Fatalf("first request: got %d, want 200", w.Code)
+	}
+	w := post(r, nil)
+	if w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("second request: got %d, want 428", w.Code)
+	}
+	var issued struct {
+		Challenge  string `json:"challenge"`
+		Difficulty int    `json:"difficulty"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+		t.Fatal(err)
+	}
+
+	solved := map[string]string{
+		ProofOfWorkChallengeHeader: issued.Challenge,
+		ProofOfWorkSolutionHeader:  solve(issued.Challenge, issued.Difficulty),
+	}
+	if w := post(r, solved); w.Code != http.StatusOK {
+		t.Fatalf("solved request: got %d, want 200", w.Code)
+	}
+
+	// two tokens are left as if the solved request had not been made
+	for i, want := range []int{http.StatusPreconditionRequired, http.StatusPreconditionRequired, http.StatusTooManyRequests} {
+		if w := post(r, nil); w.Code != want {
+			t.Errorf("request %d after the solved one: got %d, want %d", i+1, w.Code, want)
+		}
+	}
+	// a replayed solution is counted
+	if w := post(r, solved); w.Code != http.StatusTooManyRequests {
+		t.Errorf("replayed solution: got %d, want 429", w.Code)
+	}
+}
+
+func solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		hash := sha256.Sum256([]byte(challenge + ":" + solution))
+		if leadingZeroBits(hash[:]) >= difficulty {
+			return solution
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the ID correlating a request across services and logs
+const RequestIDHeader = "X-Request-ID"
+
+// traceparentHeader is the W3C trace context header,
+// version-traceid-spanid-flags, e.g. 00-4bf9…4736-00f0…02b7-01
+const traceparentHeader = "traceparent"
+
+// validRequestID limits IDs accepted from clients to what is safe to log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestContext attaches log to the request context together with the
+// request ID, the matched route and the trace and span IDs, so that
+// logger.FromContext returns a logger tagging every entry with them. The
+// request ID is taken from X-Request-ID when the client sent a valid one,
+// generated otherwise, and returned in the response header. It should be the
+// first middleware so that everything after it logs with these fields.
+func RequestContext(log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		fields := []zap.Field{
+			zap.String(logger.RequestIDKey, requestID),
+			zap.String(logger.RouteKey, c.Request.Method+" "+route),
+		}
+
+		ctx := c.Request.Context()
+		if span := spanContext(c); span.IsValid() {
+			fields = append(fields,
+				zap.String(logger.TraceIDKey, span.TraceID().String()),
+				zap.String(logger.SpanIDKey, span.SpanID().String()),
+			)
+		}
+
+		ctx = logger.ContextWithFields(logger.WithContext(ctx, log), fields...)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// spanContext is the span of an OpenTelemetry instrumentation that already
+// started one for the request, or the caller's span from traceparent
+func spanContext(c *gin.Context) trace.SpanContext {
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+		return span
+	}
+	parts := strings.Split(c.GetHeader(traceparentHeader), "-")
+	if len(parts) != 4 {
+		return trace.SpanContext{}
+	}
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	spanID, err := trace.SpanIDFromHex(parts[2])
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
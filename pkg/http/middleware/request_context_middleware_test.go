@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// newLoggedRouter serves /users/:id through RequestContext and
+// LoggingMiddleware, logging to a file whose entries it returns
+func newLoggedRouter(t *testing.T, handler gin.HandlerFunc) (*gin.Engine, func() []map[string]any) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := logger.New("test", logger.Config{Level: "debug", Format: logger.FormatJSON, Outputs: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestContext(log), LoggingMiddleware())
+	r.GET("/users/:id", handler)
+
+	return r, func() []map[string]any {
+		log.Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestRequestContextTagsEntries(t *testing.T) {
+	r, entries := newLoggedRouter(t, func(c *gin.Context) {
+		// authentication adds the user once it is known
+		ctx := logger.ContextWithFields(c.Request.Context(), zap.String(logger.UserIDKey, "ada"))
+		c.Request = c.Request.WithContext(ctx)
+		logger.FromContext(ctx).Info("loading user")
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "gateway-7f3a")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "gateway-7f3a" {
+		t.Errorf("%s = %q, want the client's ID echoed", RequestIDHeader, got)
+	}
+	logged := entries()
+	if len(logged) != 2 {
+		t.Fatalf("got %d entries, want the handler's and the request's", len(logged))
+	}
+	for _, entry := range logged {
+		want := map[string]any{
+			logger.RequestIDKey: "gateway-7f3a",
+			logger.RouteKey:     "GET /users/:id",
+			logger.UserIDKey:    "ada",
+			logger.TraceIDKey:   "4bf92f3577b34da6a3ce929d0e0e4736",
+			logger.SpanIDKey:    "00f067aa0ba902b7",
+		}
+		for key, value := range want {
+			if entry[key] != value {
+				t.Errorf("%s: %s = %v, want %v", entry["msg"], key, entry[key], value)
+			}
+		}
+	}
+	if last := logged[1]; last["level"] != "error" || last["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("request logged as %v with status %v, want error and 500", last["level"], last["status"])
+	}
+}
+
+func TestRequestContextGeneratesRequestIDs(t *testing.T) {
+	r, entries := newLoggedRouter(t, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, header := range []string{"", "has spaces", strings.Repeat("a", 129), "bad\x1b[31m"} {
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		req.Header.Set("traceparent", "garbage")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		got := w.Header().Get(RequestIDHeader)
+		if got == header || !validRequestID.MatchString(got) || len(got) != 32 {
+			t.Errorf("header %q: request ID %q, want a generated one", header, got)
+		}
+	}
+	for _, entry := range entries() {
+		if entry["level"] != "debug" || entry[logger.TraceIDKey] != nil {
+			t.Errorf("got %v, want a debug entry without a trace ID", entry)
+		}
+	}
+}
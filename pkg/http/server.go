@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/istiak-004/myFolio-microservices/pkg/logger"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
 )
 
@@ -25,6 +26,9 @@ type ServerConfig struct {
 	ShutdownTimeout time.Duration
 	EnableHTTPS     bool
 	DomainWhitelist []string
+	// TrustedProxies are the IPs and CIDRs of proxies whose X-Forwarded-For
+	// is believed when determining the client IP; none by default
+	TrustedProxies []string
 }
 
 // Server represents an HTTP server
@@ -45,6 +49,12 @@ func NewServer(cfg ServerConfig, logger *logger.Logger) *Server {
 	router.Use(
 		gin.Recovery(), // Handle panics
 	)
+	// gin trusts every proxy unless told otherwise, which would let any
+	// client choose the IP rate limits are keyed on
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies, trusting none", zap.Error(err))
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	// Configure server
 	srv := &http.Server{
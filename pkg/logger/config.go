@@ -0,0 +1,34 @@
+package logger
+
+import "time"
+
+// Formats a Logger writes entries in
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Outputs other than a file path
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Config configures a Logger
+type Config struct {
+	Level       string         `mapstructure:"level" validate:"required,oneof=debug info warn error"`
+	Format      string         `mapstructure:"format" validate:"required,oneof=json console"`
+	Outputs     []string       `mapstructure:"outputs" validate:"required,dive,required"` // stdout, stderr or file paths
+	ErrorOutput string         `mapstructure:"error_output"`                              // also receives every error, e.g. stderr; empty disables
+	Rotation    RotationConfig `mapstructure:"rotation"`                                  // applies to file outputs
+}
+
+// RotationConfig configures when log files are rotated and how many old ones
+// are kept. Rotated files get a timestamp in their name.
+type RotationConfig struct {
+	MaxSizeMB  int           `mapstructure:"max_size_mb" validate:"gte=0"` // 0 selects 100 MB
+	Interval   time.Duration `mapstructure:"interval" validate:"gte=0"`    // also rotate this often, e.g. 24h; 0 rotates by size only
+	MaxAge     time.Duration `mapstructure:"max_age" validate:"gte=0"`     // 0 keeps old files regardless of age
+	MaxBackups int           `mapstructure:"max_backups" validate:"gte=0"` // 0 keeps every old file
+	Compress   bool          `mapstructure:"compress"`                     // gzip rotated files
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Keys of the request-scoped fields set by the request context middleware
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	RouteKey     = "route"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+)
+
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	fieldsContextKey
+)
+
+// WithContext returns a copy of ctx carrying l, which FromContext returns
+// with the fields added to ctx by ContextWithFields
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// ContextWithFields returns a copy of ctx whose logs carry fields in addition
+// to those ctx already carries, e.g. the user ID once a request is authenticated
+func ContextWithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := contextFields(ctx)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(append(merged, existing...), fields...)
+	return context.WithValue(ctx, fieldsContextKey, merged)
+}
+
+// FromContext returns the logger carried by ctx with the request-scoped
+// fields of ctx. Without one it returns a logger that discards everything;
+// code holding its own logger should use Ctx instead.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerContextKey).(*Logger)
+	if !ok {
+		return &Logger{zapLogger: zap.NewNop(), level: zap.NewAtomicLevel()}
+	}
+	return l.Ctx(ctx)
+}
+
+// Ctx returns l with the request-scoped fields of ctx, so that entries logged
+// while serving a request, from a repository say, can be tied to it
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// With returns a logger adding fields to every entry
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	derived := *l
+	derived.zapLogger = l.zapLogger.With(fields...)
+	derived.files = nil // closed by the logger they belong to
+	return &derived
+}
+
+func contextFields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsContextKey).([]zap.Field)
+	return fields
+}
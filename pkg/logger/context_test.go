@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestContextLoggerCarriesRequestFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := New("test", Config{Level: "debug", Format: FormatJSON, Outputs: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithFields(WithContext(context.Background(), log), zap.String(RequestIDKey, "req-1"))
+	FromContext(ctx).Info("received")
+	authenticated := ContextWithFields(ctx, zap.String(UserIDKey, "ada"))
+	FromContext(authenticated).Info("authenticated")
+	// a logger held by a repository picks up the fields too
+	log.Ctx(authenticated).With(zap.String("component", "users")).Info("queried")
+	log.Ctx(context.Background()).Info("background")
+
+	// derived loggers do not own the files
+	if err := FromContext(authenticated).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"received":      {RequestIDKey: "req-1"},
+		"authenticated": {RequestIDKey: "req-1", UserIDKey: "ada"},
+		"queried":       {RequestIDKey: "req-1", UserIDKey: "ada", "component": "users"},
+		"background":    {},
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d entries, want %d:\n%s", len(lines), len(want), data)
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		msg, _ := entry["msg"].(string)
+		for key, value := range want[msg] {
+			if entry[key] != value {
+				t.Errorf("%s: %s = %v, want %s", msg, key, entry[key], value)
+			}
+		}
+		if msg == "background" && entry[RequestIDKey] != nil {
+			t.Errorf("background entry carries a request ID: %s", line)
+		}
+	}
+}
+
+func TestFromContextWithoutLoggerDiscards(t *testing.T) {
+	log := FromContext(ContextWithFields(context.Background(), zap.String(RequestIDKey, "req-1")))
+	log.Info("nobody listens")
+	log.Error("still nobody")
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// rotatingFile is a log file rotated when it reaches its maximum size, by
+// lumberjack, and at every multiple of the interval since the zero time, so
+// a 24h interval rotates at midnight UTC
+type rotatingFile struct {
+	*lumberjack.Logger
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // when the file is rotated next
+}
+
+func newRotatingFile(path string, cfg RotationConfig) (*rotatingFile, error) {
+	// fail at startup rather than on the first entry
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	file.Close()
+
+	f := &rotatingFile{
+		Logger: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     int((cfg.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)), // whole days, rounded up
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		},
+		interval: cfg.Interval,
+	}
+	if f.interval > 0 {
+		f.next = time.Now().Truncate(f.interval).Add(f.interval)
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.interval > 0 {
+		f.mu.Lock()
+		if now := time.Now(); !now.Before(f.next) {
+			f.next = now.Truncate(f.interval).Add(f.interval)
+			if err := f.Logger.Rotate(); err != nil {
+				f.mu.Unlock()
+				return 0, err
+			}
+		}
+		f.mu.Unlock()
+	}
+	return f.Logger.Write(p)
+}
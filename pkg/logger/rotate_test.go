@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backups returns the rotated files next to path
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(path) && strings.HasPrefix(entry.Name(), "app-") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestRotatingFileRotatesOnSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, RotationConfig{Interval: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	if !f.next.After(time.Now()) || !f.next.Equal(f.next.Truncate(24*time.Hour)) {
+		t.Errorf("next rotation at %v, want the coming midnight UTC", f.next)
+	}
+	if _, err := f.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := backups(t, path); len(got) != 0 {
+		t.Fatalf("rotated before the interval passed: %v", got)
+	}
+
+	f.next = time.Now().Add(-time.Second)
+	if _, err := f.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := backups(t, path); len(got) != 1 {
+		t.Errorf("backups = %v, want one", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "after\n" {
+		t.Errorf("current file has %q, want only the entry after rotation", data)
+	}
+	if !f.next.After(time.Now()) {
+		t.Errorf("next rotation at %v, want it rescheduled", f.next)
+	}
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, RotationConfig{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for i := 0; i < 1025; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := backups(t, path); len(got) != 1 {
+		t.Errorf("backups = %v, want one after exceeding 1 MB", got)
+	}
+}
+
+func TestRotationMaxAgeRoundsUpToDays(t *testing.T) {
+	for maxAge, want := range map[time.Duration]int{0: 0, time.Hour: 1, 24 * time.Hour: 1, 168 * time.Hour: 7, 169 * time.Hour: 8} {
+		f, err := newRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotationConfig{MaxAge: maxAge})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.MaxAge != want {
+			t.Errorf("max age %v kept for %d days, want %d", maxAge, f.MaxAge, want)
+		}
+	}
+}
@@ -1,10 +1,11 @@
 package logger
 
 import (
+	"errors"
+	"io"
 	"os"
 	"runtime"
 	"strings"
-	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -12,50 +13,117 @@ import (
 
 type Logger struct {
 	zapLogger   *zap.Logger
+	level       zap.AtomicLevel
 	serviceName string
+	files       []io.Closer // rotating log files, closed by Close
 }
 
-var (
-	instance *Logger
-	once     sync.Once
-)
-
-// NewLogger initializes the logger only once (singleton)
+// NewLogger returns a logger for use before the service config is loaded, and
+// for tools without one: JSON at info level when ENV is production, colored
+// console output at debug level otherwise, always to stdout.
 func NewLogger(serviceName string) *Logger {
-	once.Do(func() {
-		instance = &Logger{
-			zapLogger:   initZapLogger(serviceName),
-			serviceName: serviceName,
+	cfg := Config{Level: "debug", Format: FormatConsole, Outputs: []string{OutputStdout}}
+	if os.Getenv("ENV") == "production" {
+		cfg = Config{Level: "info", Format: FormatJSON, Outputs: []string{OutputStdout}}
+	}
+	logger, err := New(serviceName, cfg)
+	if err != nil {
+		panic("failed to initialize zap logger: " + err.Error())
+	}
+	return logger
+}
+
+// New builds a logger from cfg. Every entry at or above the level goes to each
+// of the outputs; with cfg.ErrorOutput set, errors are also written there.
+// Close the logger to release its files.
+func New(serviceName string, cfg Config) (*Logger, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	l := &Logger{level: level, serviceName: serviceName}
+
+	var cores []zapcore.Core
+	for _, output := range cfg.Outputs {
+		core, err := l.newCore(output, cfg, level)
+		if err != nil {
+			l.Close()
+			return nil, err
 		}
-	})
-	return instance
-}
-
-// initZapLogger configures a zap logger based on environment
-func initZapLogger(serviceName string) *zap.Logger {
-	var cfg zap.Config
-	env := os.Getenv("ENV")
-
-	if env == "production" {
-		cfg = zap.NewProductionConfig()
-		cfg.EncoderConfig.TimeKey = "timestamp"
-		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	} else {
-		cfg = zap.NewDevelopmentConfig()
-		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		cores = append(cores, core)
+	}
+	if cfg.ErrorOutput != "" {
+		core, err := l.newCore(cfg.ErrorOutput, cfg, zap.ErrorLevel)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		cores = append(cores, core)
 	}
 
-	cfg.OutputPaths = []string{"stdout"}
-	cfg.InitialFields = map[string]interface{}{
-		"service": serviceName,
+	l.zapLogger = zap.New(zapcore.NewTee(cores...),
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+		zap.AddStacktrace(zap.ErrorLevel),
+		zap.Fields(zap.String("service", serviceName)),
+	)
+	return l, nil
+}
+
+// newCore writes entries enabled by level to output in the configured format
+func (l *Logger) newCore(output string, cfg Config, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	var (
+		sink     zapcore.WriteSyncer
+		terminal bool
+	)
+	switch output {
+	case OutputStdout:
+		sink, terminal = zapcore.Lock(os.Stdout), true
+	case OutputStderr:
+		sink, terminal = zapcore.Lock(os.Stderr), true
+	default:
+		file, err := newRotatingFile(output, cfg.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		l.files = append(l.files, file)
+		sink = zapcore.AddSync(file)
 	}
+	return zapcore.NewCore(newEncoder(cfg.Format, terminal), sink, level), nil
+}
 
-	logger, err := cfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+// newEncoder returns the encoder of format; console output is colored only
+// on stdout and stderr so files stay free of escape codes
+func newEncoder(format string, terminal bool) zapcore.Encoder {
+	if format == FormatJSON {
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.TimeKey = "timestamp"
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	if terminal {
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+// SetLevel changes the minimum level logged, e.g. "debug" or "warn". It takes
+// effect immediately, for loggers derived with WithComponent too.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
 	if err != nil {
-		panic("failed to initialize zap logger: " + err.Error())
+		return err
 	}
-	return logger
+	l.level.SetLevel(parsed)
+	return nil
+}
+
+// Level returns the minimum level logged
+func (l *Logger) Level() string {
+	return l.level.Level().String()
 }
 
 // WithComponent adds a component field to the logger
@@ -112,6 +180,19 @@ func (l *Logger) Sync() {
 	_ = l.zapLogger.Sync()
 }
 
+// Close flushes the logger and closes its log files. Entries logged after
+// Close reopen the files.
+func (l *Logger) Close() error {
+	if l.zapLogger != nil {
+		l.Sync()
+	}
+	var errs []error
+	for _, file := range l.files {
+		errs = append(errs, file.Close())
+	}
+	return errors.Join(errs...)
+}
+
 // WithFields adds multiple fields to the logger (like logrus.WithFields)
 func (l *Logger) WithFields(fields map[string]interface{}) *zap.Logger {
 	zapFields := make([]zap.Field, 0, len(fields))
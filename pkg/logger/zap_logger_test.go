@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readEntries returns the messages of the JSON entries in a log file
+func readEntries(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry struct {
+			Msg     string `json:"msg"`
+			Service string `json:"service"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		if entry.Service != "test" {
+			t.Errorf("entry %q has service %q, want test", entry.Msg, entry.Service)
+		}
+		messages = append(messages, entry.Msg)
+	}
+	return messages
+}
+
+func TestNewWritesToEveryOutput(t *testing.T) {
+	dir := t.TempDir()
+	app, audit, errs := filepath.Join(dir, "app.log"), filepath.Join(dir, "logs", "audit.log"), filepath.Join(dir, "errors.log")
+	log, err := New("test", Config{Level: "info", Format: FormatJSON, Outputs: []string{app, audit}, ErrorOutput: errs})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	log.Debug("hidden")
+	log.Info("started")
+	log.Error("failed")
+	if err := log.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("details")
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "started,failed,details"
+	for _, path := range []string{app, audit} {
+		if got := strings.Join(readEntries(t, path), ","); got != want {
+			t.Errorf("%s has %s, want %s", filepath.Base(path), got, want)
+		}
+	}
+	if got := strings.Join(readEntries(t, errs), ","); got != "failed" {
+		t.Errorf("error output has %s, want failed", got)
+	}
+	if log.Level() != "debug" {
+		t.Errorf("Level() = %q, want debug", log.Level())
+	}
+}
+
+func TestConsoleFilesHaveNoColors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := New("test", Config{Level: "info", Format: FormatConsole, Outputs: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Warn("careful")
+	log.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "WARN") || strings.Contains(string(data), "\x1b[") {
+		t.Errorf("got %q, want an uncolored WARN entry", data)
+	}
+}
+
+func TestNewRejectsBadConfigs(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"unknown level", Config{Level: "loud", Format: FormatJSON, Outputs: []string{OutputStderr}}},
+		{"output under a file", Config{Level: "info", Format: FormatJSON, Outputs: []string{filepath.Join(blocker, "app.log")}}},
+		{"error output under a file", Config{Level: "info", Format: FormatJSON, Outputs: []string{OutputStderr}, ErrorOutput: filepath.Join(blocker, "errors.log")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New("test", tt.cfg); err == nil {
+				t.Error("New succeeded")
+			}
+		})
+	}
+
+	log, err := New("test", Config{Level: "info", Format: FormatJSON, Outputs: []string{OutputStderr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetLevel("loud"); err == nil || log.Level() != "info" {
+		t.Errorf("SetLevel(loud) = %v, level %s; want an error and info kept", err, log.Level())
+	}
+}
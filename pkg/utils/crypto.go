@@ -5,12 +5,11 @@ import (
 	"encoding/base64"
 	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/istiak-004/myFolio-microservices/pkg/hashing"
 )
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return hashing.Default().Hash(password)
 }
 
 func GenerateUUID() string {
@@ -23,8 +22,8 @@ func GenerateUUID() string {
 }
 
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, err := hashing.Default().Verify(password, hash)
+	return err == nil && ok
 }
 
 func GenerateRandomString(n int) string {